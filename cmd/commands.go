@@ -0,0 +1,994 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"databases2026/configs"
+	"databases2026/internal/booking"
+	"databases2026/internal/handler"
+	"databases2026/internal/holdstore"
+	"databases2026/internal/integrity"
+	"databases2026/internal/migrate"
+	"databases2026/internal/presenter"
+	"databases2026/internal/repository/postgres"
+	"databases2026/internal/seed"
+	"databases2026/internal/service"
+	"databases2026/internal/timetable"
+	"databases2026/pkg/model"
+)
+
+// --- init / drop / reset / seed ---
+
+var initOpts struct {
+	noSeed bool
+}
+
+func initFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&initOpts.noSeed, "no-seed", false, "do not load test data into a freshly created database")
+	seedFlags(fs)
+}
+
+func runInit(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+
+	common := cfg.Common()
+	db, err := handler.InitDataBase(common)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fmt.Printf("✅ Подключено к '%s' БД\n", common.DataBaseName)
+
+	name := cfg.Sports.DataBaseName
+	isExist, err := handler.DbIsExist(db, name)
+	if err != nil {
+		return fmt.Errorf("DbIsExist: %w", err)
+	}
+
+	if !isExist {
+		if err := handler.CreateDataBase(ctx, db, name); err != nil {
+			return err
+		}
+		fmt.Printf("Database %s created\n", name)
+	} else {
+		fmt.Printf("Database %s already exists, applying pending migrations\n", name)
+	}
+
+	dbSportsClub, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer dbSportsClub.Close()
+	fmt.Printf("✅ Подключено к '%s' БД\n", name)
+
+	// Схема — через миграции (0001 — бывший init_db.sql)
+	migrator, err := newMigrator(dbSportsClub)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	// Тестовые данные заливаем только в только что созданную базу
+	if isExist || initOpts.noSeed {
+		return nil
+	}
+	return loadSeed(ctx, dbSportsClub)
+}
+
+var dropOpts struct {
+	missingOK bool
+	yes       bool
+	confirm   string
+	force     bool
+}
+
+func dropFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&dropOpts.missingOK, "missing-ok", false, "do not fail if the database does not exist")
+	fs.BoolVar(&dropOpts.yes, "yes", false, "confirm dropping without a prompt")
+	fs.StringVar(&dropOpts.confirm, "confirm", "", "confirm by repeating the database name")
+	fs.BoolVar(&dropOpts.force, "force", false, "allow dropping a database on a non-local host")
+}
+
+// confirmDrop проверяет защиту от случайного удаления: хост должен быть
+// локальным (или -force), а удаление — подтверждено через -yes, -confirm
+// или вводом имени базы в терминале.
+func confirmDrop(name string) error {
+	host := cfg.Sports.Host
+	if !isLocalHost(host) && !dropOpts.force {
+		return usageErrorf("refusing to drop %s on non-local host %q without -force", name, host)
+	}
+
+	switch {
+	case dropOpts.yes:
+		return nil
+	case dropOpts.confirm != "":
+		if dropOpts.confirm != name {
+			return usageErrorf("-confirm %q does not match database name %q", dropOpts.confirm, name)
+		}
+		return nil
+	}
+
+	if st, err := os.Stdin.Stat(); err != nil || st.Mode()&os.ModeCharDevice == 0 {
+		return usageErrorf("dropping %s requires -yes or -confirm %s", name, name)
+	}
+	fmt.Printf("This will terminate all sessions and drop database %q on %s.\nType the database name to confirm: ", name, host)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(line) != name {
+		return usageErrorf("confirmation does not match, database %s was not dropped", name)
+	}
+	return nil
+}
+
+func isLocalHost(host string) bool {
+	if host == "" || host == "localhost" || strings.HasPrefix(host, "/") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func runDrop(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+
+	db, err := handler.InitDataBase(cfg.Common())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	name := cfg.Sports.DataBaseName
+	isExist, err := handler.DbIsExist(db, name)
+	if err != nil {
+		return fmt.Errorf("DbIsExist: %w", err)
+	}
+	if !isExist {
+		if dropOpts.missingOK {
+			fmt.Printf("Database %s does not exist\n", name)
+			return nil
+		}
+		return fmt.Errorf("database %s does not exist", name)
+	}
+
+	if err := confirmDrop(name); err != nil {
+		return err
+	}
+
+	terminated, err := handler.DropDataBase(ctx, db, name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database %s dropped (%d session(s) terminated)\n", name, terminated)
+	return nil
+}
+
+func resetFlags(fs *flag.FlagSet) {
+	dropFlags(fs)
+	initFlags(fs)
+}
+
+func runReset(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+
+	dropOpts.missingOK = true
+	if err := runDrop(ctx, nil); err != nil {
+		return err
+	}
+	return runInit(ctx, nil)
+}
+
+var seedOpts struct {
+	seed        uint64
+	scale       float64
+	baseDate    string
+	truncate    bool
+	dropIndexes bool
+	counts      map[string]int
+}
+
+func seedFlags(fs *flag.FlagSet) {
+	fs.Uint64Var(&seedOpts.seed, "seed", 1, "random seed; the same seed, scale and counts give the same data")
+	fs.Float64Var(&seedOpts.scale, "scale", 1, "multiplier for the default row counts (1 ≈ 10k users, 50k schedules)")
+	fs.StringVar(&seedOpts.baseDate, "base-date", "",
+		"date (YYYY-MM-DD) the generated history ends at and seat holds expire after; default today, with holds expiring after the load")
+	fs.BoolVar(&seedOpts.truncate, "truncate", false, "empty all tables before loading")
+	fs.BoolVar(&seedOpts.dropIndexes, "drop-indexes", false,
+		"drop secondary indexes of the COPY-loaded tables during the load and recreate them afterwards")
+
+	seedOpts.counts = map[string]int{}
+	for _, table := range seed.Tables {
+		name := strings.ReplaceAll(table, "_", "-")
+		fs.Func(name, "row count for "+table+" (overrides -scale)", func(v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid count %q", v)
+			}
+			seedOpts.counts[table] = n
+			return nil
+		})
+	}
+}
+
+func runSeed(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return loadSeed(ctx, db)
+}
+
+func loadSeed(ctx context.Context, db *sql.DB) error {
+	opts := seed.Options{Seed: seedOpts.seed, Scale: seedOpts.scale, Counts: seedOpts.counts}
+	if seedOpts.baseDate != "" {
+		now, err := time.Parse(time.DateOnly, seedOpts.baseDate)
+		if err != nil {
+			return usageErrorf("invalid -base-date: %v", err)
+		}
+		opts.Now = now
+	}
+	if opts.Scale <= 0 {
+		return usageErrorf("-scale must be positive")
+	}
+
+	g, err := seed.New(opts)
+	if err != nil {
+		return usageErrorf("%v", err)
+	}
+
+	start := time.Now()
+	err = seed.Load(ctx, db, g, seed.LoadOptions{
+		Truncate:    seedOpts.truncate,
+		DropIndexes: seedOpts.dropIndexes,
+		Logf: func(format string, args ...any) {
+			fmt.Printf(format+"\n", args...)
+		},
+	})
+	if err != nil {
+		if errors.Is(err, seed.ErrNotEmpty) {
+			return fmt.Errorf("%w (use -truncate to replace existing data)", err)
+		}
+		return err
+	}
+	fmt.Printf("✅ Test data loaded in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// --- migrate / scripts ---
+
+func newMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	migrations, err := migrate.Load(configs.Migrations())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	m := migrate.New(db, migrations)
+	m.Logf = func(format string, args ...any) {
+		fmt.Printf(format+"\n", args...)
+	}
+	return m, nil
+}
+
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("missing action")
+	}
+	action, rest := args[0], args[1:]
+
+	number := func(def int) (int, error) {
+		switch {
+		case len(rest) == 0 && def >= 0:
+			return def, nil
+		case len(rest) != 1:
+			return 0, usageErrorf("%s expects one number", action)
+		}
+		n, err := strconv.Atoi(rest[0])
+		if err != nil || n < 0 {
+			return 0, usageErrorf("invalid number %q", rest[0])
+		}
+		return n, nil
+	}
+
+	var n int
+	var err error
+	switch action {
+	case "up", "status":
+		if len(rest) > 0 {
+			return usageErrorf("%s takes no arguments", action)
+		}
+	case "down":
+		n, err = number(1)
+	case "goto", "force":
+		n, err = number(-1)
+	default:
+		return usageErrorf("unknown action %q", action)
+	}
+	if err != nil {
+		return err
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		return migrator.Down(ctx, n)
+	case "goto":
+		return migrator.Goto(ctx, n)
+	case "force":
+		return migrator.Force(ctx, n)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if errors.Is(err, migrate.ErrNotInitialized) {
+		fmt.Println("⚠️  not initialised: no schema_migrations table (migrate up creates it)")
+	} else if err != nil {
+		return err
+	}
+	for _, st := range statuses {
+		switch {
+		case st.Missing:
+			fmt.Printf("❓ %04d  applied %s, file missing\n", st.Version, st.AppliedAt.Format(time.DateTime))
+		case st.Applied:
+			fmt.Printf("✅ %s  applied %s\n", st.Migration, st.AppliedAt.Format(time.DateTime))
+		default:
+			fmt.Printf("⏳ %s  pending\n", st.Migration)
+		}
+	}
+	return nil
+}
+
+func runScripts(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		names, err := configs.Scripts()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	for _, name := range args {
+		content, err := configs.ReadScript(name)
+		if err != nil {
+			return err
+		}
+		fmt.Print(content)
+	}
+	return nil
+}
+
+// --- report ---
+
+var reportOpts struct {
+	section string
+}
+
+var reportSections = []string{"all", "aggregate", "window", "join"}
+
+func reportFlags(fs *flag.FlagSet) {
+	fs.StringVar(&reportOpts.section, "section", "all", "which reports to print: all, aggregate, window or join")
+}
+
+func runReport(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+	if !slices.Contains(reportSections, reportOpts.section) {
+		return usageErrorf("unknown section %q", reportOpts.section)
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return businessCases(ctx, db, reportOpts.section)
+}
+
+func businessCases(ctx context.Context, db *sql.DB, section string) error {
+	out := presenter.Text{W: os.Stdout}
+	var errs []error
+
+	if section == "all" || section == "aggregate" {
+		out.Section("📊 Агрегирующие:")
+		errs = append(errs,
+			present(out.TotalRevenue)(service.GetTotalRevenue(ctx, db)),
+			present(out.AvgClassRating)(service.GetAvgClassRating(ctx, db)),
+			present(out.BookingsPerDay)(service.GetBookingsPerDay(ctx, db)),
+			present(out.TopSportsByAttendance)(service.GetTopSportsByAttendance(ctx, db)),
+		)
+	}
+
+	if section == "all" || section == "window" {
+		out.Section("🪟 Оконные функции:")
+		errs = append(errs,
+			present(out.UserRankByLoyalty)(service.GetUserRankByLoyalty(ctx, db)),
+			present(out.RunningTotalRevenue)(service.GetRunningTotalRevenue(ctx, db)),
+			present(out.ClassBookingsWithMovingAvg)(service.GetClassBookingsWithMovingAvg(ctx, db)),
+			present(out.CoachRatingWithRowNumber)(service.GetCoachRatingWithRowNumber(ctx, db)),
+		)
+	}
+
+	if section == "all" || section == "join" {
+		out.Section("🔗 JOIN-запросы:")
+		errs = append(errs,
+			present(out.UsersWithLoyalty)(service.GetUsersWithLoyalty(ctx, db)),
+			present(out.ActiveMemberships)(service.GetActiveMemberships(ctx, db)),
+			present(out.BookingsWithDetails)(service.GetBookingsWithDetails(ctx, db)),
+			present(out.PaymentsWithMembership)(service.GetPaymentsWithMembership(ctx, db)),
+			present(out.ReviewsWithCoachInfo)(service.GetReviewsWithCoachInfo(ctx, db)),
+			present(out.ReferralRewards)(service.GetReferralRewards(ctx, db)),
+			present(out.ScheduleWithRoomAndSport)(service.GetScheduleWithRoomAndSport(ctx, db)),
+			present(out.FullBookingInfo)(service.GetFullBookingInfo(ctx, db)),
+		)
+	}
+
+	return errors.Join(errs...)
+}
+
+// present печатает результат отчёта. Упавший отчёт отмечается на своём
+// месте, ошибка возвращается дальше, а остальные отчёты продолжают
+// выполняться.
+func present[T any](print func(T)) func(T, error) error {
+	return func(result T, err error) error {
+		if err != nil {
+			fmt.Println("❌ failed, see errors below")
+			return err
+		}
+		print(result)
+		return nil
+	}
+}
+
+// --- bench ---
+
+var benchOpts struct {
+	iterations int
+}
+
+func benchFlags(fs *flag.FlagSet) {
+	fs.IntVar(&benchOpts.iterations, "n", 1, "number of CRUD round trips")
+}
+
+func runBench(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+	if benchOpts.iterations < 1 {
+		return usageErrorf("-n must be positive")
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fmt.Printf("✅ Подключено к '%s' БД\n", cfg.Sports.DataBaseName)
+
+	var total, fastest, slowest time.Duration
+	for i := range benchOpts.iterations {
+		start := time.Now()
+		if err := crudTests(ctx, db, i); err != nil {
+			return err
+		}
+		d := time.Since(start)
+		total += d
+		if i == 0 || d < fastest {
+			fastest = d
+		}
+		slowest = max(slowest, d)
+	}
+	fmt.Printf("\n⏱  %d round trips: avg=%s min=%s max=%s\n",
+		benchOpts.iterations, total/time.Duration(benchOpts.iterations), fastest, slowest)
+
+	report, err := handler.Health(ctx, db)
+	if err != nil {
+		return fmt.Errorf("health: %w", err)
+	}
+	fmt.Println("\n🩺 Состояние пула:")
+	fmt.Println(report)
+	return nil
+}
+
+func crudTests(ctx context.Context, db *sql.DB, iteration int) error {
+	// Вся цепочка user → coach → sport → class → room → schedule → booking
+	// выполняется в одной транзакции: при ошибке на любом шаге ничего не останется.
+	return handler.WithTx(ctx, db, nil, func(tx *sql.Tx) error {
+		suffix := fmt.Sprintf("%d-%d", os.Getpid(), iteration)
+
+		userID, err := handler.CreateUser(tx, "bench-"+suffix+"@example.com")
+		if err != nil {
+			return fmt.Errorf("CreateUser: %w", err)
+		}
+
+		err = handler.CreateCoach(tx, userID)
+		if err != nil {
+			return fmt.Errorf("CreateCoach: %w", err)
+		}
+
+		sportID, err := handler.CreateSport(tx, "Pilates "+suffix)
+		if err != nil {
+			return fmt.Errorf("CreateSport: %w", err)
+		}
+
+		classID, err := handler.CreateClass(tx, sportID, userID)
+		if err != nil {
+			return fmt.Errorf("CreateClass: %w", err)
+		}
+
+		roomID, err := handler.CreateRoom(tx, 20)
+		if err != nil {
+			return fmt.Errorf("CreateRoom: %w", err)
+		}
+
+		// Через timetable, как и schedule: при пересечении он называет занятие.
+		sched, err := timetable.New(postgres.NewTx(tx)).Create(ctx, model.Schedule{
+			ClassID: classID, RoomID: roomID, StartTime: time.Now(), EndTime: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			return fmt.Errorf("CreateSchedule: %w", err)
+		}
+		schedID := sched.ID
+
+		bookingID, err := handler.CreateBooking(tx, userID, schedID)
+		if err != nil {
+			return fmt.Errorf("CreateBooking: %w", err)
+		}
+
+		fmt.Printf("Созданы сущности: user=%d, booking=%d\n", userID, bookingID)
+
+		// Очистка
+		for _, step := range []struct {
+			name string
+			fn   func() error
+		}{
+			{"DeleteBooking", func() error { return handler.DeleteBooking(tx, bookingID) }},
+			{"DeleteSchedule", func() error { return handler.DeleteSchedule(tx, schedID) }},
+			{"DeleteRoom", func() error { return handler.DeleteRoom(tx, roomID) }},
+			{"DeleteClass", func() error { return handler.DeleteClass(tx, classID) }},
+			{"DeleteSport", func() error { return handler.DeleteSport(tx, sportID) }},
+			{"DeleteCoach", func() error { return handler.DeleteCoach(tx, userID) }},
+			{"DeleteUser", func() error { return handler.DeleteUser(tx, userID) }},
+		} {
+			if err := step.fn(); err != nil {
+				return fmt.Errorf("%s: %w", step.name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// --- schedule ---
+
+// scheduleLayout — формат -start; время без пояса, как в schedules.
+const scheduleLayout = "2006-01-02 15:04"
+
+var scheduleOpts struct {
+	class    int
+	room     int
+	start    string
+	duration time.Duration
+}
+
+func scheduleFlags(fs *flag.FlagSet) {
+	fs.IntVar(&scheduleOpts.class, "class", 0, "class id")
+	fs.IntVar(&scheduleOpts.room, "room", 0, "room id")
+	fs.StringVar(&scheduleOpts.start, "start", "", "start time, "+scheduleLayout)
+	fs.DurationVar(&scheduleOpts.duration, "duration", 0, "length of the class (default 1h for add, unchanged for move)")
+}
+
+// runSchedule добавляет или переносит занятие через timetable, поэтому
+// пересечение по залу или тренеру называет конфликтующее занятие.
+func runSchedule(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageErrorf("missing action")
+	}
+	action, rest := args[0], args[1:]
+
+	var start time.Time
+	if scheduleOpts.start != "" {
+		var err error
+		if start, err = time.Parse(scheduleLayout, scheduleOpts.start); err != nil {
+			return usageErrorf("invalid -start %q: want %s", scheduleOpts.start, scheduleLayout)
+		}
+	}
+	if scheduleOpts.duration < 0 {
+		return usageErrorf("-duration must be positive")
+	}
+
+	var id int
+	switch action {
+	case "add":
+		if len(rest) > 0 {
+			return usageErrorf("add takes no arguments")
+		}
+		if scheduleOpts.class == 0 || scheduleOpts.room == 0 || start.IsZero() {
+			return usageErrorf("add needs -class, -room and -start")
+		}
+	case "move":
+		var err error
+		if len(rest) != 1 {
+			return usageErrorf("move expects one schedule id")
+		}
+		if id, err = strconv.Atoi(rest[0]); err != nil || id <= 0 {
+			return usageErrorf("invalid schedule id %q", rest[0])
+		}
+		if scheduleOpts.class == 0 && scheduleOpts.room == 0 && start.IsZero() && scheduleOpts.duration == 0 {
+			return usageErrorf("move needs at least one of -class, -room, -start, -duration")
+		}
+	default:
+		return usageErrorf("unknown action %q", action)
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store := postgres.New(db)
+	svc := timetable.New(store)
+
+	var sched model.Schedule
+	if action == "add" {
+		sched, err = svc.Create(ctx, model.Schedule{
+			ClassID:   scheduleOpts.class,
+			RoomID:    scheduleOpts.room,
+			StartTime: start,
+			EndTime:   start.Add(cmp.Or(scheduleOpts.duration, time.Hour)),
+		})
+	} else {
+		sched, err = moveSchedule(ctx, store, svc, id, start)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ schedule %d: class %d, room %d, %s – %s\n", sched.ID, sched.ClassID, sched.RoomID,
+		sched.StartTime.Format(scheduleLayout), sched.EndTime.Format(scheduleLayout))
+	return nil
+}
+
+// moveSchedule меняет занятие по флагам schedule; без -duration длина
+// занятия сохраняется.
+func moveSchedule(
+	ctx context.Context,
+	store *postgres.Store,
+	svc *timetable.Service,
+	id int,
+	start time.Time,
+) (model.Schedule, error) {
+	current, err := store.Schedules().Get(ctx, id)
+	if err != nil {
+		return model.Schedule{}, err
+	}
+	var p handler.SchedulePatch
+	if scheduleOpts.class != 0 {
+		p.ClassID = &scheduleOpts.class
+	}
+	if scheduleOpts.room != 0 {
+		p.RoomID = &scheduleOpts.room
+	}
+	if !start.IsZero() || scheduleOpts.duration != 0 {
+		if start.IsZero() {
+			start = current.StartTime
+		}
+		end := start.Add(cmp.Or(scheduleOpts.duration, current.EndTime.Sub(current.StartTime)))
+		p.StartTime, p.EndTime = &start, &end
+	}
+	return svc.Update(ctx, id, p)
+}
+
+// --- serve ---
+
+var serveOpts struct {
+	addr          string
+	sweepInterval time.Duration
+	holds         string
+}
+
+func serveFlags(fs *flag.FlagSet) {
+	fs.StringVar(&serveOpts.addr, "addr", ":8080", "listen address")
+	fs.DurationVar(&serveOpts.sweepInterval, "sweep-interval", time.Minute,
+		"how often to delete expired seat holds (0 disables the sweeper)")
+	fs.StringVar(&serveOpts.holds, "holds", "postgres",
+		"seat hold store: postgres, memory or redis://[:password@]host[:port][/db]")
+}
+
+// openHoldStore возвращает хранилище временных броней по значению -holds;
+// для postgres — nil: брони хранятся в temp_bookings основной базы.
+func openHoldStore(spec string) (holdstore.Store, func(), error) {
+	switch {
+	case spec == "postgres":
+		return nil, func() {}, nil
+	case spec == "memory":
+		return holdstore.NewMemory(), func() {}, nil
+	case strings.HasPrefix(spec, "redis://"):
+		opts, err := holdstore.ParseRedisURL(spec)
+		if err != nil {
+			return nil, nil, usageErrorf("-holds: %v", err)
+		}
+		r := holdstore.NewRedis(opts)
+		return r, func() { r.Close() }, nil
+	default:
+		return nil, nil, usageErrorf("-holds: unknown hold store %q", spec)
+	}
+}
+
+func runServe(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+
+	holds, closeHolds, err := openHoldStore(serveOpts.holds)
+	if err != nil {
+		return err
+	}
+	defer closeHolds()
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	mux := http.NewServeMux()
+	// /healthz — процесс жив; /readyz — база отвечает.
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		report, err := handler.Health(r.Context(), db)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, report)
+	})
+
+	store := postgres.New(db)
+	if holds == nil {
+		holds = holdstore.NewRepository(store)
+	}
+	if serveOpts.sweepInterval > 0 {
+		svc := booking.NewWithHolds(store, holds)
+		go svc.RunSweeper(ctx, serveOpts.sweepInterval, func(format string, args ...any) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		})
+	}
+
+	srv := &http.Server{Addr: serveOpts.addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	fmt.Printf("Listening on %s\n", serveOpts.addr)
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// --- check ---
+
+var checkOpts struct {
+	format string
+	limit  int
+	only   string
+}
+
+func checkFlags(fs *flag.FlagSet) {
+	var names []string
+	for _, c := range integrity.Checks() {
+		names = append(names, c.Name)
+	}
+	fs.StringVar(&checkOpts.format, "format", "text", "output format: text or json")
+	fs.IntVar(&checkOpts.limit, "limit", 20, "offending row ids to list per check")
+	fs.StringVar(&checkOpts.only, "only", "", "comma-separated data checks to run (default all): "+strings.Join(names, ", "))
+}
+
+// checkReport — итог check; с -format json печатается целиком.
+type checkReport struct {
+	Database          string            `json:"database"`
+	Exists            bool              `json:"exists"`
+	Migrations        int               `json:"migrations"`
+	MigrationProblems []string          `json:"migration_problems"`
+	Integrity         *integrity.Report `json:"integrity"`
+}
+
+func (r checkReport) ok() bool {
+	return r.Exists && len(r.MigrationProblems) == 0 && r.Integrity != nil && r.Integrity.OK()
+}
+
+func runCheck(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		return usageErrorf("unexpected arguments %q", args)
+	}
+	if checkOpts.format != "text" && checkOpts.format != "json" {
+		return usageErrorf("unknown format %q", checkOpts.format)
+	}
+	var only []string
+	for _, name := range strings.Split(checkOpts.only, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(integrity.Checks(), func(c integrity.Check) bool { return c.Name == name }) {
+			return usageErrorf("unknown check %q", name)
+		}
+		only = append(only, name)
+	}
+
+	report, err := collectCheck(ctx, only)
+	if err != nil {
+		return err
+	}
+
+	if checkOpts.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printCheck(report)
+	}
+
+	if !report.ok() {
+		return errCheckFailed
+	}
+	return nil
+}
+
+// collectCheck проверяет наличие базы и миграций, а затем данные. Данные
+// проверяются только на полной схеме, иначе запросы упадут на отсутствующих
+// таблицах.
+func collectCheck(ctx context.Context, only []string) (checkReport, error) {
+	report := checkReport{Database: cfg.Sports.DataBaseName, MigrationProblems: []string{}}
+
+	common, err := handler.InitDataBase(cfg.Common())
+	if err != nil {
+		return report, err
+	}
+	defer common.Close()
+
+	report.Exists, err = handler.DbIsExist(common, report.Database)
+	if err != nil {
+		return report, fmt.Errorf("DbIsExist: %w", err)
+	}
+	if !report.Exists {
+		return report, nil
+	}
+
+	db, err := handler.InitDataBase(cfg.Sports)
+	if err != nil {
+		return report, err
+	}
+	defer db.Close()
+
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return report, err
+	}
+	statuses, err := migrator.Status(ctx)
+	if errors.Is(err, migrate.ErrNotInitialized) {
+		report.MigrationProblems = append(report.MigrationProblems,
+			"database is not initialised: no schema_migrations table (run init or migrate up)")
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	report.Migrations = len(statuses)
+	missing := false
+	for _, st := range statuses {
+		switch {
+		case st.Missing:
+			missing = true
+			report.MigrationProblems = append(report.MigrationProblems,
+				fmt.Sprintf("migration %04d is applied but its file is missing", st.Version))
+		case !st.Applied:
+			report.MigrationProblems = append(report.MigrationProblems,
+				fmt.Sprintf("migration %s is pending", st.Migration))
+		}
+	}
+	// Без всех миграций проверки могут обратиться к несуществующим
+	// таблицам. Выбранные через -only всё же выполняются: так находят
+	// данные, из-за которых миграция не применяется.
+	if len(report.MigrationProblems) > 0 && (missing || len(only) == 0) {
+		return report, nil
+	}
+
+	integrityReport, err := integrity.Run(ctx, db, integrity.Options{Only: only, Limit: checkOpts.limit})
+	if err != nil {
+		return report, err
+	}
+	report.Integrity = &integrityReport
+	return report, nil
+}
+
+func printCheck(r checkReport) {
+	if !r.Exists {
+		fmt.Printf("❌ database %s does not exist\n", r.Database)
+		return
+	}
+	fmt.Printf("✅ database %s exists\n", r.Database)
+
+	for _, problem := range r.MigrationProblems {
+		fmt.Printf("❌ %s\n", problem)
+	}
+	switch {
+	case r.Integrity == nil:
+		fmt.Println("⏭  data checks skipped until migrations are applied")
+		return
+	case len(r.MigrationProblems) == 0:
+		fmt.Printf("✅ all %d migrations applied\n", r.Migrations)
+	}
+
+	for _, res := range r.Integrity.Results {
+		if res.OK() {
+			fmt.Printf("✅ %s\n", res.Check)
+			continue
+		}
+		ids := make([]string, len(res.IDs))
+		for i, id := range res.IDs {
+			ids[i] = strconv.Itoa(id)
+		}
+		more := ""
+		if res.Count > len(res.IDs) {
+			more = ", …"
+		}
+		fmt.Printf("❌ %s: %d row(s) in %s — %s\n   ids: %s%s\n",
+			res.Check, res.Count, res.Table, res.Description, strings.Join(ids, ", "), more)
+	}
+}
@@ -1,223 +1,218 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"time"
 	"os"
-	"flag"
-	"databases2026/pkg/model"
-	"databases2026/internal/handler"
-	"databases2026/internal/service"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
-	_ "github.com/lib/pq"
+	"databases2026/configs"
 )
 
-var commonDb = model.DbConnectionSettings{
-	Host:         "localhost",
-	Port:         5432,
-	User:         "postgres",
-	Password:     "postgres",
-	DataBaseName: "postgres",
-}
-
-var sportsDb = model.DbConnectionSettings{
-	Host:         "localhost",
-	Port:         5432,
-	User:         "postgres",
-	Password:     "postgres",
-	DataBaseName: "sports_club",
-}
-
-func crudTests(db *sql.DB) {
-	userID, err := handler.CreateUser(db, "test78@example.com")
-	if (err != nil) {
-		fmt.Println("CreateUser: ", err)
-		os.Exit(1)
-	}
-
-	err = handler.CreateCoach(db, userID)
-	if (err != nil) {
-		fmt.Println("CreateCoach: ", err)
-		os.Exit(1)
-	}
-
-	sportID, err := handler.CreateSport(db, "Pilates")
-	if (err != nil) {
-		fmt.Println("CreateSport: ", err)
-		os.Exit(1)
-	}
-
-	classID, err := handler.CreateClass(db, sportID, userID)
-	if (err != nil) {
-		fmt.Println("CreateClass: ", err)
-		os.Exit(1)
-	}
+// Коды выхода одинаковы для всех команд, чтобы скрипты могли на них опираться.
+const (
+	exitOK          = 0
+	exitFailure     = 1 // ошибка выполнения: нет связи с БД, ошибка SQL и т.п.
+	exitUsage       = 2 // неизвестная команда, неверные флаги или аргументы
+	exitCheckFailed = 3 // check отработал, но нашёл проблемы
+)
 
-	roomID, err := handler.CreateRoom(db, 20)
-	if (err != nil) {
-		fmt.Println("CreateRoom: ", err)
-		os.Exit(1)
-	}
+var cfg configs.Config
 
-	schedID, err := handler.CreateSchedule(
-		db, classID, roomID, time.Now(), time.Now().Add(time.Hour))
-	if (err != nil) {
-		fmt.Println("CreateSchedule: ", err)
-		os.Exit(1)
-	}
+type command struct {
+	name    string
+	args    string // позиционные аргументы для строки Usage
+	summary string
+	// flags регистрирует флаги команды; флаги подключения добавляет configs.Load.
+	flags func(fs *flag.FlagSet)
+	run   func(ctx context.Context, args []string) error
+}
 
-	bookingID, err := handler.CreateBooking(db, userID, schedID)
-	if (err != nil) {
-		fmt.Println("CreateBooking: ", err)
-		os.Exit(1)
-	}
+// usageError — неверные аргументы команды (код выхода exitUsage).
+type usageError struct{ msg string }
 
-	fmt.Printf("Созданы сущности: user=%d, booking=%d\n", userID, bookingID)
+func (e usageError) Error() string { return e.msg }
 
-	// Очистка
-	handler.DeleteBooking(db, bookingID)
-	handler.DeleteSchedule(db, schedID)
-	handler.DeleteClass(db, classID)
-	handler.DeleteSport(db, sportID)
-	handler.DeleteCoach(db, userID)
-	handler.DeleteUser(db, userID)
+func usageErrorf(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
 }
 
-func businessCases(db *sql.DB) {
-	fmt.Println("\n📊 Агрегирующие:")
-	fmt.Printf("Общий доход: $%.2f\n", service.GetTotalRevenue(db))
-	fmt.Printf("Средний рейтинг: %.2f\n", service.GetAvgClassRating(db))
-	service.GetBookingsPerDay(db)
-	service.GetTopSportsByAttendance(db)
-
-	fmt.Println("\n🪟 Оконные функции:")
-	service.GetUserRankByLoyalty(db)
-	service.GetRunningTotalRevenue(db)
-	service.GetClassBookingsWithMovingAvg(db)
-	service.GetCoachRatingWithRowNumber(db)
-
-	fmt.Println("\n🔗 JOIN-запросы:")
-	service.GetUsersWithLoyalty(db)
-	service.GetActiveMemberships(db)
-	service.GetBookingsWithDetails(db)
-	service.GetPaymentsWithMembership(db)
-	service.GetReviewsWithCoachInfo(db)
-	service.GetReferralRewards(db)
-	service.GetScheduleWithRoomAndSport(db)
-	service.GetFullBookingInfo(db)
+// errCheckFailed возвращает check, если найдены проблемы (код выхода exitCheckFailed).
+var errCheckFailed = errors.New("check failed")
+
+var commands = []command{
+	{
+		name:    "init",
+		summary: "Create the database if missing, apply migrations and load test data into a fresh database",
+		flags:   initFlags,
+		run:     runInit,
+	},
+	{
+		name:    "drop",
+		summary: "Terminate sessions and drop the sports club database (requires -yes or -confirm NAME)",
+		flags:   dropFlags,
+		run:     runDrop,
+	},
+	{
+		name:    "reset",
+		summary: "Drop and re-initialise the database (drop + init, same guards as drop)",
+		flags:   resetFlags,
+		run:     runReset,
+	},
+	{
+		name:    "seed",
+		summary: "Generate test data into an existing database (-scale, -seed, per-table counts)",
+		flags:   seedFlags,
+		run:     runSeed,
+	},
+	{
+		name:    "migrate",
+		args:    "up | down [N] | status | goto VERSION | force VERSION",
+		summary: "Apply, roll back or inspect schema migrations",
+		run:     runMigrate,
+	},
+	{
+		name:    "report",
+		summary: "Print analytical reports",
+		flags:   reportFlags,
+		run:     runReport,
+	},
+	{
+		name:    "bench",
+		summary: "Run a CRUD round trip against the database and print timings and pool state",
+		flags:   benchFlags,
+		run:     runBench,
+	},
+	{
+		name:    "schedule",
+		args:    "add | move ID",
+		summary: "Add or move a class in the timetable, refusing room and coach overlaps",
+		flags:   scheduleFlags,
+		run:     runSchedule,
+	},
+	{
+		name:    "serve",
+		summary: "Serve health and readiness endpoints over HTTP and expire seat holds",
+		flags:   serveFlags,
+		run:     runServe,
+	},
+	{
+		name:    "check",
+		summary: "Check that the database is migrated and run data integrity checks",
+		flags:   checkFlags,
+		run:     runCheck,
+	},
+	{
+		name:    "scripts",
+		args:    "[NAME...]",
+		summary: "List bundled SQL scripts or print the named ones",
+		run:     runScripts,
+	},
 }
 
-func testSportClubDb() {
-	db, err := handler.InitDataBase(sportsDb)
-	if err != nil {
-		fmt.Println("InitDataBase:", err)
-		os.Exit(1)
-	}
-	defer db.Close()
-
-	isExist, err := handler.DbIsExist(db)
-	if err != nil {
-		fmt.Println("DbIsExist:", err)
-		os.Exit(1)
-	}
-
-	if (!isExist) {
-		log.Fatalf("'sports_club' database doesn't exists")
-		os.Exit(1)
-	}
-
-	fmt.Println("✅ Подключено к 'sports_club' БД")
-
-	crudTests(db)
-	businessCases(db)
+func progName() string {
+	return filepath.Base(os.Args[0])
 }
 
-func initSportsDb() {
-	db, err := handler.InitDataBase(commonDb)
-	if err != nil {
-		fmt.Println("InitDataBase:", err)
-		os.Exit(1)
+func printUsage() {
+	out := os.Stderr
+	fmt.Fprintf(out, "Usage: %s <command> [flags] [args]\n\nCommands:\n", progName())
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", c.name, c.summary)
 	}
-	defer db.Close()
-
-	fmt.Println("✅ Подключено к 'postgres' БД")
+	fmt.Fprintf(out, "\nRun '%s help <command>' for command flags.\n", progName())
+}
 
-	isExist, err := handler.DbIsExist(db)
-	if err != nil {
-		fmt.Println("DbIsExist:", err)
-		os.Exit(1)
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
 	}
+	return command{}, false
+}
 
-	if (isExist) {
-		log.Fatalf("'sports_club' database already exists")
-		os.Exit(1)
+func newFlagSet(c command) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s [flags] %s\n\n%s\n\nFlags:\n", progName(), c.name, c.args, c.summary)
+		fs.PrintDefaults()
 	}
-
-	// Создаём базу данных
-	_, err = db.Exec(`
-		CREATE DATABASE sports_club 
-		ENCODING 'UTF8' 
-		LC_COLLATE 'en_US.UTF-8' 
-		LC_CTYPE 'en_US.UTF-8' 
-		TEMPLATE template0;
-	`)
-	if err != nil {
-		log.Fatalf("Failed to create database: %v", err)
-		os.Exit(1)
+	if c.flags != nil {
+		c.flags(fs)
 	}
-	fmt.Println("Database sports_club created")
+	return fs
+}
 
-	// Подключаемся к базе sports_club
-	dbSportsClub, err := handler.InitDataBase(sportsDb)
-	if err != nil {
-		fmt.Println("InitDataBase:", err)
-		os.Exit(1)
-	}
-	defer dbSportsClub.Close()
-	fmt.Println("✅ Подключено к 'sports_club' БД")
-
-	// Функция для выполнения SQL из файла
-	execSQLFile := func(filename string) {
-		content, err := os.ReadFile(filename)
-		if err != nil {
-			log.Fatalf("Failed to read file %s: %v", filename, err)
-			os.Exit(1)
-		}
-		_, err = dbSportsClub.Exec(string(content))
-		if err != nil {
-			log.Fatalf("Failed to execute file %s: %v", filename, err)
-			os.Exit(1)
+func execute(ctx context.Context, args []string) int {
+	if len(args) == 0 {
+		printUsage()
+		return exitUsage
+	}
+
+	name := args[0]
+	switch name {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			c, ok := findCommand(args[1])
+			if !ok {
+				fmt.Fprintf(os.Stderr, "unknown command %q\n", args[1])
+				return exitUsage
+			}
+			fs := newFlagSet(c)
+			configs.Load(fs, []string{"-h"})
+			return exitOK
 		}
-		fmt.Printf("✅ Executed %s successfully\n", filename)
+		printUsage()
+		return exitOK
+	}
+
+	c, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage()
+		return exitUsage
+	}
+
+	fs := newFlagSet(c)
+	var err error
+	cfg, err = configs.Load(fs, args[1:])
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case err != nil:
+		// Ошибку разбора флагов flag уже вывел вместе с Usage, но ошибки
+		// конфиг-файла и окружения — нет.
+		fmt.Fprintln(os.Stderr, "config:", err)
+		return exitUsage
+	}
+
+	err = c.run(ctx, fs.Args())
+	var uerr usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &uerr):
+		fmt.Fprintf(os.Stderr, "%s: %v\n\n", c.name, err)
+		fs.Usage()
+		return exitUsage
+	case errors.Is(err, errCheckFailed):
+		fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+		return exitCheckFailed
+	default:
+		fmt.Fprintf(os.Stderr, "%s: %v\n", c.name, err)
+		return exitFailure
 	}
-
-	// Выполняем init_db.sql
-	execSQLFile("../configs/sql/init_db.sql")
-	// Выполняем generate_3m_bookings.sql
-	execSQLFile("../configs/sql/generate_3m_bookings.sql")
 }
 
 func main() {
-	initFlag := flag.Bool("init", false, "Initialization of 'sports_club' database")
-	testFlag := flag.Bool("test", false, "Test bench with 'sports_club' database")
-	flag.Parse()
-
-	if (!*initFlag && !*testFlag) {
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	if (*initFlag && *testFlag) {
-		fmt.Println("You need to choose one flag!!!!!!")
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	if *initFlag {
-		initSportsDb()
-	} else {
-		testSportClubDb()
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := execute(ctx, os.Args[1:])
+	stop()
+	os.Exit(code)
 }
@@ -0,0 +1,505 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"databases2026/pkg/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config описывает подключения, с которыми работает утилита.
+//
+// Значения собираются по слоям, каждый следующий перекрывает предыдущий:
+// значения по умолчанию -> конфиг-файл (YAML) -> переменные окружения PG* -> флаги.
+type Config struct {
+	// Sports — подключение к рабочей базе sports_club.
+	Sports model.DbConnectionSettings
+	// MaintenanceDataBase — служебная база, через которую создаётся/удаляется sports_club.
+	MaintenanceDataBase string
+}
+
+// Common возвращает настройки подключения к служебной базе (обычно postgres).
+func (c Config) Common() model.DbConnectionSettings {
+	common := c.Sports
+	common.DataBaseName = c.MaintenanceDataBase
+	return common
+}
+
+// ConfigFileEnv — переменная окружения с путём к конфиг-файлу, если не задан -config.
+const ConfigFileEnv = "SPORTS_CONFIG"
+
+func Default() Config {
+	return Config{
+		Sports: model.DbConnectionSettings{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			DataBaseName:    "sports_club",
+			SSLMode:         "disable",
+			ApplicationName: "databases2026",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+			PingAttempts:    5,
+			PingBackoff:     500 * time.Millisecond,
+		},
+		MaintenanceDataBase: "postgres",
+	}
+}
+
+// fileConfig — формат конфиг-файла. Указатели позволяют отличить
+// «не задано» от нулевого значения.
+type fileConfig struct {
+	Host                *string `yaml:"host"`
+	Port                *int    `yaml:"port"`
+	User                *string `yaml:"user"`
+	Password            *string `yaml:"password"`
+	DataBase            *string `yaml:"database"`
+	MaintenanceDataBase *string `yaml:"maintenance_database"`
+
+	URL              *string        `yaml:"url"`
+	SSLMode          *string        `yaml:"sslmode"`
+	SSLRootCert      *string        `yaml:"sslrootcert"`
+	SSLCert          *string        `yaml:"sslcert"`
+	SSLKey           *string        `yaml:"sslkey"`
+	ConnectTimeout   *time.Duration `yaml:"connect_timeout"`
+	StatementTimeout *time.Duration `yaml:"statement_timeout"`
+	SearchPath       *string        `yaml:"search_path"`
+	ApplicationName  *string        `yaml:"application_name"`
+
+	MaxOpenConns    *int           `yaml:"max_open_conns"`
+	MaxIdleConns    *int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime *time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime *time.Duration `yaml:"conn_max_idle_time"`
+	PingAttempts    *int           `yaml:"ping_attempts"`
+	PingBackoff     *time.Duration `yaml:"ping_backoff"`
+}
+
+type flagValues struct {
+	config        string
+	host          string
+	port          int
+	user          string
+	password      string
+	database      string
+	maintenanceDb string
+
+	url              string
+	sslMode          string
+	sslRootCert      string
+	sslCert          string
+	sslKey           string
+	connectTimeout   time.Duration
+	statementTimeout time.Duration
+	searchPath       string
+	applicationName  string
+
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+	pingAttempts    int
+	pingBackoff     time.Duration
+}
+
+// Load регистрирует флаги подключения в fs, разбирает args и собирает Config.
+// Оставшиеся позиционные аргументы доступны через fs.Args().
+func Load(fs *flag.FlagSet, args []string) (Config, error) {
+	var fv flagValues
+	fs.StringVar(&fv.config, "config", "", "path to YAML config file (env "+ConfigFileEnv+")")
+	fs.StringVar(&fv.host, "host", "", "database host (env PGHOST)")
+	fs.IntVar(&fv.port, "port", 0, "database port (env PGPORT)")
+	fs.StringVar(&fv.user, "user", "", "database user (env PGUSER)")
+	fs.StringVar(&fv.password, "password", "", "database password (env PGPASSWORD)")
+	fs.StringVar(&fv.database, "dbname", "", "sports club database name (env PGDATABASE)")
+	fs.StringVar(&fv.maintenanceDb, "maintenance-db", "",
+		"maintenance database used to create/drop the sports club database (env PGMAINTDATABASE)")
+	fs.StringVar(&fv.url, "url", "", "full postgres:// connection URL (env DATABASE_URL)")
+	fs.StringVar(&fv.sslMode, "sslmode", "", "disable, require, verify-ca or verify-full (env PGSSLMODE)")
+	fs.StringVar(&fv.sslRootCert, "sslrootcert", "", "CA certificate file (env PGSSLROOTCERT)")
+	fs.StringVar(&fv.sslCert, "sslcert", "", "client certificate file (env PGSSLCERT)")
+	fs.StringVar(&fv.sslKey, "sslkey", "", "client key file (env PGSSLKEY)")
+	fs.DurationVar(&fv.connectTimeout, "connect-timeout", 0, "connection timeout (env PGCONNECT_TIMEOUT, seconds)")
+	fs.DurationVar(&fv.statementTimeout, "statement-timeout", 0, "server-side statement timeout (env PGSTATEMENT_TIMEOUT)")
+	fs.StringVar(&fv.searchPath, "search-path", "", "schema search_path (env PGSEARCHPATH)")
+	fs.StringVar(&fv.applicationName, "application-name", "", "application_name shown in pg_stat_activity (env PGAPPNAME)")
+	fs.IntVar(&fv.maxOpenConns, "max-open-conns", 0, "maximum open connections in the pool")
+	fs.IntVar(&fv.maxIdleConns, "max-idle-conns", 0, "maximum idle connections in the pool")
+	fs.DurationVar(&fv.connMaxLifetime, "conn-max-lifetime", 0, "maximum lifetime of a pooled connection")
+	fs.DurationVar(&fv.connMaxIdleTime, "conn-max-idle-time", 0, "maximum idle time of a pooled connection")
+	fs.IntVar(&fv.pingAttempts, "ping-attempts", 0, "attempts for the initial ping while the server starts")
+	fs.DurationVar(&fv.pingBackoff, "ping-backoff", 0, "initial delay between ping attempts, doubled each time")
+
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	path := fv.config
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := applyFile(&cfg, path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := applyEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := applyFlags(&cfg, fs, fv); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func applyFile(cfg *Config, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var fc fileConfig
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&fc); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if fc.Host != nil {
+		cfg.Sports.Host = *fc.Host
+	}
+	if fc.Port != nil {
+		cfg.Sports.Port = *fc.Port
+	}
+	if fc.User != nil {
+		cfg.Sports.User = *fc.User
+	}
+	if fc.Password != nil {
+		cfg.Sports.Password = *fc.Password
+	}
+	if fc.DataBase != nil {
+		cfg.Sports.DataBaseName = *fc.DataBase
+	}
+	if fc.MaintenanceDataBase != nil {
+		cfg.MaintenanceDataBase = *fc.MaintenanceDataBase
+	}
+	if fc.URL != nil {
+		if err := applyURL(cfg, *fc.URL); err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+	if fc.SSLMode != nil {
+		cfg.Sports.SSLMode = *fc.SSLMode
+	}
+	if fc.SSLRootCert != nil {
+		cfg.Sports.SSLRootCert = *fc.SSLRootCert
+	}
+	if fc.SSLCert != nil {
+		cfg.Sports.SSLCert = *fc.SSLCert
+	}
+	if fc.SSLKey != nil {
+		cfg.Sports.SSLKey = *fc.SSLKey
+	}
+	if fc.ConnectTimeout != nil {
+		cfg.Sports.ConnectTimeout = *fc.ConnectTimeout
+	}
+	if fc.StatementTimeout != nil {
+		cfg.Sports.StatementTimeout = *fc.StatementTimeout
+	}
+	if fc.SearchPath != nil {
+		cfg.Sports.SearchPath = *fc.SearchPath
+	}
+	if fc.ApplicationName != nil {
+		cfg.Sports.ApplicationName = *fc.ApplicationName
+	}
+	if fc.MaxOpenConns != nil {
+		cfg.Sports.MaxOpenConns = *fc.MaxOpenConns
+	}
+	if fc.MaxIdleConns != nil {
+		cfg.Sports.MaxIdleConns = *fc.MaxIdleConns
+	}
+	if fc.ConnMaxLifetime != nil {
+		cfg.Sports.ConnMaxLifetime = *fc.ConnMaxLifetime
+	}
+	if fc.ConnMaxIdleTime != nil {
+		cfg.Sports.ConnMaxIdleTime = *fc.ConnMaxIdleTime
+	}
+	if fc.PingAttempts != nil {
+		cfg.Sports.PingAttempts = *fc.PingAttempts
+	}
+	if fc.PingBackoff != nil {
+		cfg.Sports.PingBackoff = *fc.PingBackoff
+	}
+
+	return nil
+}
+
+// applyURL раскладывает postgres:// URL по полям настроек, чтобы более
+// приоритетные слои могли перекрыть отдельные части (например, dbname).
+func applyURL(cfg *Config, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid database url: %w", err)
+	}
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return fmt.Errorf("invalid database url scheme %q", u.Scheme)
+	}
+
+	if host := u.Hostname(); host != "" {
+		cfg.Sports.Host = host
+	}
+	if port := u.Port(); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("invalid port in database url: %w", err)
+		}
+		cfg.Sports.Port = p
+	}
+	if u.User != nil {
+		cfg.Sports.User = u.User.Username()
+		if pass, ok := u.User.Password(); ok {
+			cfg.Sports.Password = pass
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		cfg.Sports.DataBaseName = db
+	}
+
+	q := u.Query()
+	for key, dst := range map[string]*string{
+		"sslmode":          &cfg.Sports.SSLMode,
+		"sslrootcert":      &cfg.Sports.SSLRootCert,
+		"sslcert":          &cfg.Sports.SSLCert,
+		"sslkey":           &cfg.Sports.SSLKey,
+		"search_path":      &cfg.Sports.SearchPath,
+		"application_name": &cfg.Sports.ApplicationName,
+	} {
+		if q.Has(key) {
+			*dst = q.Get(key)
+		}
+	}
+	if q.Has("connect_timeout") {
+		secs, err := strconv.Atoi(q.Get("connect_timeout"))
+		if err != nil {
+			return fmt.Errorf("invalid connect_timeout in database url: %w", err)
+		}
+		cfg.Sports.ConnectTimeout = time.Duration(secs) * time.Second
+	}
+	if q.Has("statement_timeout") {
+		ms, err := strconv.Atoi(q.Get("statement_timeout"))
+		if err != nil {
+			return fmt.Errorf("invalid statement_timeout in database url: %w", err)
+		}
+		cfg.Sports.StatementTimeout = time.Duration(ms) * time.Millisecond
+	}
+
+	return nil
+}
+
+func applyEnv(cfg *Config) error {
+	if v, ok := os.LookupEnv("DATABASE_URL"); ok {
+		if err := applyURL(cfg, v); err != nil {
+			return fmt.Errorf("DATABASE_URL: %w", err)
+		}
+	}
+	if v, ok := os.LookupEnv("PGHOST"); ok {
+		cfg.Sports.Host = v
+	}
+	if v, ok := os.LookupEnv("PGPORT"); ok {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid PGPORT %q: %w", v, err)
+		}
+		cfg.Sports.Port = port
+	}
+	if v, ok := os.LookupEnv("PGUSER"); ok {
+		cfg.Sports.User = v
+	}
+	if v, ok := os.LookupEnv("PGPASSWORD"); ok {
+		cfg.Sports.Password = v
+	}
+	if v, ok := os.LookupEnv("PGDATABASE"); ok {
+		cfg.Sports.DataBaseName = v
+	}
+	if v, ok := os.LookupEnv("PGMAINTDATABASE"); ok {
+		cfg.MaintenanceDataBase = v
+	}
+	if v, ok := os.LookupEnv("PGSSLMODE"); ok {
+		cfg.Sports.SSLMode = v
+	}
+	if v, ok := os.LookupEnv("PGSSLROOTCERT"); ok {
+		cfg.Sports.SSLRootCert = v
+	}
+	if v, ok := os.LookupEnv("PGSSLCERT"); ok {
+		cfg.Sports.SSLCert = v
+	}
+	if v, ok := os.LookupEnv("PGSSLKEY"); ok {
+		cfg.Sports.SSLKey = v
+	}
+	if v, ok := os.LookupEnv("PGCONNECT_TIMEOUT"); ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid PGCONNECT_TIMEOUT %q: %w", v, err)
+		}
+		cfg.Sports.ConnectTimeout = time.Duration(secs) * time.Second
+	}
+	if v, ok := os.LookupEnv("PGSTATEMENT_TIMEOUT"); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid PGSTATEMENT_TIMEOUT %q: %w", v, err)
+		}
+		cfg.Sports.StatementTimeout = d
+	}
+	if v, ok := os.LookupEnv("PGSEARCHPATH"); ok {
+		cfg.Sports.SearchPath = v
+	}
+	if v, ok := os.LookupEnv("PGAPPNAME"); ok {
+		cfg.Sports.ApplicationName = v
+	}
+
+	return nil
+}
+
+func applyFlags(cfg *Config, fs *flag.FlagSet, fv flagValues) error {
+	// URL применяется первым, чтобы отдельные флаги могли его уточнить.
+	if fv.url != "" {
+		if err := applyURL(cfg, fv.url); err != nil {
+			return fmt.Errorf("-url: %w", err)
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "host":
+			cfg.Sports.Host = fv.host
+		case "port":
+			cfg.Sports.Port = fv.port
+		case "user":
+			cfg.Sports.User = fv.user
+		case "password":
+			cfg.Sports.Password = fv.password
+		case "dbname":
+			cfg.Sports.DataBaseName = fv.database
+		case "maintenance-db":
+			cfg.MaintenanceDataBase = fv.maintenanceDb
+		case "sslmode":
+			cfg.Sports.SSLMode = fv.sslMode
+		case "sslrootcert":
+			cfg.Sports.SSLRootCert = fv.sslRootCert
+		case "sslcert":
+			cfg.Sports.SSLCert = fv.sslCert
+		case "sslkey":
+			cfg.Sports.SSLKey = fv.sslKey
+		case "connect-timeout":
+			cfg.Sports.ConnectTimeout = fv.connectTimeout
+		case "statement-timeout":
+			cfg.Sports.StatementTimeout = fv.statementTimeout
+		case "search-path":
+			cfg.Sports.SearchPath = fv.searchPath
+		case "application-name":
+			cfg.Sports.ApplicationName = fv.applicationName
+		case "max-open-conns":
+			cfg.Sports.MaxOpenConns = fv.maxOpenConns
+		case "max-idle-conns":
+			cfg.Sports.MaxIdleConns = fv.maxIdleConns
+		case "conn-max-lifetime":
+			cfg.Sports.ConnMaxLifetime = fv.connMaxLifetime
+		case "conn-max-idle-time":
+			cfg.Sports.ConnMaxIdleTime = fv.connMaxIdleTime
+		case "ping-attempts":
+			cfg.Sports.PingAttempts = fv.pingAttempts
+		case "ping-backoff":
+			cfg.Sports.PingBackoff = fv.pingBackoff
+		}
+	})
+
+	return nil
+}
+
+// ValidationError перечисляет все некорректные поля конфигурации разом.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+func (c Config) Validate() error {
+	var problems []string
+
+	if c.Sports.Host == "" {
+		problems = append(problems, "host must not be empty")
+	}
+	if c.Sports.Port < 1 || c.Sports.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d is out of range 1-65535", c.Sports.Port))
+	}
+	if c.Sports.User == "" {
+		problems = append(problems, "user must not be empty")
+	}
+	if c.Sports.DataBaseName == "" {
+		problems = append(problems, "database name must not be empty")
+	}
+	if c.MaintenanceDataBase == "" {
+		problems = append(problems, "maintenance database name must not be empty")
+	}
+	switch c.Sports.SSLMode {
+	case "", "disable", "require", "verify-ca", "verify-full":
+	default:
+		problems = append(problems, fmt.Sprintf("unsupported sslmode %q", c.Sports.SSLMode))
+	}
+	if c.Sports.SSLMode == "disable" && (c.Sports.SSLCert != "" || c.Sports.SSLKey != "" || c.Sports.SSLRootCert != "") {
+		problems = append(problems, "ssl certificates are set but sslmode is disable")
+	}
+	if (c.Sports.SSLCert == "") != (c.Sports.SSLKey == "") {
+		problems = append(problems, "sslcert and sslkey must be set together")
+	}
+	if c.Sports.ConnectTimeout < 0 {
+		problems = append(problems, "connect timeout must not be negative")
+	}
+	if c.Sports.StatementTimeout < 0 {
+		problems = append(problems, "statement timeout must not be negative")
+	}
+	if c.Sports.MaxOpenConns < 0 || c.Sports.MaxIdleConns < 0 {
+		problems = append(problems, "pool sizes must not be negative")
+	}
+	if c.Sports.MaxOpenConns > 0 && c.Sports.MaxIdleConns > c.Sports.MaxOpenConns {
+		problems = append(problems, fmt.Sprintf("max idle conns %d exceeds max open conns %d",
+			c.Sports.MaxIdleConns, c.Sports.MaxOpenConns))
+	}
+	if c.Sports.ConnMaxLifetime < 0 || c.Sports.ConnMaxIdleTime < 0 {
+		problems = append(problems, "connection lifetimes must not be negative")
+	}
+	if c.Sports.PingAttempts < 0 || c.Sports.PingBackoff < 0 {
+		problems = append(problems, "ping attempts and backoff must not be negative")
+	}
+	if c.Sports.DataBaseName != "" && c.Sports.DataBaseName == c.MaintenanceDataBase {
+		problems = append(problems, "database and maintenance database must differ")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
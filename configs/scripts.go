@@ -0,0 +1,50 @@
+package configs
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+)
+
+// SQL-скрипты вшиты в бинарник, поэтому утилита работает из любого каталога.
+//
+//go:embed sql
+var sqlFiles embed.FS
+
+const sqlRoot = "sql"
+
+// Migrations возвращает каталог миграций (для migrate.Load).
+func Migrations() fs.FS {
+	sub, err := fs.Sub(sqlFiles, path.Join(sqlRoot, "migrations"))
+	if err != nil {
+		// Путь фиксирован и проверяется при компиляции go:embed.
+		panic(err)
+	}
+	return sub
+}
+
+// Scripts перечисляет все вшитые .sql-файлы относительно configs/sql,
+// например "migrations/0001_init_db.up.sql".
+func Scripts() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(sqlFiles, sqlRoot, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && path.Ext(p) == ".sql" {
+			names = append(names, p[len(sqlRoot)+1:])
+		}
+		return nil
+	})
+	return names, err
+}
+
+// ReadScript возвращает содержимое скрипта по имени из Scripts.
+func ReadScript(name string) (string, error) {
+	content, err := sqlFiles.ReadFile(path.Join(sqlRoot, name))
+	if err != nil {
+		return "", fmt.Errorf("unknown script %q: %w", name, err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,21 @@
+-- Удаляем таблицы в порядке, обратном созданию (сначала зависимые)
+DROP TABLE IF EXISTS temp_bookings;
+DROP TABLE IF EXISTS system_settings;
+DROP TABLE IF EXISTS audit_logs;
+DROP TABLE IF EXISTS referrals;
+DROP TABLE IF EXISTS loyalty_points;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS promotion_usage;
+DROP TABLE IF EXISTS promotions;
+DROP TABLE IF EXISTS reviews;
+DROP TABLE IF EXISTS attendance_logs;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS user_memberships;
+DROP TABLE IF EXISTS memberships;
+DROP TABLE IF EXISTS bookings;
+DROP TABLE IF EXISTS schedules;
+DROP TABLE IF EXISTS rooms;
+DROP TABLE IF EXISTS classes;
+DROP TABLE IF EXISTS sports;
+DROP TABLE IF EXISTS coaches;
+DROP TABLE IF EXISTS users;
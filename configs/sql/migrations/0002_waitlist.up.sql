@@ -0,0 +1,10 @@
+-- 21. Лист ожидания: очередь на заполненные занятия в порядке id
+CREATE TABLE waitlist (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    schedule_id INT NOT NULL REFERENCES schedules(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (user_id, schedule_id)
+);
+
+CREATE INDEX idx_waitlist_schedule ON waitlist (schedule_id, id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_schedules_class_time;
+DROP TRIGGER IF EXISTS classes_coach_overlap ON classes;
+DROP FUNCTION IF EXISTS classes_coach_overlap();
+DROP TRIGGER IF EXISTS schedules_coach_overlap ON schedules;
+DROP FUNCTION IF EXISTS schedules_coach_overlap();
+ALTER TABLE schedules DROP CONSTRAINT IF EXISTS schedules_room_overlap;
+-- btree_gist не удаляем: расширение могли поставить и для других объектов.
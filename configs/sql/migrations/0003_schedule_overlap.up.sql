@@ -0,0 +1,113 @@
+-- Занятия в одном зале не должны пересекаться по времени.
+-- btree_gist нужен, чтобы сравнивать room_id на равенство в gist-индексе.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Уже пересекающиеся занятия не дадут добавить ограничение, а ошибка ALTER
+-- называет только одну пару. Поэтому сначала считаем их сами (те же
+-- запросы, что у check) и останавливаемся с понятным сообщением.
+DO $$
+DECLARE
+    room_clashes INT;
+    coach_clashes INT;
+BEGIN
+    SELECT count(*) INTO room_clashes FROM (
+        SELECT start_time, max(end_time) OVER (
+            PARTITION BY room_id ORDER BY start_time, id
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS prev_end
+        FROM schedules
+    ) s
+    WHERE start_time < prev_end;
+
+    SELECT count(*) INTO coach_clashes FROM (
+        SELECT s.start_time, max(s.end_time) OVER (
+            PARTITION BY c.coach_id ORDER BY s.start_time, s.id
+            ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS prev_end
+        FROM schedules s
+        JOIN classes c ON c.id = s.class_id
+    ) s
+    WHERE start_time < prev_end;
+
+    IF room_clashes > 0 OR coach_clashes > 0 THEN
+        -- pq показывает только текст ошибки, без HINT, поэтому совет — в нём.
+        RAISE EXCEPTION '% schedules overlap another in the same room and % of the same coach; '
+            'list them with "go run ./cmd check -only schedule_room_overlap,schedule_coach_overlap", '
+            'move or delete them and migrate again', room_clashes, coach_clashes
+            USING ERRCODE = 'exclusion_violation', TABLE = 'schedules';
+    END IF;
+END;
+$$;
+
+ALTER TABLE schedules ADD CONSTRAINT schedules_room_overlap
+    EXCLUDE USING gist (room_id WITH =, tsrange(start_time, end_time) WITH &&);
+
+-- Занятия одного тренера тоже не пересекаются. Тренер задан у класса, а не
+-- у занятия, поэтому exclusion constraint не подходит: проверяют триггеры,
+-- а advisory lock по тренеру не даёт параллельным транзакциям разминуться.
+CREATE FUNCTION schedules_coach_overlap() RETURNS trigger AS $$
+DECLARE
+    coach INT;
+    clash INT;
+BEGIN
+    -- Нарушения внешнего ключа и CHECK сообщат сами ограничения.
+    IF NEW.end_time <= NEW.start_time THEN
+        RETURN NEW;
+    END IF;
+    SELECT coach_id INTO coach FROM classes WHERE id = NEW.class_id;
+    IF coach IS NULL THEN
+        RETURN NEW;
+    END IF;
+    PERFORM pg_advisory_xact_lock(1, coach);
+
+    SELECT s.id INTO clash
+    FROM schedules s
+    JOIN classes c ON c.id = s.class_id
+    WHERE c.coach_id = coach
+      AND s.id <> NEW.id
+      AND tsrange(s.start_time, s.end_time) && tsrange(NEW.start_time, NEW.end_time)
+    LIMIT 1;
+
+    IF clash IS NOT NULL THEN
+        RAISE EXCEPTION 'schedule overlaps schedule % of coach %', clash, coach
+            USING ERRCODE = 'exclusion_violation', TABLE = 'schedules',
+                  CONSTRAINT = 'schedules_coach_overlap';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER schedules_coach_overlap
+    BEFORE INSERT OR UPDATE OF class_id, start_time, end_time ON schedules
+    FOR EACH ROW EXECUTE FUNCTION schedules_coach_overlap();
+
+-- Смена тренера у класса переносит к нему все занятия класса.
+CREATE FUNCTION classes_coach_overlap() RETURNS trigger AS $$
+DECLARE
+    mine INT;
+    clash INT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(1, NEW.coach_id);
+
+    SELECT s.id, o.id INTO mine, clash
+    FROM schedules s
+    JOIN schedules o ON tsrange(o.start_time, o.end_time) && tsrange(s.start_time, s.end_time)
+    JOIN classes c ON c.id = o.class_id
+    WHERE s.class_id = NEW.id
+      AND o.class_id <> NEW.id
+      AND c.coach_id = NEW.coach_id
+    LIMIT 1;
+
+    IF clash IS NOT NULL THEN
+        RAISE EXCEPTION 'schedule % overlaps schedule % of coach %', mine, clash, NEW.coach_id
+            USING ERRCODE = 'exclusion_violation', TABLE = 'schedules',
+                  CONSTRAINT = 'schedules_coach_overlap';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER classes_coach_overlap
+    BEFORE UPDATE OF coach_id ON classes
+    FOR EACH ROW WHEN (OLD.coach_id IS DISTINCT FROM NEW.coach_id)
+    EXECUTE FUNCTION classes_coach_overlap();
+
+CREATE INDEX idx_schedules_class_time ON schedules (class_id, start_time);
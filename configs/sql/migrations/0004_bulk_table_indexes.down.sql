@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_logs_entity;
+DROP INDEX IF EXISTS idx_audit_logs_user_time;
+DROP INDEX IF EXISTS idx_attendance_logs_user_time;
+DROP INDEX IF EXISTS idx_payments_user;
+DROP INDEX IF EXISTS idx_bookings_schedule;
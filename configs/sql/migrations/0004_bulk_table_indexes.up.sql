@@ -0,0 +1,7 @@
+-- Индексы больших таблиц: выборки и ON DELETE CASCADE по пользователю,
+-- подсчёт броней занятия. seed -drop-indexes снимает их на время COPY.
+CREATE INDEX idx_bookings_schedule ON bookings (schedule_id);
+CREATE INDEX idx_payments_user ON payments (user_id);
+CREATE INDEX idx_attendance_logs_user_time ON attendance_logs (user_id, start_time);
+CREATE INDEX idx_audit_logs_user_time ON audit_logs (user_id, performed_at);
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity_type, entity_id);
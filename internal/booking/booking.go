@@ -0,0 +1,217 @@
+// Package booking — запись пользователей на занятия с учётом вместимости
+// зала.
+//
+// Каждая операция выполняется в одной транзакции и начинается с блокировки
+// строки занятия (repository.Schedules.Lock), поэтому конкурентные запросы
+// на последнее место выполняются по очереди и зал не переполняется.
+// Место можно временно удержать (holds.go), а на заполненное занятие —
+// встать в лист ожидания (waitlist.go).
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/holdstore"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+var (
+	ErrFull          = errors.New("class is full")
+	ErrAlreadyBooked = errors.New("already booked")
+	ErrNotConfirmed  = errors.New("booking is not confirmed")
+)
+
+// FullError — на занятие нет мест; errors.Is(err, ErrFull).
+type FullError struct {
+	ScheduleID int
+	RoomID     int
+	Capacity   int
+}
+
+func (e *FullError) Error() string {
+	return fmt.Sprintf("schedule %d: room %d has all %d seats booked", e.ScheduleID, e.RoomID, e.Capacity)
+}
+
+func (e *FullError) Is(target error) bool {
+	return target == ErrFull
+}
+
+type Service struct {
+	store repository.Store
+	holds holdstore.Store
+}
+
+// New создаёт сервис, хранящий временные брони в temp_bookings.
+func New(store repository.Store) *Service {
+	return NewWithHolds(store, holdstore.NewRepository(store))
+}
+
+// NewWithHolds создаёт сервис с отдельным хранилищем временных броней.
+func NewWithHolds(store repository.Store, holds holdstore.Store) *Service {
+	return &Service{store: store, holds: holds}
+}
+
+// holdsIn возвращает хранилище броней для транзакции tx: хранилище в той
+// же базе работает внутри неё, внешнее — само по себе.
+func (s *Service) holdsIn(tx repository.Store) holdstore.Store {
+	if t, ok := s.holds.(holdstore.Transactional); ok {
+		return t.WithTx(tx)
+	}
+	return s.holds
+}
+
+// Availability — занятость зала на занятии. Held — действующие временные
+// брони (holds.go): они занимают место так же, как подтверждённые.
+type Availability struct {
+	ScheduleID int `json:"schedule_id"`
+	Capacity   int `json:"capacity"`
+	Confirmed  int `json:"confirmed"`
+	Held       int `json:"held"`
+}
+
+func (a Availability) Free() int {
+	return max(0, a.Capacity-a.Confirmed-a.Held)
+}
+
+// Availability возвращает число мест, подтверждённых и временных броней
+// занятия.
+func (s *Service) Availability(ctx context.Context, scheduleID int) (Availability, error) {
+	var a Availability
+	err := s.store.InTx(ctx, func(tx repository.Store) error {
+		sched, err := tx.Schedules().Get(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+		a, err = s.availability(ctx, tx, sched)
+		return err
+	})
+	return a, err
+}
+
+func (s *Service) availability(ctx context.Context, tx repository.Store, sched model.Schedule) (Availability, error) {
+	room, err := tx.Rooms().Get(ctx, sched.RoomID)
+	if err != nil {
+		return Availability{}, err
+	}
+	confirmed, err := tx.Bookings().Count(ctx, handler.BookingFilter{
+		ScheduleID: sched.ID,
+		Status:     model.BookingConfirmed,
+	})
+	if err != nil {
+		return Availability{}, err
+	}
+	held, err := s.holdsIn(tx).Count(ctx, sched.ID, now())
+	if err != nil {
+		return Availability{}, err
+	}
+	return Availability{ScheduleID: sched.ID, Capacity: room.Capacity, Confirmed: confirmed, Held: held}, nil
+}
+
+// Book записывает пользователя на занятие. Если мест нет, возвращает
+// *FullError; если пользователь уже записан — ErrAlreadyBooked. Ранее
+// отменённая бронь того же пользователя подтверждается заново, так как
+// пара (user_id, schedule_id) в bookings уникальна. Временная бронь
+// пользователя на это занятие при этом переходит в подтверждённую.
+func (s *Service) Book(ctx context.Context, userID, scheduleID int) (model.Booking, error) {
+	var b model.Booking
+	err := s.store.InTx(ctx, func(tx repository.Store) error {
+		sched, err := tx.Schedules().Lock(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := userBooking(ctx, tx, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && isConfirmed(*existing) {
+			return fmt.Errorf("user %d, schedule %d: %w", userID, scheduleID, ErrAlreadyBooked)
+		}
+		if err := s.releaseUserHolds(ctx, tx, userID, scheduleID); err != nil {
+			return err
+		}
+
+		a, err := s.availability(ctx, tx, sched)
+		if err != nil {
+			return err
+		}
+		if a.Free() == 0 {
+			return &FullError{ScheduleID: sched.ID, RoomID: sched.RoomID, Capacity: a.Capacity}
+		}
+
+		if b, err = confirm(ctx, tx, userID, scheduleID, existing); err != nil {
+			return err
+		}
+		// Записавшемуся напрямую место в очереди больше не нужно.
+		return leaveWaitlist(ctx, tx, userID, scheduleID)
+	})
+	return b, err
+}
+
+// Cancel отменяет подтверждённую бронь; освободившееся место в той же
+// транзакции получает первый в листе ожидания (см. promote).
+func (s *Service) Cancel(ctx context.Context, bookingID int) (model.Booking, error) {
+	var b model.Booking
+	err := s.store.InTx(ctx, func(tx repository.Store) error {
+		current, err := tx.Bookings().Get(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		// Та же блокировка, что и в Book: освободившееся место не должно
+		// достаться параллельной записи раньше, чем отмена зафиксируется.
+		sched, err := tx.Schedules().Lock(ctx, current.ScheduleID)
+		if err != nil {
+			return err
+		}
+		if !isConfirmed(current) {
+			return fmt.Errorf("booking %d: %w", bookingID, ErrNotConfirmed)
+		}
+
+		b, err = tx.Bookings().Update(ctx, bookingID, handler.BookingPatch{Status: ptr(model.BookingCancelled)})
+		if err != nil {
+			return err
+		}
+		_, err = s.promote(ctx, tx, sched)
+		return err
+	})
+	return b, err
+}
+
+// confirm подтверждает бронь пользователя: заново, если она была отменена
+// (existing), иначе создаёт новую.
+func confirm(
+	ctx context.Context,
+	tx repository.Store,
+	userID, scheduleID int,
+	existing *model.Booking,
+) (model.Booking, error) {
+	if existing != nil {
+		return tx.Bookings().Update(ctx, existing.ID, handler.BookingPatch{Status: ptr(model.BookingConfirmed)})
+	}
+	return tx.Bookings().Create(ctx, model.Booking{
+		UserID:     userID,
+		ScheduleID: scheduleID,
+		Status:     ptr(model.BookingConfirmed),
+	})
+}
+
+// userBooking возвращает бронь пользователя на занятие в любом статусе или nil.
+func userBooking(ctx context.Context, tx repository.Store, userID, scheduleID int) (*model.Booking, error) {
+	list, err := tx.Bookings().List(ctx, handler.BookingFilter{UserID: userID, ScheduleID: scheduleID})
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+func isConfirmed(b model.Booking) bool {
+	return b.Status != nil && *b.Status == model.BookingConfirmed
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,455 @@
+package booking_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"databases2026/internal/booking"
+	"databases2026/internal/handler"
+	"databases2026/internal/holdstore"
+	"databases2026/internal/repository"
+	"databases2026/internal/repository/memory"
+	"databases2026/internal/repository/postgres"
+	"databases2026/pkg/model"
+
+	_ "github.com/lib/pq"
+)
+
+// setup создаёт занятие в зале на capacity мест и users пользователей.
+func setup(t *testing.T, capacity, users int) (repository.Store, model.Schedule, []int) {
+	t.Helper()
+	st := memory.New()
+	sched, ids := setupIn(t, st, capacity, users)
+	return st, sched, ids
+}
+
+// setupIn создаёт те же строки в st. Имена уникальны, а в PostgreSQL
+// строки удаляются в конце теста: конкурентные транзакции должны видеть
+// зафиксированные данные, поэтому откатить всё одной транзакцией нельзя.
+func setupIn(t *testing.T, st repository.Store, capacity, users int) (model.Schedule, []int) {
+	t.Helper()
+	ctx := context.Background()
+	noErr := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	prefix := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+
+	ids := make([]int, users)
+	for i := range ids {
+		u, err := st.Users().Create(ctx, model.User{Email: fmt.Sprintf("%s-user%d@example.com", prefix, i)})
+		noErr(err)
+		ids[i] = u.ID
+		t.Cleanup(func() { cleanup(t, st.Users().Delete(ctx, u.ID)) })
+	}
+	coach, err := st.Coaches().Create(ctx, model.Coach{UserID: ids[0]})
+	noErr(err)
+	t.Cleanup(func() { cleanup(t, st.Coaches().Delete(ctx, coach.UserID)) })
+	sport, err := st.Sports().Create(ctx, model.Sport{Name: prefix})
+	noErr(err)
+	t.Cleanup(func() { cleanup(t, st.Sports().Delete(ctx, sport.ID)) })
+	class, err := st.Classes().Create(ctx, model.Class{SportID: sport.ID, CoachID: coach.UserID})
+	noErr(err)
+	t.Cleanup(func() { cleanup(t, st.Classes().Delete(ctx, class.ID)) })
+	room, err := st.Rooms().Create(ctx, model.Room{Capacity: capacity})
+	noErr(err)
+	t.Cleanup(func() { cleanup(t, st.Rooms().Delete(ctx, room.ID)) })
+	start := time.Now().UTC().Add(24 * time.Hour).Truncate(time.Hour)
+	sched, err := st.Schedules().Create(ctx, model.Schedule{
+		ClassID: class.ID, RoomID: room.ID, StartTime: start, EndTime: start.Add(time.Hour),
+	})
+	noErr(err)
+	t.Cleanup(func() { cleanup(t, st.Schedules().Delete(ctx, sched.ID)) })
+	return sched, ids
+}
+
+// cleanup сообщает об ошибке удаления; строки, уже удалённые каскадом,
+// не ошибка.
+func cleanup(t *testing.T, err error) {
+	if err != nil && !errors.Is(err, handler.ErrNotFound) {
+		t.Errorf("cleanup: %v", err)
+	}
+}
+
+// forEachStore выполняет fn на memory и, если TEST_DATABASE_URL указывает
+// на базу с применёнными миграциями, на PostgreSQL: в memory транзакции
+// идут строго по очереди, а в PostgreSQL их разводит блокировка занятия.
+func forEachStore(t *testing.T, fn func(t *testing.T, st repository.Store)) {
+	t.Helper()
+	t.Run("memory", func(t *testing.T) { fn(t, memory.New()) })
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		fn(t, postgres.New(db))
+	})
+}
+
+// parallel вызывает fn(i) для i из [0, n) одновременно и возвращает ошибки.
+func parallel(n int, fn func(i int) error) []error {
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs[i] = fn(i)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func TestBookLastSeatConcurrently(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st repository.Store) {
+		const n = 50
+		ctx := context.Background()
+		sched, users := setupIn(t, st, 1, n)
+		svc := booking.New(st)
+
+		errs := parallel(n, func(i int) error {
+			_, err := svc.Book(ctx, users[i], sched.ID)
+			return err
+		})
+
+		booked := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				booked++
+			case !errors.Is(err, booking.ErrFull):
+				t.Errorf("Book: %v", err)
+			}
+		}
+		if booked != 1 {
+			t.Fatalf("%d bookings succeeded, want 1", booked)
+		}
+		a, err := svc.Availability(ctx, sched.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Confirmed != 1 || a.Free() != 0 {
+			t.Fatalf("availability = %+v", a)
+		}
+	})
+}
+
+// Отмены раздают места очереди в той же транзакции, поэтому параллельная
+// запись не из очереди не получает ни одного места.
+func TestCancelPromotesWaitlistConcurrently(t *testing.T) {
+	const (
+		capacity  = 3
+		waiting   = 5
+		outsiders = 20
+	)
+	ctx := context.Background()
+	st, sched, users := setup(t, capacity, capacity+waiting+outsiders)
+	svc := booking.New(st)
+
+	var bookings []model.Booking
+	for _, u := range users[:capacity] {
+		b, err := svc.Book(ctx, u, sched.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bookings = append(bookings, b)
+	}
+	queue := users[capacity : capacity+waiting]
+	for _, u := range queue {
+		if _, err := svc.Join(ctx, u, sched.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errs := parallel(capacity+outsiders, func(i int) error {
+		if i < capacity {
+			_, err := svc.Cancel(ctx, bookings[i].ID)
+			return err
+		}
+		_, err := svc.Book(ctx, users[capacity+waiting+i-capacity], sched.ID)
+		return err
+	})
+	for i, err := range errs {
+		if i < capacity && err != nil {
+			t.Errorf("Cancel: %v", err)
+		}
+		if i >= capacity && !errors.Is(err, booking.ErrFull) {
+			t.Errorf("outsider Book = %v, want ErrFull", err)
+		}
+	}
+
+	confirmed, err := st.Bookings().List(ctx, handler.BookingFilter{
+		ScheduleID: sched.ID,
+		Status:     model.BookingConfirmed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, b := range confirmed {
+		got = append(got, b.UserID)
+	}
+	slices.Sort(got)
+	if want := queue[:capacity]; !slices.Equal(got, want) {
+		t.Fatalf("confirmed users = %v, want the head of the queue %v", got, want)
+	}
+
+	left, err := svc.Waitlist(ctx, sched.ID, handler.Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != waiting-capacity {
+		t.Fatalf("%d entries left on the waitlist, want %d", len(left), waiting-capacity)
+	}
+
+	for _, b := range confirmed {
+		logs, err := st.AuditLogs().List(ctx, handler.AuditLogFilter{
+			Action:     booking.ActionWaitlistPromoted,
+			EntityType: "booking",
+			EntityID:   b.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(logs) != 1 || logs[0].UserID == nil || *logs[0].UserID != b.UserID {
+			t.Errorf("audit logs for booking %d: %+v", b.ID, logs)
+		}
+		notes, err := st.Notifications().List(ctx, handler.NotificationFilter{UserID: b.UserID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(notes) != 1 {
+			t.Errorf("user %d has %d notifications, want 1", b.UserID, len(notes))
+		}
+	}
+}
+
+func TestHoldTakesLastSeat(t *testing.T) {
+	ctx := context.Background()
+	st, sched, users := setup(t, 1, 2)
+	svc := booking.New(st)
+
+	if _, err := svc.Hold(ctx, users[0], sched.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Hold(ctx, users[0], sched.ID, 0); !errors.Is(err, booking.ErrAlreadyHeld) {
+		t.Fatalf("second Hold = %v, want ErrAlreadyHeld", err)
+	}
+	_, err := svc.Book(ctx, users[1], sched.ID)
+	var full *booking.FullError
+	if !errors.As(err, &full) || full.ScheduleID != sched.ID || full.Capacity != 1 {
+		t.Fatalf("Book of a held seat = %v, want *FullError", err)
+	}
+	a, err := svc.Availability(ctx, sched.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Held != 1 || a.Free() != 0 {
+		t.Fatalf("availability = %+v", a)
+	}
+}
+
+func TestConfirmHold(t *testing.T) {
+	ctx := context.Background()
+	st, sched, users := setup(t, 1, 1)
+	svc := booking.New(st)
+
+	tb, err := svc.Hold(ctx, users[0], sched.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := svc.ConfirmHold(ctx, tb.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.UserID != users[0] || b.ScheduleID != sched.ID || b.Status == nil || *b.Status != model.BookingConfirmed {
+		t.Fatalf("ConfirmHold = %+v", b)
+	}
+	if _, err := svc.ConfirmHold(ctx, tb.Token); !errors.Is(err, handler.ErrNotFound) {
+		t.Fatalf("second ConfirmHold = %v, want ErrNotFound", err)
+	}
+	a, err := svc.Availability(ctx, sched.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Confirmed != 1 || a.Held != 0 {
+		t.Fatalf("availability = %+v", a)
+	}
+}
+
+func TestConfirmExpiredHold(t *testing.T) {
+	ctx := context.Background()
+	st, sched, users := setup(t, 1, 1)
+	svc := booking.New(st)
+
+	tb, err := svc.Hold(ctx, users[0], sched.ID, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if _, err := svc.ConfirmHold(ctx, tb.Token); !errors.Is(err, booking.ErrHoldExpired) {
+		t.Fatalf("ConfirmHold = %v, want ErrHoldExpired", err)
+	}
+}
+
+// confirmedUsers возвращает пользователей с подтверждённой бронью на занятие.
+func confirmedUsers(t *testing.T, st repository.Store, scheduleID int) []int {
+	t.Helper()
+	list, err := st.Bookings().List(context.Background(), handler.BookingFilter{
+		ScheduleID: scheduleID,
+		Status:     model.BookingConfirmed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, b := range list {
+		ids = append(ids, b.UserID)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestReleasePromotesWaitlist(t *testing.T) {
+	ctx := context.Background()
+	st, sched, users := setup(t, 1, 3)
+	svc := booking.New(st)
+
+	tb, err := svc.Hold(ctx, users[0], sched.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users[1:] {
+		if _, err := svc.Join(ctx, u, sched.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := svc.Release(ctx, tb.Token); err != nil {
+		t.Fatal(err)
+	}
+	if got := confirmedUsers(t, st, sched.ID); !slices.Equal(got, users[1:2]) {
+		t.Fatalf("confirmed users = %v, want the head of the queue %v", got, users[1:2])
+	}
+	if err := svc.Release(ctx, tb.Token); !errors.Is(err, handler.ErrNotFound) {
+		t.Fatalf("second Release = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSweepPromotesWaitlist(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	ctx := context.Background()
+	st, sched, users := setup(t, 2, 4)
+	svc := booking.New(st)
+
+	// Одна бронь истекает, другая нет: место освобождается только одно.
+	if _, err := svc.Hold(ctx, users[0], sched.ID, ttl); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Hold(ctx, users[1], sched.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users[2:] {
+		if _, err := svc.Join(ctx, u, sched.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(2 * ttl)
+
+	n, err := svc.Sweep(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("Sweep released %d holds, want 1", n)
+	}
+	if got := confirmedUsers(t, st, sched.ID); !slices.Equal(got, users[2:3]) {
+		t.Fatalf("confirmed users = %v, want the head of the queue %v", got, users[2:3])
+	}
+	if n, err := svc.Sweep(ctx); err != nil || n != 0 {
+		t.Fatalf("second Sweep = %d, %v", n, err)
+	}
+}
+
+func TestRunSweeper(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	st, sched, users := setup(t, 1, 2)
+	svc := booking.New(st)
+
+	if _, err := svc.Hold(ctx, users[0], sched.ID, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Join(ctx, users[1], sched.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	logged := make(chan string, 10)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.RunSweeper(ctx, 5*time.Millisecond, func(format string, args ...any) {
+			logged <- fmt.Sprintf(format, args...)
+		})
+	}()
+	select {
+	case msg := <-logged:
+		if msg != "hold sweeper: released 1 expired holds" {
+			t.Errorf("sweeper logged %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("sweeper did not release the hold")
+	}
+	cancel()
+	<-done
+
+	if got := confirmedUsers(t, st, sched.ID); !slices.Equal(got, users[1:2]) {
+		t.Fatalf("confirmed users = %v, want %v", got, users[1:2])
+	}
+}
+
+// Отказ в ConfirmHold не должен стоить брони во внешнем хранилище: оно не
+// откатывается вместе с транзакцией.
+func TestConfirmHoldKeepsHoldOnFailure(t *testing.T) {
+	ctx := context.Background()
+	st, sched, users := setup(t, 2, 1)
+	holds := holdstore.NewMemory()
+	svc := booking.NewWithHolds(st, holds)
+
+	tb, err := svc.Hold(ctx, users[0], sched.ID, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Bookings().Create(ctx, model.Booking{
+		UserID: users[0], ScheduleID: sched.ID, Status: ptr(model.BookingConfirmed),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := svc.ConfirmHold(ctx, tb.Token); !errors.Is(err, booking.ErrAlreadyBooked) {
+		t.Fatalf("ConfirmHold = %v, want ErrAlreadyBooked", err)
+	}
+	if _, err := holds.Get(ctx, tb.Token); err != nil {
+		t.Fatalf("hold after a failed confirm: %v", err)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,251 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+// Временные брони: Hold удерживает место на ttl и выдаёт токен,
+// ConfirmHold по токену превращает его в бронь, Release отпускает раньше
+// срока, а Sweep удаляет истёкшие. Пока бронь действует, место занято
+// (Availability.Held). Брони лежат в holdstore.Store: с хранилищем в той же
+// базе (по умолчанию temp_bookings) всё происходит в одной транзакции; с
+// внешним (Redis, память) порядок операций общий — сначала блокировка
+// занятия в базе и проверки, потом изменение брони, — поэтому место
+// по-прежнему не раздаётся дважды, а бронь теряется, только если не
+// удался сам коммит.
+
+// DefaultHoldTTL — срок временной брони, если ttl не задан.
+const DefaultHoldTTL = 10 * time.Minute
+
+var (
+	ErrAlreadyHeld = errors.New("seat already held")
+	ErrHoldExpired = errors.New("hold expired")
+)
+
+// now — текущее время в UTC: expires_at хранится как TIMESTAMP без пояса,
+// поэтому время записи и сравнения должно быть в одном поясе.
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// Hold удерживает место на занятии на ttl (DefaultHoldTTL, если ttl <= 0).
+// Если мест нет, возвращает *FullError; если у пользователя уже есть
+// бронь — ErrAlreadyBooked, действующая временная бронь — ErrAlreadyHeld.
+func (s *Service) Hold(ctx context.Context, userID, scheduleID int, ttl time.Duration) (model.TempBooking, error) {
+	if ttl <= 0 {
+		ttl = DefaultHoldTTL
+	}
+	token, err := newToken()
+	if err != nil {
+		return model.TempBooking{}, err
+	}
+
+	var tb model.TempBooking
+	err = s.store.InTx(ctx, func(tx repository.Store) error {
+		sched, err := tx.Schedules().Lock(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := userBooking(ctx, tx, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && isConfirmed(*existing) {
+			return fmt.Errorf("user %d, schedule %d: %w", userID, scheduleID, ErrAlreadyBooked)
+		}
+
+		t := now()
+		holds := s.holdsIn(tx)
+		active, err := holds.Active(ctx, scheduleID, t)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(active, func(h model.TempBooking) bool { return h.UserID == userID }) {
+			return fmt.Errorf("user %d, schedule %d: %w", userID, scheduleID, ErrAlreadyHeld)
+		}
+
+		a, err := s.availability(ctx, tx, sched)
+		if err != nil {
+			return err
+		}
+		if a.Free() == 0 {
+			return &FullError{ScheduleID: sched.ID, RoomID: sched.RoomID, Capacity: a.Capacity}
+		}
+
+		tb, err = holds.Put(ctx, model.TempBooking{
+			UserID:     userID,
+			ScheduleID: scheduleID,
+			ExpiresAt:  t.Add(ttl),
+			Token:      token,
+		})
+		return err
+	})
+	return tb, err
+}
+
+// ConfirmHold превращает временную бронь в подтверждённую и удаляет её.
+// Истёкшая бронь не подтверждается (ErrHoldExpired), даже если Sweep её
+// ещё не удалил.
+func (s *Service) ConfirmHold(ctx context.Context, token string) (model.Booking, error) {
+	var b model.Booking
+	err := s.store.InTx(ctx, func(tx repository.Store) error {
+		holds := s.holdsIn(tx)
+		tb, err := holds.Get(ctx, token)
+		if err != nil {
+			return err
+		}
+		sched, err := tx.Schedules().Lock(ctx, tb.ScheduleID)
+		if err != nil {
+			return err
+		}
+		if !tb.ExpiresAt.After(now()) {
+			return fmt.Errorf("temp booking %d: %w", tb.ID, ErrHoldExpired)
+		}
+
+		// Проверки идут до удаления: внешнее хранилище не откатывается
+		// вместе с транзакцией, и отказ не должен стоить пользователю брони.
+		existing, err := userBooking(ctx, tx, tb.UserID, tb.ScheduleID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && isConfirmed(*existing) {
+			return fmt.Errorf("user %d, schedule %d: %w", tb.UserID, tb.ScheduleID, ErrAlreadyBooked)
+		}
+
+		// Место держит сама бронь, и a её ещё учитывает (Free() тут не
+		// годится: он не бывает меньше нуля), но вместимость зала могли
+		// уменьшить.
+		a, err := s.availability(ctx, tx, sched)
+		if err != nil {
+			return err
+		}
+		if a.Confirmed+a.Held > a.Capacity {
+			return &FullError{ScheduleID: sched.ID, RoomID: sched.RoomID, Capacity: a.Capacity}
+		}
+
+		// Удаление после блокировки: если бронь успели отпустить или
+		// подтвердить параллельно, здесь будет ErrNotFound.
+		if err := holds.Delete(ctx, token); err != nil {
+			return err
+		}
+		if b, err = confirm(ctx, tx, tb.UserID, tb.ScheduleID, existing); err != nil {
+			return err
+		}
+		return leaveWaitlist(ctx, tx, tb.UserID, tb.ScheduleID)
+	})
+	return b, err
+}
+
+// Release отпускает временную бронь; освободившееся место получает первый
+// в листе ожидания.
+func (s *Service) Release(ctx context.Context, token string) error {
+	return s.store.InTx(ctx, func(tx repository.Store) error {
+		holds := s.holdsIn(tx)
+		tb, err := holds.Get(ctx, token)
+		if err != nil {
+			return err
+		}
+		sched, err := tx.Schedules().Lock(ctx, tb.ScheduleID)
+		if err != nil {
+			return err
+		}
+		if err := holds.Delete(ctx, token); err != nil {
+			return err
+		}
+		_, err = s.promote(ctx, tx, sched)
+		return err
+	})
+}
+
+// Sweep удаляет истёкшие временные брони и отдаёт освободившиеся места
+// листу ожидания; каждое занятие — в своей транзакции, чтобы не держать
+// блокировки многих занятий сразу. Возвращает число удалённых броней.
+func (s *Service) Sweep(ctx context.Context) (int, error) {
+	swept, err := s.holds.Sweep(ctx, now())
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for scheduleID, n := range swept {
+		total += n
+		err := s.store.InTx(ctx, func(tx repository.Store) error {
+			sched, err := tx.Schedules().Lock(ctx, scheduleID)
+			if err != nil {
+				return err
+			}
+			_, err = s.promote(ctx, tx, sched)
+			return err
+		})
+		// Брони могли остаться от удалённого занятия: у temp_bookings нет
+		// внешних ключей.
+		if err != nil && !errors.Is(err, handler.ErrNotFound) {
+			return total, fmt.Errorf("schedule %d: %w", scheduleID, err)
+		}
+	}
+	return total, nil
+}
+
+// RunSweeper вызывает Sweep каждые every, пока не отменён ctx. Ошибки и
+// число удалённых броней передаются в logf (nil — без вывода); после
+// ошибки работа продолжается со следующего тика.
+func (s *Service) RunSweeper(ctx context.Context, every time.Duration, logf func(format string, args ...any)) {
+	if logf == nil {
+		logf = func(string, ...any) {}
+	}
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n, err := s.Sweep(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			logf("hold sweeper: %v", err)
+		case n > 0:
+			logf("hold sweeper: released %d expired holds", n)
+		}
+	}
+}
+
+// newToken — 64 шестнадцатеричных символа, ровно под temp_bookings.token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate hold token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// releaseUserHolds удаляет действующие временные брони пользователя на
+// занятие.
+func (s *Service) releaseUserHolds(ctx context.Context, tx repository.Store, userID, scheduleID int) error {
+	holds := s.holdsIn(tx)
+	active, err := holds.Active(ctx, scheduleID, now())
+	if err != nil {
+		return err
+	}
+	for _, tb := range active {
+		if tb.UserID != userID {
+			continue
+		}
+		if err := holds.Delete(ctx, tb.Token); err != nil && !errors.Is(err, handler.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
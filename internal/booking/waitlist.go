@@ -0,0 +1,138 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+// Лист ожидания: на заполненное занятие пользователь встаёт в очередь
+// (таблица waitlist, порядок — по id), а при отмене брони место сразу
+// получает первый в очереди.
+
+var (
+	ErrNotFull        = errors.New("class has free seats")
+	ErrAlreadyWaiting = errors.New("already on the waitlist")
+)
+
+// ActionWaitlistPromoted — действие в audit_logs при переводе из листа
+// ожидания в брони; entity_id — id брони.
+const ActionWaitlistPromoted = "waitlist_promoted"
+
+// Join ставит пользователя в конец листа ожидания. Встать в очередь можно
+// только на заполненное занятие: если места есть, возвращает ErrNotFull.
+func (s *Service) Join(ctx context.Context, userID, scheduleID int) (model.WaitlistEntry, error) {
+	var w model.WaitlistEntry
+	err := s.store.InTx(ctx, func(tx repository.Store) error {
+		sched, err := tx.Schedules().Lock(ctx, scheduleID)
+		if err != nil {
+			return err
+		}
+
+		existing, err := userBooking(ctx, tx, userID, scheduleID)
+		if err != nil {
+			return err
+		}
+		if existing != nil && isConfirmed(*existing) {
+			return fmt.Errorf("user %d, schedule %d: %w", userID, scheduleID, ErrAlreadyBooked)
+		}
+
+		a, err := s.availability(ctx, tx, sched)
+		if err != nil {
+			return err
+		}
+		if a.Free() > 0 {
+			return fmt.Errorf("schedule %d: %d free seats: %w", scheduleID, a.Free(), ErrNotFull)
+		}
+
+		queued, err := tx.Waitlist().List(ctx, handler.WaitlistFilter{UserID: userID, ScheduleID: scheduleID})
+		if err != nil {
+			return err
+		}
+		if len(queued) > 0 {
+			return fmt.Errorf("user %d, schedule %d: %w", userID, scheduleID, ErrAlreadyWaiting)
+		}
+
+		w, err = tx.Waitlist().Create(ctx, model.WaitlistEntry{UserID: userID, ScheduleID: scheduleID})
+		return err
+	})
+	return w, err
+}
+
+// Leave убирает запись из листа ожидания.
+func (s *Service) Leave(ctx context.Context, entryID int) error {
+	return s.store.Waitlist().Delete(ctx, entryID)
+}
+
+// Waitlist возвращает очередь на занятие, первым — следующий на запись.
+func (s *Service) Waitlist(ctx context.Context, scheduleID int, page handler.Page) ([]model.WaitlistEntry, error) {
+	return s.store.Waitlist().List(ctx, handler.WaitlistFilter{ScheduleID: scheduleID, Page: page})
+}
+
+// promote раздаёт свободные места занятия по очереди: запись уходит из
+// листа ожидания, пользователь получает подтверждённую бронь, в audit_logs
+// пишется ActionWaitlistPromoted, а в notifications — уведомление.
+// Вызывается внутри транзакции, заблокировавшей занятие.
+func (s *Service) promote(ctx context.Context, tx repository.Store, sched model.Schedule) ([]model.Booking, error) {
+	var promoted []model.Booking
+	for {
+		a, err := s.availability(ctx, tx, sched)
+		if err != nil || a.Free() == 0 {
+			return promoted, err
+		}
+		next, err := tx.Waitlist().List(ctx, handler.WaitlistFilter{
+			ScheduleID: sched.ID,
+			Page:       handler.Page{Limit: 1},
+		})
+		if err != nil || len(next) == 0 {
+			return promoted, err
+		}
+
+		w := next[0]
+		if err := tx.Waitlist().Delete(ctx, w.ID); err != nil {
+			return promoted, err
+		}
+		existing, err := userBooking(ctx, tx, w.UserID, sched.ID)
+		if err != nil {
+			return promoted, err
+		}
+		if existing != nil && isConfirmed(*existing) {
+			continue
+		}
+
+		b, err := confirm(ctx, tx, w.UserID, sched.ID, existing)
+		if err != nil {
+			return promoted, err
+		}
+		if _, err := tx.AuditLogs().Log(ctx, model.AuditLog{
+			UserID:     ptr(w.UserID),
+			Action:     ActionWaitlistPromoted,
+			EntityType: ptr("booking"),
+			EntityID:   ptr(b.ID),
+		}); err != nil {
+			return promoted, err
+		}
+		if _, err := tx.Notifications().Create(ctx, model.Notification{UserID: w.UserID}); err != nil {
+			return promoted, err
+		}
+		promoted = append(promoted, b)
+	}
+}
+
+// leaveWaitlist убирает пользователя из очереди на занятие, если он в ней.
+func leaveWaitlist(ctx context.Context, tx repository.Store, userID, scheduleID int) error {
+	queued, err := tx.Waitlist().List(ctx, handler.WaitlistFilter{UserID: userID, ScheduleID: scheduleID})
+	if err != nil {
+		return err
+	}
+	for _, w := range queued {
+		if err := tx.Waitlist().Delete(ctx, w.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"time"
+
+	"databases2026/pkg/model"
+
+	"github.com/lib/pq"
+)
+
+// Массовая загрузка через COPY FROM STDIN для самых объёмных таблиц.
+// Строки читаются из итератора по одной, поэтому объём загрузки не
+// ограничен памятью. COPY работает только внутри транзакции (см. WithTx).
+
+const defaultProgressEvery = 100_000
+
+type CopyOptions struct {
+	// KeepIDs копирует id из строк; иначе id выдаёт последовательность.
+	// После загрузки с KeepIDs последовательность выставляется на max(id).
+	KeepIDs bool
+	// DropIndexes удаляет вторичные индексы таблицы на время загрузки и
+	// создаёт их заново после неё. Индексы PRIMARY KEY и UNIQUE остаются:
+	// на них держатся ограничения.
+	DropIndexes bool
+	// Progress вызывается каждые ProgressEvery строк (по умолчанию 100 000);
+	// итог загрузки возвращает сама функция Copy*.
+	Progress      func(CopyStats)
+	ProgressEvery int
+}
+
+type CopyStats struct {
+	Table   string
+	Rows    int
+	Elapsed time.Duration
+}
+
+// RowsPerSecond — средняя скорость загрузки.
+func (s CopyStats) RowsPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Rows) / s.Elapsed.Seconds()
+}
+
+func (s CopyStats) String() string {
+	return fmt.Sprintf("%s: %d rows in %s (%.0f rows/s)",
+		s.Table, s.Rows, s.Elapsed.Round(time.Millisecond), s.RowsPerSecond())
+}
+
+// Незаполненные необязательные поля при COPY стали бы NULL, а не DEFAULT,
+// поэтому умолчания схемы подставляются здесь.
+
+// --- 1. users ---
+func CopyUsers(ctx context.Context, tx *sql.Tx, rows iter.Seq[model.User], opts CopyOptions) (CopyStats, error) {
+	return copyRows(ctx, tx, "users", []string{"email"}, rows, opts,
+		func(u model.User) (int, []any) { return u.ID, []any{u.Email} })
+}
+
+// --- 7. bookings ---
+func CopyBookings(ctx context.Context, tx *sql.Tx, rows iter.Seq[model.Booking], opts CopyOptions) (CopyStats, error) {
+	return copyRows(ctx, tx, "bookings", []string{"user_id", "schedule_id", "status"}, rows, opts,
+		func(b model.Booking) (int, []any) {
+			return b.ID, []any{b.UserID, b.ScheduleID, orDefault(b.Status, model.BookingConfirmed)}
+		})
+}
+
+// --- 10. payments ---
+func CopyPayments(ctx context.Context, tx *sql.Tx, rows iter.Seq[model.Payment], opts CopyOptions) (CopyStats, error) {
+	return copyRows(ctx, tx, "payments", []string{"user_id", "amount", "status"}, rows, opts,
+		func(p model.Payment) (int, []any) {
+			return p.ID, []any{p.UserID, p.Amount, orDefault(p.Status, model.PaymentCompleted)}
+		})
+}
+
+// --- 11. attendance_logs ---
+func CopyAttendanceLogs(
+	ctx context.Context, tx *sql.Tx, rows iter.Seq[model.AttendanceLog], opts CopyOptions,
+) (CopyStats, error) {
+	return copyRows(ctx, tx, "attendance_logs", []string{"user_id", "start_time", "end_time"}, rows, opts,
+		func(a model.AttendanceLog) (int, []any) { return a.ID, []any{a.UserID, a.StartTime, a.EndTime} })
+}
+
+// --- 18. audit_logs ---
+func CopyAuditLogs(ctx context.Context, tx *sql.Tx, rows iter.Seq[model.AuditLog], opts CopyOptions) (CopyStats, error) {
+	// DEFAULT NOW() — время начала транзакции; ближайший аналог — начало загрузки.
+	now := time.Now()
+	return copyRows(ctx, tx, "audit_logs",
+		[]string{"user_id", "action", "entity_type", "entity_id", "performed_at"}, rows, opts,
+		func(a model.AuditLog) (int, []any) {
+			return a.ID, []any{a.UserID, a.Action, a.EntityType, a.EntityID, orDefault(a.PerformedAt, now)}
+		})
+}
+
+func orDefault[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+func copyRows[T any](
+	ctx context.Context,
+	tx *sql.Tx,
+	table string,
+	columns []string,
+	rows iter.Seq[T],
+	opts CopyOptions,
+	values func(T) (id int, vals []any),
+) (CopyStats, error) {
+	stats := CopyStats{Table: table}
+	start := time.Now()
+
+	every := opts.ProgressEvery
+	if every <= 0 {
+		every = defaultProgressEvery
+	}
+
+	var indexes []string
+	if opts.DropIndexes {
+		var err error
+		if indexes, err = dropIndexes(ctx, tx, table); err != nil {
+			return stats, err
+		}
+	}
+
+	if opts.KeepIDs {
+		columns = append([]string{"id"}, columns...)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return stats, fmt.Errorf("failed to start COPY into %s: %w", table, err)
+	}
+	defer stmt.Close()
+
+	for row := range rows {
+		id, vals := values(row)
+		if opts.KeepIDs {
+			vals = append([]any{id}, vals...)
+		}
+		if _, err := stmt.ExecContext(ctx, vals...); err != nil {
+			return stats, mapError(err)
+		}
+
+		stats.Rows++
+		if opts.Progress != nil && stats.Rows%every == 0 {
+			stats.Elapsed = time.Since(start)
+			opts.Progress(stats)
+		}
+	}
+
+	// Пустой Exec отправляет остаток буфера и завершает COPY; ошибки
+	// ограничений сервер сообщает именно здесь.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return stats, mapError(err)
+	}
+	if err := stmt.Close(); err != nil {
+		return stats, mapError(err)
+	}
+
+	for _, def := range indexes {
+		if _, err := tx.ExecContext(ctx, def); err != nil {
+			return stats, fmt.Errorf("failed to recreate index: %s: %w", def, err)
+		}
+	}
+
+	if opts.KeepIDs {
+		if err := ResetSequence(ctx, tx, table); err != nil {
+			return stats, err
+		}
+	}
+
+	stats.Elapsed = time.Since(start)
+	return stats, nil
+}
+
+// dropIndexes удаляет индексы table, не связанные с ограничениями, и
+// возвращает их CREATE INDEX для восстановления.
+func dropIndexes(ctx context.Context, tx *sql.Tx, table string) ([]string, error) {
+	const query = `
+		SELECT quote_ident(n.nspname) || '.' || quote_ident(c.relname), pg_get_indexdef(x.indexrelid)
+		FROM pg_index x
+		JOIN pg_class c ON c.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE x.indrelid = $1::regclass
+		  AND NOT EXISTS (SELECT 1 FROM pg_constraint k WHERE k.conindid = x.indexrelid AND k.conrelid = x.indrelid)`
+
+	rows, err := tx.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes of %s: %w", table, err)
+	}
+	var names, defs []string
+	for rows.Next() {
+		var name, def string
+		if err := rows.Scan(&name, &def); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+		defs = append(defs, def)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, err := tx.ExecContext(ctx, "DROP INDEX "+name); err != nil {
+			return nil, fmt.Errorf("failed to drop index %s: %w", name, err)
+		}
+	}
+	return defs, nil
+}
+
+// ResetSequence выставляет последовательность SERIAL-столбца id таблицы на
+// max(id), чтобы следующий INSERT не столкнулся с загруженными явно id.
+func ResetSequence(ctx context.Context, db Executor, table string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(max(id), 1), count(*) > 0) FROM %s",
+		table, table))
+	if err != nil {
+		return fmt.Errorf("failed to reset sequence of %s: %w", table, err)
+	}
+	return nil
+}
@@ -1,499 +1,719 @@
 package handler
 
 import (
-	"database/sql"
-	"fmt"
-	"log"
+	"context"
 	"time"
+
 	"databases2026/pkg/model"
 
 	_ "github.com/lib/pq"
 )
 
-func nullInt(v int) interface{} {
-	if v == 0 {
-		return nil
-	}
-	return v
-}
+// Каждая операция есть в двух вариантах: XxxContext принимает контекст
+// и строку model.*, возвращая её в том виде, в каком она легла в БД;
+// Xxx — прежний вариант со скалярными аргументами поверх XxxContext
+// с context.Background().
+//
+// Delete* для несуществующей строки возвращают ErrNotFound (раньше — nil),
+// в том числе прежние варианты: DELETE, который ничего не удалил, — почти
+// всегда ошибка в id. Кому удаление должно быть идемпотентным, проверяет
+// errors.Is(err, ErrNotFound).
 
-func InitDataBase(dbSettings model.DbConnectionSettings) (*sql.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		dbSettings.Host, dbSettings.Port, dbSettings.User,
-		dbSettings.Password, dbSettings.DataBaseName,
-	)
+// --- 1. users ---
+func CreateUserContext(ctx context.Context, db Executor, u model.User) (model.User, error) {
+	const query = "INSERT INTO users (email) VALUES ($1) RETURNING " + userColumns
+	v, err := scanUser(db.QueryRowContext(ctx, query, u.Email))
+	return v, mapError(err)
+}
 
-	db, err := sql.Open("postgres", dsn)
+func CreateUser(db Executor, email string) (int, error) {
+	u, err := CreateUserContext(context.Background(), db, model.User{Email: email})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open db connection: %w", err)
-	}
-
-	if err := db.Ping(); err != nil {
-		db.Close()
-		return nil, fmt.Errorf("failed to ping db: %w", err)
+		return 0, err
 	}
 
-	return db, nil
+	return u.ID, err
 }
 
-func DbIsExist(db *sql.DB) (bool, error) {
-	const query = "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname='sports_club');"
-	var exists bool
-	err := db.QueryRow(query).Scan(&exists)
-	if err != nil {
-		log.Fatalf("Failed to check database existence: %v", err)
-		return false, err
-	}
-
-	return exists, err
+func DeleteUserContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
+	return affected(res, err, "user", id)
 }
 
-// --- 1. users ---
-func CreateUser(db *sql.DB, email string) (int, error) {
-	var id int
-	err := db.QueryRow("INSERT INTO users (email) VALUES ($1) RETURNING id", email).Scan(&id)
-	if err != nil {
-		return 0, err
-	}
+func DeleteUser(db Executor, id int) error {
+	return DeleteUserContext(context.Background(), db, id)
+}
 
-	return id, err
+// --- 2. coaches ---
+func CreateCoachContext(ctx context.Context, db Executor, c model.Coach) (model.Coach, error) {
+	const query = "INSERT INTO coaches (user_id) VALUES ($1) RETURNING " + coachColumns
+	v, err := scanCoach(db.QueryRowContext(ctx, query, c.UserID))
+	return v, mapError(err)
 }
 
-func DeleteUser(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM users WHERE id = $1", id)
+func CreateCoach(db Executor, userID int) error {
+	_, err := CreateCoachContext(context.Background(), db, model.Coach{UserID: userID})
 	return err
 }
 
-// --- 2. coaches ---
-func CreateCoach(db *sql.DB, userID int) error {
-	_, err := db.Exec("INSERT INTO coaches (user_id) VALUES ($1)", userID)
-	return err
+func DeleteCoachContext(ctx context.Context, db Executor, userID int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM coaches WHERE user_id = $1", userID)
+	return affected(res, err, "coach", userID)
 }
 
-func DeleteCoach(db *sql.DB, userID int) error {
-	_, err := db.Exec("DELETE FROM coaches WHERE user_id = $1", userID)
-	return err
+func DeleteCoach(db Executor, userID int) error {
+	return DeleteCoachContext(context.Background(), db, userID)
 }
 
 // --- 3. sports ---
-func CreateSport(db *sql.DB, name string) (int, error) {
-	const query = "INSERT INTO sports (name) VALUES ($1) RETURNING id"
-	var id int
-	err := db.QueryRow(query, name).Scan(&id)
+func CreateSportContext(ctx context.Context, db Executor, s model.Sport) (model.Sport, error) {
+	const query = "INSERT INTO sports (name) VALUES ($1) RETURNING " + sportColumns
+	v, err := scanSport(db.QueryRowContext(ctx, query, s.Name))
+	return v, mapError(err)
+}
+
+func CreateSport(db Executor, name string) (int, error) {
+	s, err := CreateSportContext(context.Background(), db, model.Sport{Name: name})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return s.ID, err
 }
 
-func DeleteSport(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM sports WHERE id = $1", id)
-	return err
+func DeleteSportContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM sports WHERE id = $1", id)
+	return affected(res, err, "sport", id)
+}
+
+func DeleteSport(db Executor, id int) error {
+	return DeleteSportContext(context.Background(), db, id)
 }
 
 // --- 4. classes ---
-func CreateClass(db *sql.DB, sportID, coachID int) (int, error) {
+func CreateClassContext(ctx context.Context, db Executor, c model.Class) (model.Class, error) {
 	const query = `
-		INSERT INTO classes (sport_id, coach_id) 
-		VALUES ($1, $2) RETURNING id
-	`
+		INSERT INTO classes (sport_id, coach_id)
+		VALUES ($1, $2) RETURNING ` + classColumns
 
-	var id int
-	err := db.QueryRow(query, sportID, coachID).Scan(&id)
+	v, err := scanClass(db.QueryRowContext(ctx, query, c.SportID, c.CoachID))
+	return v, mapError(err)
+}
+
+func CreateClass(db Executor, sportID, coachID int) (int, error) {
+	c, err := CreateClassContext(context.Background(), db,
+		model.Class{SportID: sportID, CoachID: coachID})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return c.ID, err
 }
 
-func DeleteClass(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM classes WHERE id = $1", id)
-	return err
+func DeleteClassContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM classes WHERE id = $1", id)
+	return affected(res, err, "class", id)
+}
+
+func DeleteClass(db Executor, id int) error {
+	return DeleteClassContext(context.Background(), db, id)
 }
 
 // --- 5. rooms ---
-func CreateRoom(db *sql.DB, capacity int) (int, error) {
-	const query = "INSERT INTO rooms (capacity) VALUES ($1) RETURNING id"
-	var id int
-	err := db.QueryRow(query, capacity).Scan(&id)
+func CreateRoomContext(ctx context.Context, db Executor, r model.Room) (model.Room, error) {
+	const query = "INSERT INTO rooms (capacity) VALUES ($1) RETURNING " + roomColumns
+	v, err := scanRoom(db.QueryRowContext(ctx, query, r.Capacity))
+	return v, mapError(err)
+}
+
+func CreateRoom(db Executor, capacity int) (int, error) {
+	r, err := CreateRoomContext(context.Background(), db, model.Room{Capacity: capacity})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return r.ID, err
 }
 
-func DeleteRoom(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM rooms WHERE id = $1", id)
-	return err
+func DeleteRoomContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM rooms WHERE id = $1", id)
+	return affected(res, err, "room", id)
+}
+
+func DeleteRoom(db Executor, id int) error {
+	return DeleteRoomContext(context.Background(), db, id)
 }
 
 // --- 6. schedules ---
-func CreateSchedule(db *sql.DB, classID, roomID int, start, end time.Time) (int, error) {
+func CreateScheduleContext(
+	ctx context.Context,
+	db Executor,
+	s model.Schedule,
+) (model.Schedule, error) {
 	const query = `
 		INSERT INTO schedules (class_id, room_id, start_time, end_time)
-		VALUES ($1, $2, $3, $4) RETURNING id
-	`
+		VALUES ($1, $2, $3, $4) RETURNING ` + scheduleColumns
+
+	v, err := scanSchedule(db.QueryRowContext(ctx, query, s.ClassID, s.RoomID, s.StartTime, s.EndTime))
+	return v, mapError(err)
+}
 
-	var id int
-	err := db.QueryRow(query, classID, roomID, start, end).Scan(&id)
+func CreateSchedule(db Executor, classID, roomID int, start, end time.Time) (int, error) {
+	s, err := CreateScheduleContext(context.Background(), db, model.Schedule{
+		ClassID:   classID,
+		RoomID:    roomID,
+		StartTime: start,
+		EndTime:   end,
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return s.ID, err
 }
 
-func DeleteSchedule(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM schedules WHERE id = $1", id)
-	return err
+func DeleteScheduleContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM schedules WHERE id = $1", id)
+	return affected(res, err, "schedule", id)
+}
+
+func DeleteSchedule(db Executor, id int) error {
+	return DeleteScheduleContext(context.Background(), db, id)
 }
 
 // --- 7. bookings ---
-func CreateBooking(db *sql.DB, userID, scheduleID int) (int, error) {
-	const query = `
-		INSERT INTO bookings (user_id, schedule_id)
-		VALUES ($1, $2) RETURNING id
-	`
 
-	var id int
-	err := db.QueryRow(query, userID, scheduleID).Scan(&id)
+// CreateBookingContext не проверяет вместимость зала; записывать
+// пользователей на занятия следует через internal/booking.
+func CreateBookingContext(ctx context.Context, db Executor, b model.Booking) (model.Booking, error) {
+	v, err := scanBooking(insertRow(ctx, db, "bookings", []column{
+		{"user_id", b.UserID},
+		{"schedule_id", b.ScheduleID},
+		{"status", b.Status},
+	}, bookingColumns))
+	return v, mapError(err)
+}
+
+func CreateBooking(db Executor, userID, scheduleID int) (int, error) {
+	b, err := CreateBookingContext(context.Background(), db,
+		model.Booking{UserID: userID, ScheduleID: scheduleID})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return b.ID, err
 }
 
-func DeleteBooking(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM bookings WHERE id = $1", id)
-	return err
+func DeleteBookingContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM bookings WHERE id = $1", id)
+	return affected(res, err, "booking", id)
+}
+
+func DeleteBooking(db Executor, id int) error {
+	return DeleteBookingContext(context.Background(), db, id)
 }
 
 // --- 8. memberships ---
-func CreateMembership(db *sql.DB, durationDays int, price float64) (int, error) {
+func CreateMembershipContext(
+	ctx context.Context,
+	db Executor,
+	m model.Membership,
+) (model.Membership, error) {
 	const query = `
-		INSERT INTO memberships (duration_days, price) 
-		VALUES ($1, $2) RETURNING id
-	`
+		INSERT INTO memberships (duration_days, price)
+		VALUES ($1, $2) RETURNING ` + membershipColumns
+
+	v, err := scanMembership(db.QueryRowContext(ctx, query, m.DurationDays, m.Price))
+	return v, mapError(err)
+}
 
-	var id int
-	err := db.QueryRow(query, durationDays, price).Scan(&id)
+func CreateMembership(db Executor, durationDays int, price float64) (int, error) {
+	m, err := CreateMembershipContext(context.Background(), db,
+		model.Membership{DurationDays: durationDays, Price: price})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return m.ID, err
 }
 
-func DeleteMembership(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM memberships WHERE id = $1", id)
-	return err
+func DeleteMembershipContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM memberships WHERE id = $1", id)
+	return affected(res, err, "membership", id)
+}
+
+func DeleteMembership(db Executor, id int) error {
+	return DeleteMembershipContext(context.Background(), db, id)
 }
 
 // --- 9. user_memberships ---
+func CreateUserMembershipContext(
+	ctx context.Context,
+	db Executor,
+	um model.UserMembership,
+) (model.UserMembership, error) {
+	v, err := scanUserMembership(insertRow(ctx, db, "user_memberships", []column{
+		{"user_id", um.UserID},
+		{"membership_id", um.MembershipID},
+		{"started_at", um.StartedAt},
+		{"ended_at", um.EndedAt},
+		{"is_active", um.IsActive},
+	}, userMembershipColumns))
+	return v, mapError(err)
+}
+
 func CreateUserMembership(
-	db *sql.DB,
+	db Executor,
 	userID int,
 	membershipID int,
 	started time.Time,
 	ended time.Time,
 ) (int, error) {
-	const query = `
-		INSERT INTO user_memberships (user_id, membership_id, started_at, ended_at)
-		VALUES ($1, $2, $3, $4) RETURNING id
-	`
-
-	var id int
-	err := db.QueryRow(query, userID, membershipID, started, ended).Scan(&id)
+	um, err := CreateUserMembershipContext(context.Background(), db, model.UserMembership{
+		UserID:       userID,
+		MembershipID: membershipID,
+		StartedAt:    started,
+		EndedAt:      ended,
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return um.ID, err
 }
 
-func DeleteUserMembership(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM user_memberships WHERE id = $1", id)
-	return err
+func DeleteUserMembershipContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM user_memberships WHERE id = $1", id)
+	return affected(res, err, "user membership", id)
 }
 
-// --- 10. payments ---
-func CreatePayment(db *sql.DB, userID int, amount float64) (int, error) {
-	const query = `
-		INSERT INTO payments (user_id, amount)
-		VALUES ($1, $2) RETURNING id
-	`
+func DeleteUserMembership(db Executor, id int) error {
+	return DeleteUserMembershipContext(context.Background(), db, id)
+}
 
-	var id int
-	err := db.QueryRow(query, userID, amount).Scan(&id)
+// --- 10. payments ---
+func CreatePaymentContext(ctx context.Context, db Executor, p model.Payment) (model.Payment, error) {
+	v, err := scanPayment(insertRow(ctx, db, "payments", []column{
+		{"user_id", p.UserID},
+		{"amount", p.Amount},
+		{"status", p.Status},
+	}, paymentColumns))
+	return v, mapError(err)
+}
+
+func CreatePayment(db Executor, userID int, amount float64) (int, error) {
+	p, err := CreatePaymentContext(context.Background(), db,
+		model.Payment{UserID: userID, Amount: amount})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return p.ID, err
 }
 
-func DeletePayment(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM payments WHERE id = $1", id)
-	return err
+func DeletePaymentContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM payments WHERE id = $1", id)
+	return affected(res, err, "payment", id)
+}
+
+func DeletePayment(db Executor, id int) error {
+	return DeletePaymentContext(context.Background(), db, id)
 }
 
 // --- 11. attendance_logs ---
-func CreateAttendanceLog(db *sql.DB, userID int, start, end time.Time) (int, error) {
+func CreateAttendanceLogContext(
+	ctx context.Context,
+	db Executor,
+	a model.AttendanceLog,
+) (model.AttendanceLog, error) {
 	const query = `
 		INSERT INTO attendance_logs (user_id, start_time, end_time)
-		VALUES ($1, $2, $3) RETURNING id
-	`
+		VALUES ($1, $2, $3) RETURNING ` + attendanceLogColumns
+
+	v, err := scanAttendanceLog(db.QueryRowContext(ctx, query, a.UserID, a.StartTime, a.EndTime))
+	return v, mapError(err)
+}
 
-	var id int
-	err := db.QueryRow(query, userID, start, end).Scan(&id)
+func CreateAttendanceLog(db Executor, userID int, start, end time.Time) (int, error) {
+	a, err := CreateAttendanceLogContext(context.Background(), db,
+		model.AttendanceLog{UserID: userID, StartTime: start, EndTime: end})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return a.ID, err
 }
 
-func DeleteAttendanceLog(db *sql.DB, id int) error {
+func DeleteAttendanceLogContext(ctx context.Context, db Executor, id int) error {
 	const query = "DELETE FROM attendance_logs WHERE id = $1"
-	_, err := db.Exec(query, id)
-	return err
+	res, err := db.ExecContext(ctx, query, id)
+	return affected(res, err, "attendance log", id)
+}
+
+func DeleteAttendanceLog(db Executor, id int) error {
+	return DeleteAttendanceLogContext(context.Background(), db, id)
 }
 
 // --- 12. reviews ---
-func CreateReview(db *sql.DB, userID, coachID, classID int, rating int) (int, error) {
+func CreateReviewContext(ctx context.Context, db Executor, r model.Review) (model.Review, error) {
 	const query = `
 		INSERT INTO reviews (user_id, coach_id, class_id, rating)
-		VALUES ($1, $2, $3, $4) RETURNING id
-	`
+		VALUES ($1, $2, $3, $4) RETURNING ` + reviewColumns
+
+	v, err := scanReview(db.QueryRowContext(ctx, query, r.UserID, r.CoachID, r.ClassID, r.Rating))
+	return v, mapError(err)
+}
 
-	var id int
-	err := db.QueryRow(query, userID, nullInt(coachID), nullInt(classID), rating).Scan(&id)
+// CreateReview: coachID или classID, равный 0, записывается как NULL.
+func CreateReview(db Executor, userID, coachID, classID int, rating int) (int, error) {
+	r, err := CreateReviewContext(context.Background(), db, model.Review{
+		UserID:  userID,
+		CoachID: optInt(coachID),
+		ClassID: optInt(classID),
+		Rating:  rating,
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return r.ID, err
 }
 
-func DeleteReview(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM reviews WHERE id = $1", id)
-	return err
+func DeleteReviewContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM reviews WHERE id = $1", id)
+	return affected(res, err, "review", id)
+}
+
+func DeleteReview(db Executor, id int) error {
+	return DeleteReviewContext(context.Background(), db, id)
 }
 
 // --- 13. promotions ---
+func CreatePromotionContext(
+	ctx context.Context,
+	db Executor,
+	p model.Promotion,
+) (model.Promotion, error) {
+	v, err := scanPromotion(insertRow(ctx, db, "promotions", []column{
+		{"code", p.Code},
+		{"discount_percent", p.DiscountPercent},
+		{"valid_from", p.ValidFrom},
+		{"valid_until", p.ValidUntil},
+		{"max_uses", p.MaxUses},
+		{"used_count", p.UsedCount},
+	}, promotionColumns))
+	return v, mapError(err)
+}
+
 func CreatePromotion(
-	db *sql.DB,
+	db Executor,
 	code string,
 	discount int,
 	from time.Time,
 	until time.Time,
 	maxUses *int,
 ) (int, error) {
-	var id int
-	if maxUses == nil {
-		err := db.QueryRow(`
-			INSERT INTO promotions 
-			(code, discount_percent, valid_from, valid_until)
-			VALUES ($1, $2, $3, $4) RETURNING id
-		`, code, discount, from, until).Scan(&id)
-		if err != nil {
-			return 0, err
-		}
-
-		return id, err
-	}
-
-	err := db.QueryRow(`
-		INSERT INTO promotions 
-		(code, discount_percent, valid_from, valid_until, max_uses)
-		VALUES ($1, $2, $3, $4, $5) RETURNING id
-	`, code, discount, from, until, *maxUses).Scan(&id)
+	p, err := CreatePromotionContext(context.Background(), db, model.Promotion{
+		Code:            code,
+		DiscountPercent: discount,
+		ValidFrom:       from,
+		ValidUntil:      until,
+		MaxUses:         maxUses,
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return p.ID, err
 }
 
-func DeletePromotion(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM promotions WHERE id = $1", id)
-	return err
+func DeletePromotionContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM promotions WHERE id = $1", id)
+	return affected(res, err, "promotion", id)
+}
+
+func DeletePromotion(db Executor, id int) error {
+	return DeletePromotionContext(context.Background(), db, id)
 }
 
 // --- 14. promotion_usage ---
-func UsePromotion(db *sql.DB, userID, promoID int) (int, error) {
+func UsePromotionContext(
+	ctx context.Context,
+	db Executor,
+	pu model.PromotionUsage,
+) (model.PromotionUsage, error) {
 	const query = `
-		INSERT INTO promotion_usage (user_id, promotion_id) 
-		VALUES ($1, $2) RETURNING id
-	`
+		INSERT INTO promotion_usage (user_id, promotion_id)
+		VALUES ($1, $2) RETURNING ` + promotionUsageColumns
+
+	v, err := scanPromotionUsage(db.QueryRowContext(ctx, query, pu.UserID, pu.PromotionID))
+	return v, mapError(err)
+}
 
-	var id int
-	err := db.QueryRow(query, userID, promoID).Scan(&id)
+func UsePromotion(db Executor, userID, promoID int) (int, error) {
+	pu, err := UsePromotionContext(context.Background(), db,
+		model.PromotionUsage{UserID: userID, PromotionID: promoID})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return pu.ID, err
 }
 
-func DeletePromotionUsage(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM promotion_usage WHERE id = $1", id)
-	return err
+func DeletePromotionUsageContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM promotion_usage WHERE id = $1", id)
+	return affected(res, err, "promotion usage", id)
 }
 
-// --- 15. notifications ---
-func CreateNotification(db *sql.DB, userID int, isRead bool) (int, error) {
-	const query = `
-		INSERT INTO notifications (user_id, is_read) 
-		VALUES ($1, $2) RETURNING id
-	`
+func DeletePromotionUsage(db Executor, id int) error {
+	return DeletePromotionUsageContext(context.Background(), db, id)
+}
 
-	var id int
-	err := db.QueryRow(query, userID, isRead).Scan(&id)
+// --- 15. notifications ---
+func CreateNotificationContext(
+	ctx context.Context,
+	db Executor,
+	n model.Notification,
+) (model.Notification, error) {
+	v, err := scanNotification(insertRow(ctx, db, "notifications", []column{
+		{"user_id", n.UserID},
+		{"is_read", n.IsRead},
+	}, notificationColumns))
+	return v, mapError(err)
+}
+
+func CreateNotification(db Executor, userID int, isRead bool) (int, error) {
+	n, err := CreateNotificationContext(context.Background(), db,
+		model.Notification{UserID: userID, IsRead: &isRead})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return n.ID, err
 }
 
-func DeleteNotification(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM notifications WHERE id = $1", id)
-	return err
+func DeleteNotificationContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM notifications WHERE id = $1", id)
+	return affected(res, err, "notification", id)
+}
+
+func DeleteNotification(db Executor, id int) error {
+	return DeleteNotificationContext(context.Background(), db, id)
 }
 
 // --- 16. loyalty_points ---
-func SetLoyaltyPoints(db *sql.DB, userID, points int) error {
+
+// SetLoyaltyPointsContext создаёт или перезаписывает баллы пользователя.
+// Points == nil только гарантирует наличие строки, не меняя баллы.
+func SetLoyaltyPointsContext(
+	ctx context.Context,
+	db Executor,
+	lp model.LoyaltyPoints,
+) (model.LoyaltyPoints, error) {
 	const query = `
 		INSERT INTO loyalty_points (user_id, points)
-		VALUES ($1, $2) ON CONFLICT (user_id) DO UPDATE SET points = $2
-	`
+		VALUES ($1, COALESCE($2::INT, 0))
+		ON CONFLICT (user_id) DO UPDATE
+		SET points = COALESCE($2::INT, loyalty_points.points)
+		RETURNING ` + loyaltyPointsColumns
 
-	_, err := db.Exec(query,userID, points)
-	return err
+	v, err := scanLoyaltyPoints(db.QueryRowContext(ctx, query, lp.UserID, lp.Points))
+	return v, mapError(err)
 }
 
-func DeleteLoyaltyPoints(db *sql.DB, userID int) error {
-	_, err := db.Exec("DELETE FROM loyalty_points WHERE user_id = $1", userID)
+func SetLoyaltyPoints(db Executor, userID, points int) error {
+	_, err := SetLoyaltyPointsContext(context.Background(), db,
+		model.LoyaltyPoints{UserID: userID, Points: &points})
 	return err
 }
 
-// --- 17. referrals ---
-func CreateReferral(db *sql.DB, referrerID, referredID int) (int, error) {
-	const query = `
-		INSERT INTO referrals (referrer_id, referred_id) 
-		VALUES ($1, $2) RETURNING id
-	`
+func DeleteLoyaltyPointsContext(ctx context.Context, db Executor, userID int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM loyalty_points WHERE user_id = $1", userID)
+	return affected(res, err, "loyalty points of user", userID)
+}
+
+func DeleteLoyaltyPoints(db Executor, userID int) error {
+	return DeleteLoyaltyPointsContext(context.Background(), db, userID)
+}
 
-	var id int
-	err := db.QueryRow(query, referrerID, referredID).Scan(&id)
+// --- 17. referrals ---
+func CreateReferralContext(
+	ctx context.Context,
+	db Executor,
+	r model.Referral,
+) (model.Referral, error) {
+	v, err := scanReferral(insertRow(ctx, db, "referrals", []column{
+		{"referrer_id", r.ReferrerID},
+		{"referred_id", r.ReferredID},
+		{"rewarded", r.Rewarded},
+	}, referralColumns))
+	return v, mapError(err)
+}
+
+func CreateReferral(db Executor, referrerID, referredID int) (int, error) {
+	r, err := CreateReferralContext(context.Background(), db,
+		model.Referral{ReferrerID: referrerID, ReferredID: referredID})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return r.ID, err
 }
 
-func DeleteReferral(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM referrals WHERE id = $1", id)
-	return err
+func DeleteReferralContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM referrals WHERE id = $1", id)
+	return affected(res, err, "referral", id)
+}
+
+func DeleteReferral(db Executor, id int) error {
+	return DeleteReferralContext(context.Background(), db, id)
 }
 
 // --- 18. audit_logs ---
+func LogAuditContext(ctx context.Context, db Executor, a model.AuditLog) (model.AuditLog, error) {
+	v, err := scanAuditLog(insertRow(ctx, db, "audit_logs", []column{
+		{"user_id", a.UserID},
+		{"action", a.Action},
+		{"entity_type", a.EntityType},
+		{"entity_id", a.EntityID},
+		{"performed_at", a.PerformedAt},
+	}, auditLogColumns))
+	return v, mapError(err)
+}
+
+// LogAudit: пустой entityType записывается как NULL.
 func LogAudit(
-	db *sql.DB,
+	db Executor,
 	userID *int,
 	action string,
 	entityType string,
 	entityID *int,
 ) (int, error) {
-	checkAndExit := func(id int, err error) (int, error) {
-		if err != nil {
-			return 0, err
-		}
-		return id, nil
-	}
-
-	var id int
-	if userID == nil && entityID == nil {
-		err := db.QueryRow(`
-			INSERT INTO audit_logs (action) VALUES ($1) RETURNING id
-		`, action).Scan(&id)
-		return checkAndExit(id, err)
-	}
-
-	if userID == nil {
-		err := db.QueryRow(`
-			INSERT INTO audit_logs (action, entity_type, entity_id) 
-			VALUES ($1, $2, $3) RETURNING id
-		`, action, entityType, entityID).Scan(&id)
-		return checkAndExit(id, err)
+	a, err := LogAuditContext(context.Background(), db, model.AuditLog{
+		UserID:     userID,
+		Action:     action,
+		EntityType: optString(entityType),
+		EntityID:   entityID,
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	if entityID == nil {
-		err := db.QueryRow(`
-			INSERT INTO audit_logs (user_id, action, entity_type) 
-			VALUES ($1, $2, $3) RETURNING id
-		`, *userID, action, entityType).Scan(&id)
-		return checkAndExit(id, err)
-	}
+	return a.ID, err
+}
 
-	err := db.QueryRow(`
-		INSERT INTO audit_logs (user_id, action, entity_type, entity_id) 
-		VALUES ($1, $2, $3, $4) RETURNING id
-	`, *userID, action, entityType, *entityID).Scan(&id)
-	return checkAndExit(id, err)
+func DeleteAuditLogContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM audit_logs WHERE id = $1", id)
+	return affected(res, err, "audit log", id)
 }
 
-func DeleteAuditLog(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM audit_logs WHERE id = $1", id)
-	return err
+func DeleteAuditLog(db Executor, id int) error {
+	return DeleteAuditLogContext(context.Background(), db, id)
 }
 
 // --- 19. system_settings ---
-func SetSystemSetting(db *sql.DB, key, value string) error {
+func SetSystemSettingContext(
+	ctx context.Context,
+	db Executor,
+	s model.SystemSetting,
+) (model.SystemSetting, error) {
 	const query = `
 		INSERT INTO system_settings (key, value) VALUES ($1, $2)
-		ON CONFLICT (key) DO UPDATE SET value = $
-	`
+		ON CONFLICT (key) DO UPDATE SET value = $2
+		RETURNING ` + systemSettingColumns
 
-	_, err := db.Exec(query, key, value)
-	return err
+	v, err := scanSystemSetting(db.QueryRowContext(ctx, query, s.Key, s.Value))
+	return v, mapError(err)
 }
 
-func DeleteSystemSetting(db *sql.DB, key string) error {
-	_, err := db.Exec("DELETE FROM system_settings WHERE key = $1", key)
+func SetSystemSetting(db Executor, key, value string) error {
+	_, err := SetSystemSettingContext(context.Background(), db,
+		model.SystemSetting{Key: key, Value: &value})
 	return err
 }
 
+func DeleteSystemSettingContext(ctx context.Context, db Executor, key string) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM system_settings WHERE key = $1", key)
+	return affected(res, err, "system setting", key)
+}
+
+func DeleteSystemSetting(db Executor, key string) error {
+	return DeleteSystemSettingContext(context.Background(), db, key)
+}
+
 // --- 20. temp_bookings ---
+func CreateTempBookingContext(
+	ctx context.Context,
+	db Executor,
+	tb model.TempBooking,
+) (model.TempBooking, error) {
+	const query = `
+		INSERT INTO temp_bookings (user_id, schedule_id, expires_at, token)
+		VALUES ($1, $2, $3, $4) RETURNING ` + tempBookingColumns
+
+	v, err := scanTempBooking(db.QueryRowContext(ctx, query,
+		tb.UserID, tb.ScheduleID, tb.ExpiresAt, tb.Token))
+	return v, mapError(err)
+}
+
 func CreateTempBooking(
-	db *sql.DB,
+	db Executor,
 	userID int,
 	scheduleID int,
 	expires time.Time,
 	token string,
 ) (int, error) {
-	const query = `
-		INSERT INTO temp_bookings (user_id, schedule_id, expires_at, token)
-		VALUES ($1, $2, $3, $4) RETURNING id
-	`
-
-	var id int
-	err := db.QueryRow(query, userID, scheduleID, expires, token).Scan(&id)
+	tb, err := CreateTempBookingContext(context.Background(), db, model.TempBooking{
+		UserID:     userID,
+		ScheduleID: scheduleID,
+		ExpiresAt:  expires,
+		Token:      token,
+	})
 	if err != nil {
 		return 0, err
 	}
 
-	return id, err
+	return tb.ID, err
 }
 
-func DeleteTempBooking(db *sql.DB, id int) error {
-	_, err := db.Exec("DELETE FROM temp_bookings WHERE id = $1", id)
-	return err
+func DeleteTempBookingContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM temp_bookings WHERE id = $1", id)
+	return affected(res, err, "temp booking", id)
+}
+
+func DeleteTempBooking(db Executor, id int) error {
+	return DeleteTempBookingContext(context.Background(), db, id)
+}
+
+// DeleteExpiredTempBookings удаляет брони, истёкшие к моменту before
+// (scheduleID == 0 — на всех занятиях), и возвращает их число.
+func DeleteExpiredTempBookings(ctx context.Context, db Executor, scheduleID int, before time.Time) (int, error) {
+	var c conds
+	c.add("expires_at <= ?", before)
+	c.addIf(scheduleID != 0, "schedule_id = ?", scheduleID)
+	res, err := db.ExecContext(ctx, "DELETE FROM temp_bookings"+c.where(), c.args...)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// --- 21. waitlist ---
+func CreateWaitlistEntryContext(
+	ctx context.Context,
+	db Executor,
+	w model.WaitlistEntry,
+) (model.WaitlistEntry, error) {
+	v, err := scanWaitlistEntry(insertRow(ctx, db, "waitlist", []column{
+		{"user_id", w.UserID},
+		{"schedule_id", w.ScheduleID},
+		{"created_at", w.CreatedAt},
+	}, waitlistColumns))
+	return v, mapError(err)
+}
+
+func DeleteWaitlistEntryContext(ctx context.Context, db Executor, id int) error {
+	res, err := db.ExecContext(ctx, "DELETE FROM waitlist WHERE id = $1", id)
+	return affected(res, err, "waitlist entry", id)
 }
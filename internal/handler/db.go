@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"databases2026/pkg/model"
+
+	"github.com/lib/pq"
+)
+
+const maxPingBackoff = 10 * time.Second
+
+func InitDataBase(dbSettings model.DbConnectionSettings) (*sql.DB, error) {
+	dsn, err := BuildDSN(dbSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db connection: %w", err)
+	}
+
+	if dbSettings.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(dbSettings.MaxOpenConns)
+	}
+	if dbSettings.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(dbSettings.MaxIdleConns)
+	}
+	if dbSettings.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(dbSettings.ConnMaxLifetime)
+	}
+	if dbSettings.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(dbSettings.ConnMaxIdleTime)
+	}
+
+	if err := pingWithRetry(db, dbSettings.PingAttempts, dbSettings.PingBackoff); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
+	}
+
+	return db, nil
+}
+
+// pingWithRetry ждёт, пока сервер начнёт принимать подключения
+// (например, контейнер из docker-compose ещё стартует).
+func pingWithRetry(db *sql.DB, attempts int, backoff time.Duration) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = db.Ping(); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("ping attempt %d/%d failed: %v; retrying in %s", attempt, attempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxPingBackoff {
+			backoff = maxPingBackoff
+		}
+	}
+
+	return fmt.Errorf("after %d attempt(s): %w", attempts, err)
+}
+
+func DbIsExist(db *sql.DB, name string) (bool, error) {
+	const query = "SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname=$1);"
+	var exists bool
+	err := db.QueryRow(query, name).Scan(&exists)
+	if err != nil {
+		log.Fatalf("Failed to check database existence: %v", err)
+		return false, err
+	}
+
+	return exists, err
+}
+
+// CreateDataBase создаёт базу name; db должен быть подключён к другой (служебной) базе.
+func CreateDataBase(ctx context.Context, db *sql.DB, name string) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE DATABASE %s
+		ENCODING 'UTF8'
+		LC_COLLATE 'en_US.UTF-8'
+		LC_CTYPE 'en_US.UTF-8'
+		TEMPLATE template0;
+	`, pq.QuoteIdentifier(name)))
+	if err != nil {
+		return fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return nil
+}
+
+// DropDataBase запрещает новые подключения к базе name, завершает
+// существующие и удаляет базу. Возвращает число завершённых сеансов.
+// Если удалить не удалось, подключения снова разрешаются.
+func DropDataBase(ctx context.Context, db *sql.DB, name string) (int, error) {
+	quoted := pq.QuoteIdentifier(name)
+
+	if _, err := db.ExecContext(ctx, "ALTER DATABASE "+quoted+" ALLOW_CONNECTIONS false"); err != nil {
+		return 0, fmt.Errorf("failed to block connections to %s: %w", name, err)
+	}
+
+	var terminated int
+	err := db.QueryRowContext(ctx, `
+		SELECT count(*) FILTER (WHERE pg_terminate_backend(pid))
+		FROM pg_stat_activity
+		WHERE datname = $1 AND pid <> pg_backend_pid()`, name).Scan(&terminated)
+	if err == nil {
+		_, err = db.ExecContext(ctx, "DROP DATABASE "+quoted)
+	}
+	if err != nil {
+		if _, rbErr := db.ExecContext(context.Background(),
+			"ALTER DATABASE "+quoted+" ALLOW_CONNECTIONS true"); rbErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to re-allow connections: %w", rbErr))
+		}
+		return terminated, fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return terminated, nil
+}
+
+// HealthReport — состояние пула и сервера на момент вызова Health.
+type HealthReport struct {
+	Stats         sql.DBStats
+	ServerVersion string
+	Database      string
+	Latency       time.Duration
+}
+
+func Health(ctx context.Context, db *sql.DB) (HealthReport, error) {
+	var report HealthReport
+
+	start := time.Now()
+	err := db.QueryRowContext(ctx, "SELECT version(), current_database()").
+		Scan(&report.ServerVersion, &report.Database)
+	report.Latency = time.Since(start)
+	report.Stats = db.Stats()
+	if err != nil {
+		return report, fmt.Errorf("health check failed: %w", err)
+	}
+
+	return report, nil
+}
+
+func (r HealthReport) String() string {
+	return fmt.Sprintf(
+		"db=%s latency=%s open=%d in_use=%d idle=%d wait_count=%d wait=%s "+
+			"max_open=%d closed_idle=%d closed_lifetime=%d\n%s",
+		r.Database, r.Latency.Round(time.Microsecond),
+		r.Stats.OpenConnections, r.Stats.InUse, r.Stats.Idle,
+		r.Stats.WaitCount, r.Stats.WaitDuration,
+		r.Stats.MaxOpenConnections, r.Stats.MaxIdleTimeClosed, r.Stats.MaxLifetimeClosed,
+		r.ServerVersion,
+	)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// BuildDSN собирает строку подключения для lib/pq из настроек.
+func BuildDSN(s model.DbConnectionSettings) (string, error) {
+	sslMode := s.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	if !sslModes[sslMode] {
+		return "", fmt.Errorf("unsupported sslmode %q", sslMode)
+	}
+
+	params := []struct{ key, value string }{
+		{"sslmode", sslMode},
+		{"sslrootcert", s.SSLRootCert},
+		{"sslcert", s.SSLCert},
+		{"sslkey", s.SSLKey},
+		{"connect_timeout", seconds(s.ConnectTimeout)},
+		{"statement_timeout", milliseconds(s.StatementTimeout)},
+		{"search_path", s.SearchPath},
+		{"application_name", s.ApplicationName},
+	}
+
+	parts := []string{
+		"host=" + quoteValue(s.Host),
+		"port=" + strconv.Itoa(s.Port),
+		"user=" + quoteValue(s.User),
+		"password=" + quoteValue(s.Password),
+		"dbname=" + quoteValue(s.DataBaseName),
+	}
+	for _, p := range params {
+		if p.value != "" {
+			parts = append(parts, p.key+"="+quoteValue(p.value))
+		}
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+// quoteValue экранирует значение по правилам key=value строк libpq.
+func quoteValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, ` '\`) {
+		return v
+	}
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `'`, `\'`)
+	return "'" + v + "'"
+}
+
+// connect_timeout в libpq задаётся в целых секундах.
+func seconds(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	secs := int64((d + time.Second - 1) / time.Second)
+	return strconv.FormatInt(secs, 10)
+}
+
+func milliseconds(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10)
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Ошибки, которые возвращают функции пакета вместо «сырых» *pq.Error.
+// Проверять через errors.Is; подробности — через errors.As(*ConstraintError).
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrDuplicate      = errors.New("duplicate value")
+	ErrForeignKey     = errors.New("foreign key violation")
+	ErrCheckViolation = errors.New("check constraint violation")
+	ErrNotNull        = errors.New("not null violation")
+	ErrExclusion      = errors.New("exclusion constraint violation")
+)
+
+// ConstraintError описывает нарушение ограничения схемы, например
+// UNIQUE users.email или CHECK reviews.rating.
+type ConstraintError struct {
+	Kind       error
+	Table      string
+	Constraint string
+	Columns    []string
+	Detail     string
+	// Err — исходная ошибка драйвера; nil, если ошибку построил не драйвер
+	// (например, in-memory репозиторий).
+	Err *pq.Error
+}
+
+func (e *ConstraintError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Kind.Error())
+	if e.Table != "" {
+		b.WriteString(" on " + e.Table)
+		if len(e.Columns) > 0 {
+			b.WriteString("(" + strings.Join(e.Columns, ", ") + ")")
+		}
+	}
+	if e.Constraint != "" {
+		b.WriteString(" [" + e.Constraint + "]")
+	}
+	if e.Detail != "" {
+		b.WriteString(": " + e.Detail)
+	}
+	return b.String()
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	if e.Err == nil {
+		return nil
+	}
+	return e.Err
+}
+
+// mapError переводит ошибки драйвера в ошибки пакета. Остальные
+// ошибки (в т.ч. 40001/40P01 для WithTx) возвращаются как есть.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	var kind error
+	switch pqErr.Code {
+	case "23505":
+		kind = ErrDuplicate
+	case "23503":
+		kind = ErrForeignKey
+	case "23514":
+		kind = ErrCheckViolation
+	case "23502":
+		kind = ErrNotNull
+	case "23P01":
+		kind = ErrExclusion
+	default:
+		return err
+	}
+
+	return &ConstraintError{
+		Kind:       kind,
+		Table:      pqErr.Table,
+		Constraint: pqErr.Constraint,
+		Columns:    constraintColumns(pqErr),
+		Detail:     pqErr.Detail,
+		Err:        pqErr,
+	}
+}
+
+func constraintColumns(e *pq.Error) []string {
+	if e.Column != "" {
+		return []string{e.Column}
+	}
+	if cols := detailKey(e.Detail); cols != nil {
+		return cols
+	}
+
+	// Имена ограничений по умолчанию: <table>_<column>_check/_key/_fkey.
+	// Для табличных CHECK (reviews_check) столбец определить нельзя.
+	name := strings.TrimPrefix(e.Constraint, e.Table+"_")
+	for _, suffix := range []string{"_check", "_fkey", "_key"} {
+		if col, ok := strings.CutSuffix(name, suffix); ok && col != "" {
+			return []string{col}
+		}
+	}
+	return nil
+}
+
+// detailKey разбирает список ключа из DETAIL:
+// "Key (user_id, schedule_id)=(1, 2) already exists." -> [user_id schedule_id].
+// У exclusion constraint в ключе бывают выражения со своими скобками и
+// запятыми: "Key (room_id, tsrange(start_time, end_time))=(...)".
+func detailKey(detail string) []string {
+	rest, ok := strings.CutPrefix(detail, "Key (")
+	if !ok {
+		return nil
+	}
+	var cols []string
+	depth, from := 0, 0
+	for i, c := range rest {
+		switch c {
+		case '(':
+			depth++
+		case ',':
+			if depth == 0 {
+				cols = append(cols, strings.TrimSpace(rest[from:i]))
+				from = i + 1
+			}
+		case ')':
+			if depth > 0 {
+				depth--
+				continue
+			}
+			if !strings.HasPrefix(rest[i+1:], "=") {
+				return nil
+			}
+			return append(cols, strings.TrimSpace(rest[from:i]))
+		}
+	}
+	return nil
+}
+
+// notFound — mapError с указанием, какая именно строка не найдена.
+func notFound(err error, table string, key any) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s %v: %w", table, key, ErrNotFound)
+	}
+	return mapError(err)
+}
+
+// affected возвращает ErrNotFound, если DELETE/UPDATE не затронул ни одной строки.
+func affected(res sql.Result, err error, table string, key any) error {
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%s %v: %w", table, key, ErrNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *pq.Error
+		kind    error
+		columns []string
+	}{
+		{
+			name: "unique, composite key",
+			err: &pq.Error{Code: "23505", Table: "bookings", Constraint: "bookings_user_id_schedule_id_key",
+				Detail: "Key (user_id, schedule_id)=(1, 2) already exists."},
+			kind:    ErrDuplicate,
+			columns: []string{"user_id", "schedule_id"},
+		},
+		{
+			name: "unique, one column",
+			err: &pq.Error{Code: "23505", Table: "users", Constraint: "users_email_key",
+				Detail: "Key (email)=(a@example.com) already exists."},
+			kind:    ErrDuplicate,
+			columns: []string{"email"},
+		},
+		{
+			name: "unparsable detail",
+			err:  &pq.Error{Code: "23505", Table: "users", Constraint: "users_pkey", Detail: "Key (id=(1) already exists."},
+			kind: ErrDuplicate,
+		},
+		{
+			name: "foreign key",
+			err: &pq.Error{Code: "23503", Table: "bookings", Constraint: "bookings_schedule_id_fkey",
+				Detail: `Key (schedule_id)=(42) is not present in table "schedules".`},
+			kind:    ErrForeignKey,
+			columns: []string{"schedule_id"},
+		},
+		{
+			name:    "check, column from the constraint name",
+			err:     &pq.Error{Code: "23514", Table: "reviews", Constraint: "reviews_rating_check"},
+			kind:    ErrCheckViolation,
+			columns: []string{"rating"},
+		},
+		{
+			name: "check on the whole table",
+			err:  &pq.Error{Code: "23514", Table: "reviews", Constraint: "reviews_check"},
+			kind: ErrCheckViolation,
+		},
+		{
+			name:    "not null",
+			err:     &pq.Error{Code: "23502", Table: "users", Column: "email"},
+			kind:    ErrNotNull,
+			columns: []string{"email"},
+		},
+		{
+			name: "exclusion",
+			err: &pq.Error{Code: "23P01", Table: "schedules", Constraint: "schedules_room_overlap",
+				Detail: `Key (room_id, tsrange(start_time, end_time))=(1, ["2030-01-01 10:00:00","2030-01-01 12:00:00")) ` +
+					`conflicts with existing key (room_id, tsrange(start_time, end_time))=(1, ["2030-01-01 09:00:00","2030-01-01 11:00:00")).`},
+			kind:    ErrExclusion,
+			columns: []string{"room_id", "tsrange(start_time, end_time)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mapError(fmt.Errorf("insert: %w", tt.err))
+			if !errors.Is(err, tt.kind) {
+				t.Fatalf("got %v, want %v", err, tt.kind)
+			}
+			var cerr *ConstraintError
+			if !errors.As(err, &cerr) {
+				t.Fatalf("got %T, want *ConstraintError", err)
+			}
+			if cerr.Table != tt.err.Table || cerr.Constraint != tt.err.Constraint || cerr.Detail != tt.err.Detail {
+				t.Errorf("got %+v, want fields of %+v", cerr, tt.err)
+			}
+			if !slices.Equal(cerr.Columns, tt.columns) {
+				t.Errorf("columns %q, want %q", cerr.Columns, tt.columns)
+			}
+			var pqErr *pq.Error
+			if !errors.As(err, &pqErr) || pqErr != tt.err {
+				t.Errorf("driver error is not reachable through errors.As")
+			}
+		})
+	}
+}
+
+func TestMapErrorPassThrough(t *testing.T) {
+	if mapError(nil) != nil {
+		t.Error("mapError(nil) != nil")
+	}
+	if err := mapError(sql.ErrNoRows); !errors.Is(err, ErrNotFound) || !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("sql.ErrNoRows mapped to %v", err)
+	}
+	// Ошибки сериализации WithTx должен увидеть как есть.
+	for _, err := range []error{&pq.Error{Code: "40001"}, &pq.Error{Code: "40P01"}, errors.New("other")} {
+		if got := mapError(err); got != err {
+			t.Errorf("mapError(%v) = %v, want it unchanged", err, got)
+		}
+	}
+}
+
+func TestConstraintErrorMessage(t *testing.T) {
+	err := mapError(&pq.Error{Code: "23505", Table: "bookings", Constraint: "bookings_user_id_schedule_id_key",
+		Detail: "Key (user_id, schedule_id)=(1, 2) already exists."})
+	want := "duplicate value on bookings(user_id, schedule_id) [bookings_user_id_schedule_id_key]: " +
+		"Key (user_id, schedule_id)=(1, 2) already exists."
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+}
@@ -0,0 +1,580 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+// Операции чтения появились после перехода на контексты, поэтому
+// существуют только в context-first виде.
+
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// Page — страница результата List*. Limit == 0 означает DefaultPageLimit.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// Window возвращает фактические LIMIT и OFFSET с учётом умолчаний и границ.
+func (p Page) Window() (limit, offset int) {
+	limit, offset = p.Limit, p.Offset
+	switch {
+	case limit <= 0:
+		limit = DefaultPageLimit
+	case limit > MaxPageLimit:
+		limit = MaxPageLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// conds накапливает условия WHERE; "?" в выражении заменяется на $N.
+type conds struct {
+	parts []string
+	args  []any
+}
+
+func (c *conds) add(expr string, value any) {
+	c.args = append(c.args, value)
+	c.parts = append(c.parts, strings.Replace(expr, "?", fmt.Sprintf("$%d", len(c.args)), 1))
+}
+
+func (c *conds) addIf(ok bool, expr string, value any) {
+	if ok {
+		c.add(expr, value)
+	}
+}
+
+func (c *conds) where() string {
+	if len(c.parts) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(c.parts, " AND ")
+}
+
+func listRows[T any](
+	ctx context.Context,
+	db Executor,
+	table string,
+	columns string,
+	c conds,
+	orderBy string,
+	page Page,
+	scan func(rowScanner) (T, error),
+) ([]T, error) {
+	limit, offset := page.Window()
+	args := append(c.args, limit, offset)
+	query := fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s LIMIT $%d OFFSET $%d",
+		columns, table, c.where(), orderBy, len(args)-1, len(args))
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var result []T
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+
+	return result, mapError(rows.Err())
+}
+
+// --- 1. users ---
+type UserFilter struct {
+	// EmailLike — шаблон ILIKE, например '%@example.com'.
+	EmailLike string
+	Page
+}
+
+func GetUserByID(ctx context.Context, db Executor, id int) (model.User, error) {
+	const query = "SELECT " + userColumns + " FROM users WHERE id = $1"
+	v, err := scanUser(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "user", id)
+}
+
+func GetUserByEmail(ctx context.Context, db Executor, email string) (model.User, error) {
+	const query = "SELECT " + userColumns + " FROM users WHERE email = $1"
+	v, err := scanUser(db.QueryRowContext(ctx, query, email))
+	return v, notFound(err, "user", email)
+}
+
+func ListUsers(ctx context.Context, db Executor, f UserFilter) ([]model.User, error) {
+	var c conds
+	c.addIf(f.EmailLike != "", "email ILIKE ?", f.EmailLike)
+	return listRows(ctx, db, "users", userColumns, c, "id", f.Page, scanUser)
+}
+
+// --- 2. coaches ---
+func GetCoachByID(ctx context.Context, db Executor, userID int) (model.Coach, error) {
+	const query = "SELECT " + coachColumns + " FROM coaches WHERE user_id = $1"
+	v, err := scanCoach(db.QueryRowContext(ctx, query, userID))
+	return v, notFound(err, "coach", userID)
+}
+
+func ListCoaches(ctx context.Context, db Executor, page Page) ([]model.Coach, error) {
+	return listRows(ctx, db, "coaches", coachColumns, conds{}, "user_id", page, scanCoach)
+}
+
+// --- 3. sports ---
+func GetSportByID(ctx context.Context, db Executor, id int) (model.Sport, error) {
+	const query = "SELECT " + sportColumns + " FROM sports WHERE id = $1"
+	v, err := scanSport(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "sport", id)
+}
+
+func ListSports(ctx context.Context, db Executor, page Page) ([]model.Sport, error) {
+	return listRows(ctx, db, "sports", sportColumns, conds{}, "id", page, scanSport)
+}
+
+// --- 4. classes ---
+type ClassFilter struct {
+	SportID int
+	CoachID int
+	Page
+}
+
+func GetClassByID(ctx context.Context, db Executor, id int) (model.Class, error) {
+	const query = "SELECT " + classColumns + " FROM classes WHERE id = $1"
+	v, err := scanClass(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "class", id)
+}
+
+func ListClasses(ctx context.Context, db Executor, f ClassFilter) ([]model.Class, error) {
+	var c conds
+	c.addIf(f.SportID != 0, "sport_id = ?", f.SportID)
+	c.addIf(f.CoachID != 0, "coach_id = ?", f.CoachID)
+	return listRows(ctx, db, "classes", classColumns, c, "id", f.Page, scanClass)
+}
+
+// --- 5. rooms ---
+type RoomFilter struct {
+	MinCapacity int
+	Page
+}
+
+func GetRoomByID(ctx context.Context, db Executor, id int) (model.Room, error) {
+	const query = "SELECT " + roomColumns + " FROM rooms WHERE id = $1"
+	v, err := scanRoom(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "room", id)
+}
+
+func ListRooms(ctx context.Context, db Executor, f RoomFilter) ([]model.Room, error) {
+	var c conds
+	c.addIf(f.MinCapacity > 0, "capacity >= ?", f.MinCapacity)
+	return listRows(ctx, db, "rooms", roomColumns, c, "id", f.Page, scanRoom)
+}
+
+// --- 6. schedules ---
+
+// ScheduleFilter: From/To задают окно; в выборку попадают занятия,
+// пересекающиеся с ним. CoachID — занятия классов тренера.
+type ScheduleFilter struct {
+	ClassID int
+	RoomID  int
+	CoachID int
+	From    time.Time
+	To      time.Time
+	Page
+}
+
+func GetScheduleByID(ctx context.Context, db Executor, id int) (model.Schedule, error) {
+	const query = "SELECT " + scheduleColumns + " FROM schedules WHERE id = $1"
+	v, err := scanSchedule(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "schedule", id)
+}
+
+// LockSchedule читает занятие с блокировкой строки (SELECT ... FOR UPDATE)
+// до конца транзакции: так конкурентные записи на одно занятие выполняются
+// по очереди. Вне транзакции блокировка снимается сразу.
+func LockSchedule(ctx context.Context, db Executor, id int) (model.Schedule, error) {
+	const query = "SELECT " + scheduleColumns + " FROM schedules WHERE id = $1 FOR UPDATE"
+	v, err := scanSchedule(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "schedule", id)
+}
+
+func ListSchedules(ctx context.Context, db Executor, f ScheduleFilter) ([]model.Schedule, error) {
+	var c conds
+	c.addIf(f.ClassID != 0, "class_id = ?", f.ClassID)
+	c.addIf(f.RoomID != 0, "room_id = ?", f.RoomID)
+	c.addIf(f.CoachID != 0, "class_id IN (SELECT id FROM classes WHERE coach_id = ?)", f.CoachID)
+	c.addIf(!f.From.IsZero(), "end_time > ?", f.From)
+	c.addIf(!f.To.IsZero(), "start_time < ?", f.To)
+	return listRows(ctx, db, "schedules", scheduleColumns, c, "start_time, id", f.Page, scanSchedule)
+}
+
+// --- 7. bookings ---
+type BookingFilter struct {
+	UserID     int
+	ScheduleID int
+	Status     string
+	Page
+}
+
+func GetBookingByID(ctx context.Context, db Executor, id int) (model.Booking, error) {
+	const query = "SELECT " + bookingColumns + " FROM bookings WHERE id = $1"
+	v, err := scanBooking(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "booking", id)
+}
+
+func ListBookings(ctx context.Context, db Executor, f BookingFilter) ([]model.Booking, error) {
+	return listRows(ctx, db, "bookings", bookingColumns, f.conds(), "id", f.Page, scanBooking)
+}
+
+// CountBookings считает брони по фильтру; Page не учитывается.
+func CountBookings(ctx context.Context, db Executor, f BookingFilter) (int, error) {
+	c := f.conds()
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT count(*) FROM bookings"+c.where(), c.args...).Scan(&n)
+	return n, mapError(err)
+}
+
+func (f BookingFilter) conds() conds {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.ScheduleID != 0, "schedule_id = ?", f.ScheduleID)
+	c.addIf(f.Status != "", "status = ?", f.Status)
+	return c
+}
+
+// --- 8. memberships ---
+func GetMembershipByID(ctx context.Context, db Executor, id int) (model.Membership, error) {
+	const query = "SELECT " + membershipColumns + " FROM memberships WHERE id = $1"
+	v, err := scanMembership(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "membership", id)
+}
+
+func ListMemberships(ctx context.Context, db Executor, page Page) ([]model.Membership, error) {
+	return listRows(ctx, db, "memberships", membershipColumns, conds{}, "id", page, scanMembership)
+}
+
+// --- 9. user_memberships ---
+type UserMembershipFilter struct {
+	UserID       int
+	MembershipID int
+	Active       *bool
+	Page
+}
+
+func GetUserMembershipByID(ctx context.Context, db Executor, id int) (model.UserMembership, error) {
+	const query = "SELECT " + userMembershipColumns + " FROM user_memberships WHERE id = $1"
+	v, err := scanUserMembership(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "user membership", id)
+}
+
+func ListUserMemberships(
+	ctx context.Context,
+	db Executor,
+	f UserMembershipFilter,
+) ([]model.UserMembership, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.MembershipID != 0, "membership_id = ?", f.MembershipID)
+	c.addIf(f.Active != nil, "COALESCE(is_active, FALSE) = ?", f.Active)
+	return listRows(ctx, db, "user_memberships", userMembershipColumns, c, "id", f.Page,
+		scanUserMembership)
+}
+
+// --- 10. payments ---
+type PaymentFilter struct {
+	UserID int
+	Status string
+	Page
+}
+
+func GetPaymentByID(ctx context.Context, db Executor, id int) (model.Payment, error) {
+	const query = "SELECT " + paymentColumns + " FROM payments WHERE id = $1"
+	v, err := scanPayment(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "payment", id)
+}
+
+func ListPayments(ctx context.Context, db Executor, f PaymentFilter) ([]model.Payment, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.Status != "", "status = ?", f.Status)
+	return listRows(ctx, db, "payments", paymentColumns, c, "id", f.Page, scanPayment)
+}
+
+// --- 11. attendance_logs ---
+type AttendanceLogFilter struct {
+	UserID int
+	From   time.Time
+	To     time.Time
+	Page
+}
+
+func GetAttendanceLogByID(ctx context.Context, db Executor, id int) (model.AttendanceLog, error) {
+	const query = "SELECT " + attendanceLogColumns + " FROM attendance_logs WHERE id = $1"
+	v, err := scanAttendanceLog(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "attendance log", id)
+}
+
+func ListAttendanceLogs(
+	ctx context.Context,
+	db Executor,
+	f AttendanceLogFilter,
+) ([]model.AttendanceLog, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(!f.From.IsZero(), "start_time >= ?", f.From)
+	c.addIf(!f.To.IsZero(), "start_time < ?", f.To)
+	return listRows(ctx, db, "attendance_logs", attendanceLogColumns, c, "start_time, id", f.Page,
+		scanAttendanceLog)
+}
+
+// --- 12. reviews ---
+type ReviewFilter struct {
+	UserID  int
+	CoachID int
+	ClassID int
+	Page
+}
+
+func GetReviewByID(ctx context.Context, db Executor, id int) (model.Review, error) {
+	const query = "SELECT " + reviewColumns + " FROM reviews WHERE id = $1"
+	v, err := scanReview(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "review", id)
+}
+
+func ListReviews(ctx context.Context, db Executor, f ReviewFilter) ([]model.Review, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.CoachID != 0, "coach_id = ?", f.CoachID)
+	c.addIf(f.ClassID != 0, "class_id = ?", f.ClassID)
+	return listRows(ctx, db, "reviews", reviewColumns, c, "id", f.Page, scanReview)
+}
+
+// --- 13. promotions ---
+
+// PromotionFilter: ValidAt оставляет только промокоды, действующие на эту дату.
+type PromotionFilter struct {
+	ValidAt time.Time
+	Page
+}
+
+func GetPromotionByID(ctx context.Context, db Executor, id int) (model.Promotion, error) {
+	const query = "SELECT " + promotionColumns + " FROM promotions WHERE id = $1"
+	v, err := scanPromotion(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "promotion", id)
+}
+
+func GetPromotionByCode(ctx context.Context, db Executor, code string) (model.Promotion, error) {
+	const query = "SELECT " + promotionColumns + " FROM promotions WHERE code = $1"
+	v, err := scanPromotion(db.QueryRowContext(ctx, query, code))
+	return v, notFound(err, "promotion", code)
+}
+
+func ListPromotions(ctx context.Context, db Executor, f PromotionFilter) ([]model.Promotion, error) {
+	var c conds
+	if !f.ValidAt.IsZero() {
+		c.add("valid_from <= ?", f.ValidAt)
+		c.add("valid_until >= ?", f.ValidAt)
+	}
+	return listRows(ctx, db, "promotions", promotionColumns, c, "id", f.Page, scanPromotion)
+}
+
+// --- 14. promotion_usage ---
+type PromotionUsageFilter struct {
+	UserID      int
+	PromotionID int
+	Page
+}
+
+func GetPromotionUsageByID(ctx context.Context, db Executor, id int) (model.PromotionUsage, error) {
+	const query = "SELECT " + promotionUsageColumns + " FROM promotion_usage WHERE id = $1"
+	v, err := scanPromotionUsage(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "promotion usage", id)
+}
+
+func ListPromotionUsages(
+	ctx context.Context,
+	db Executor,
+	f PromotionUsageFilter,
+) ([]model.PromotionUsage, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.PromotionID != 0, "promotion_id = ?", f.PromotionID)
+	return listRows(ctx, db, "promotion_usage", promotionUsageColumns, c, "id", f.Page,
+		scanPromotionUsage)
+}
+
+// --- 15. notifications ---
+type NotificationFilter struct {
+	UserID     int
+	UnreadOnly bool
+	Page
+}
+
+func GetNotificationByID(ctx context.Context, db Executor, id int) (model.Notification, error) {
+	const query = "SELECT " + notificationColumns + " FROM notifications WHERE id = $1"
+	v, err := scanNotification(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "notification", id)
+}
+
+func ListNotifications(
+	ctx context.Context,
+	db Executor,
+	f NotificationFilter,
+) ([]model.Notification, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.UnreadOnly, "COALESCE(is_read, FALSE) = ?", false)
+	return listRows(ctx, db, "notifications", notificationColumns, c, "id", f.Page,
+		scanNotification)
+}
+
+// --- 16. loyalty_points ---
+func GetLoyaltyPointsByUserID(ctx context.Context, db Executor, userID int) (model.LoyaltyPoints, error) {
+	const query = "SELECT " + loyaltyPointsColumns + " FROM loyalty_points WHERE user_id = $1"
+	v, err := scanLoyaltyPoints(db.QueryRowContext(ctx, query, userID))
+	return v, notFound(err, "loyalty points of user", userID)
+}
+
+func ListLoyaltyPoints(ctx context.Context, db Executor, page Page) ([]model.LoyaltyPoints, error) {
+	return listRows(ctx, db, "loyalty_points", loyaltyPointsColumns, conds{}, "user_id", page,
+		scanLoyaltyPoints)
+}
+
+// --- 17. referrals ---
+type ReferralFilter struct {
+	ReferrerID int
+	ReferredID int
+	Page
+}
+
+func GetReferralByID(ctx context.Context, db Executor, id int) (model.Referral, error) {
+	const query = "SELECT " + referralColumns + " FROM referrals WHERE id = $1"
+	v, err := scanReferral(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "referral", id)
+}
+
+func ListReferrals(ctx context.Context, db Executor, f ReferralFilter) ([]model.Referral, error) {
+	var c conds
+	c.addIf(f.ReferrerID != 0, "referrer_id = ?", f.ReferrerID)
+	c.addIf(f.ReferredID != 0, "referred_id = ?", f.ReferredID)
+	return listRows(ctx, db, "referrals", referralColumns, c, "id", f.Page, scanReferral)
+}
+
+// --- 18. audit_logs ---
+type AuditLogFilter struct {
+	UserID     int
+	Action     string
+	EntityType string
+	EntityID   int
+	From       time.Time
+	To         time.Time
+	Page
+}
+
+func GetAuditLogByID(ctx context.Context, db Executor, id int) (model.AuditLog, error) {
+	const query = "SELECT " + auditLogColumns + " FROM audit_logs WHERE id = $1"
+	v, err := scanAuditLog(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "audit log", id)
+}
+
+func ListAuditLogs(ctx context.Context, db Executor, f AuditLogFilter) ([]model.AuditLog, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.Action != "", "action = ?", f.Action)
+	c.addIf(f.EntityType != "", "entity_type = ?", f.EntityType)
+	c.addIf(f.EntityID != 0, "entity_id = ?", f.EntityID)
+	c.addIf(!f.From.IsZero(), "performed_at >= ?", f.From)
+	c.addIf(!f.To.IsZero(), "performed_at < ?", f.To)
+	return listRows(ctx, db, "audit_logs", auditLogColumns, c, "id", f.Page, scanAuditLog)
+}
+
+// --- 19. system_settings ---
+func GetSystemSettingByKey(ctx context.Context, db Executor, key string) (model.SystemSetting, error) {
+	const query = "SELECT " + systemSettingColumns + " FROM system_settings WHERE key = $1"
+	v, err := scanSystemSetting(db.QueryRowContext(ctx, query, key))
+	return v, notFound(err, "system setting", key)
+}
+
+func ListSystemSettings(ctx context.Context, db Executor, page Page) ([]model.SystemSetting, error) {
+	return listRows(ctx, db, "system_settings", systemSettingColumns, conds{}, "key", page,
+		scanSystemSetting)
+}
+
+// --- 20. temp_bookings ---
+type TempBookingFilter struct {
+	UserID     int
+	ScheduleID int
+	// ExpiredBefore оставляет только брони, истёкшие к этому моменту.
+	ExpiredBefore time.Time
+	// ActiveAt оставляет только брони, ещё действующие в этот момент.
+	ActiveAt time.Time
+	Page
+}
+
+func GetTempBookingByID(ctx context.Context, db Executor, id int) (model.TempBooking, error) {
+	const query = "SELECT " + tempBookingColumns + " FROM temp_bookings WHERE id = $1"
+	v, err := scanTempBooking(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "temp booking", id)
+}
+
+func GetTempBookingByToken(ctx context.Context, db Executor, token string) (model.TempBooking, error) {
+	const query = "SELECT " + tempBookingColumns + " FROM temp_bookings WHERE token = $1"
+	v, err := scanTempBooking(db.QueryRowContext(ctx, query, token))
+	return v, notFound(err, "temp booking", token)
+}
+
+func ListTempBookings(
+	ctx context.Context,
+	db Executor,
+	f TempBookingFilter,
+) ([]model.TempBooking, error) {
+	return listRows(ctx, db, "temp_bookings", tempBookingColumns, f.conds(), "id", f.Page, scanTempBooking)
+}
+
+// CountTempBookings считает временные брони по фильтру без учёта страницы.
+func CountTempBookings(ctx context.Context, db Executor, f TempBookingFilter) (int, error) {
+	c := f.conds()
+	var n int
+	err := db.QueryRowContext(ctx, "SELECT count(*) FROM temp_bookings"+c.where(), c.args...).Scan(&n)
+	return n, mapError(err)
+}
+
+func (f TempBookingFilter) conds() conds {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.ScheduleID != 0, "schedule_id = ?", f.ScheduleID)
+	c.addIf(!f.ExpiredBefore.IsZero(), "expires_at <= ?", f.ExpiredBefore)
+	c.addIf(!f.ActiveAt.IsZero(), "expires_at > ?", f.ActiveAt)
+	return c
+}
+
+// --- 21. waitlist ---
+type WaitlistFilter struct {
+	UserID     int
+	ScheduleID int
+	Page
+}
+
+func GetWaitlistEntryByID(ctx context.Context, db Executor, id int) (model.WaitlistEntry, error) {
+	const query = "SELECT " + waitlistColumns + " FROM waitlist WHERE id = $1"
+	v, err := scanWaitlistEntry(db.QueryRowContext(ctx, query, id))
+	return v, notFound(err, "waitlist entry", id)
+}
+
+// ListWaitlist возвращает записи в порядке очереди (по id).
+func ListWaitlist(ctx context.Context, db Executor, f WaitlistFilter) ([]model.WaitlistEntry, error) {
+	var c conds
+	c.addIf(f.UserID != 0, "user_id = ?", f.UserID)
+	c.addIf(f.ScheduleID != 0, "schedule_id = ?", f.ScheduleID)
+	return listRows(ctx, db, "waitlist", waitlistColumns, c, "id", f.Page, scanWaitlistEntry)
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"databases2026/pkg/model"
+)
+
+// Списки столбцов в порядке полей соответствующих структур model.*.
+const (
+	userColumns           = "id, email"
+	coachColumns          = "user_id"
+	sportColumns          = "id, name"
+	classColumns          = "id, sport_id, coach_id"
+	roomColumns           = "id, capacity"
+	scheduleColumns       = "id, class_id, room_id, start_time, end_time"
+	bookingColumns        = "id, user_id, schedule_id, status"
+	membershipColumns     = "id, duration_days, price"
+	userMembershipColumns = "id, user_id, membership_id, started_at, ended_at, is_active"
+	paymentColumns        = "id, user_id, amount, status"
+	attendanceLogColumns  = "id, user_id, start_time, end_time"
+	reviewColumns         = "id, user_id, coach_id, class_id, rating"
+	promotionColumns      = "id, code, discount_percent, valid_from, valid_until, max_uses, used_count"
+	promotionUsageColumns = "id, user_id, promotion_id"
+	notificationColumns   = "id, user_id, is_read"
+	loyaltyPointsColumns  = "user_id, points"
+	referralColumns       = "id, referrer_id, referred_id, rewarded"
+	auditLogColumns       = "id, user_id, action, entity_type, entity_id, performed_at"
+	systemSettingColumns  = "key, value"
+	tempBookingColumns    = "id, user_id, schedule_id, expires_at, token"
+	waitlistColumns       = "id, user_id, schedule_id, created_at"
+)
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (model.User, error) {
+	var v model.User
+	err := row.Scan(&v.ID, &v.Email)
+	return v, err
+}
+
+func scanCoach(row rowScanner) (model.Coach, error) {
+	var v model.Coach
+	err := row.Scan(&v.UserID)
+	return v, err
+}
+
+func scanSport(row rowScanner) (model.Sport, error) {
+	var v model.Sport
+	err := row.Scan(&v.ID, &v.Name)
+	return v, err
+}
+
+func scanClass(row rowScanner) (model.Class, error) {
+	var v model.Class
+	err := row.Scan(&v.ID, &v.SportID, &v.CoachID)
+	return v, err
+}
+
+func scanRoom(row rowScanner) (model.Room, error) {
+	var v model.Room
+	err := row.Scan(&v.ID, &v.Capacity)
+	return v, err
+}
+
+func scanSchedule(row rowScanner) (model.Schedule, error) {
+	var v model.Schedule
+	err := row.Scan(&v.ID, &v.ClassID, &v.RoomID, &v.StartTime, &v.EndTime)
+	return v, err
+}
+
+func scanBooking(row rowScanner) (model.Booking, error) {
+	var v model.Booking
+	err := row.Scan(&v.ID, &v.UserID, &v.ScheduleID, &v.Status)
+	return v, err
+}
+
+func scanMembership(row rowScanner) (model.Membership, error) {
+	var v model.Membership
+	err := row.Scan(&v.ID, &v.DurationDays, &v.Price)
+	return v, err
+}
+
+func scanUserMembership(row rowScanner) (model.UserMembership, error) {
+	var v model.UserMembership
+	err := row.Scan(&v.ID, &v.UserID, &v.MembershipID, &v.StartedAt, &v.EndedAt, &v.IsActive)
+	return v, err
+}
+
+func scanPayment(row rowScanner) (model.Payment, error) {
+	var v model.Payment
+	err := row.Scan(&v.ID, &v.UserID, &v.Amount, &v.Status)
+	return v, err
+}
+
+func scanAttendanceLog(row rowScanner) (model.AttendanceLog, error) {
+	var v model.AttendanceLog
+	err := row.Scan(&v.ID, &v.UserID, &v.StartTime, &v.EndTime)
+	return v, err
+}
+
+func scanReview(row rowScanner) (model.Review, error) {
+	var v model.Review
+	err := row.Scan(&v.ID, &v.UserID, &v.CoachID, &v.ClassID, &v.Rating)
+	return v, err
+}
+
+func scanPromotion(row rowScanner) (model.Promotion, error) {
+	var v model.Promotion
+	err := row.Scan(&v.ID, &v.Code, &v.DiscountPercent, &v.ValidFrom, &v.ValidUntil,
+		&v.MaxUses, &v.UsedCount)
+	return v, err
+}
+
+func scanPromotionUsage(row rowScanner) (model.PromotionUsage, error) {
+	var v model.PromotionUsage
+	err := row.Scan(&v.ID, &v.UserID, &v.PromotionID)
+	return v, err
+}
+
+func scanNotification(row rowScanner) (model.Notification, error) {
+	var v model.Notification
+	err := row.Scan(&v.ID, &v.UserID, &v.IsRead)
+	return v, err
+}
+
+func scanLoyaltyPoints(row rowScanner) (model.LoyaltyPoints, error) {
+	var v model.LoyaltyPoints
+	err := row.Scan(&v.UserID, &v.Points)
+	return v, err
+}
+
+func scanReferral(row rowScanner) (model.Referral, error) {
+	var v model.Referral
+	err := row.Scan(&v.ID, &v.ReferrerID, &v.ReferredID, &v.Rewarded)
+	return v, err
+}
+
+func scanAuditLog(row rowScanner) (model.AuditLog, error) {
+	var v model.AuditLog
+	err := row.Scan(&v.ID, &v.UserID, &v.Action, &v.EntityType, &v.EntityID, &v.PerformedAt)
+	return v, err
+}
+
+func scanSystemSetting(row rowScanner) (model.SystemSetting, error) {
+	var v model.SystemSetting
+	err := row.Scan(&v.Key, &v.Value)
+	return v, err
+}
+
+func scanTempBooking(row rowScanner) (model.TempBooking, error) {
+	var v model.TempBooking
+	err := row.Scan(&v.ID, &v.UserID, &v.ScheduleID, &v.ExpiresAt, &v.Token)
+	return v, err
+}
+
+func scanWaitlistEntry(row rowScanner) (model.WaitlistEntry, error) {
+	var v model.WaitlistEntry
+	err := row.Scan(&v.ID, &v.UserID, &v.ScheduleID, &v.CreatedAt)
+	return v, err
+}
+
+// column — пара «столбец = значение» для динамически собираемых запросов.
+type column struct {
+	name  string
+	value any
+}
+
+// insertRow вставляет строку и возвращает столбцы returning. Столбцы
+// с nil-указателем пропускаются, чтобы сработал DEFAULT из схемы.
+func insertRow(
+	ctx context.Context,
+	db Executor,
+	table string,
+	cols []column,
+	returning string,
+) *sql.Row {
+	names := make([]string, 0, len(cols))
+	holders := make([]string, 0, len(cols))
+	args := make([]any, 0, len(cols))
+	for _, c := range cols {
+		if isNil(c.value) {
+			continue
+		}
+		args = append(args, c.value)
+		names = append(names, c.name)
+		holders = append(holders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	var query string
+	if len(names) == 0 {
+		query = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s", table, returning)
+	} else {
+		query = fmt.Sprintf(
+			"INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+			table, strings.Join(names, ", "), strings.Join(holders, ", "), returning,
+		)
+	}
+
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// optInt — 0 означает «не задано» (NULL).
+func optInt(v int) *int {
+	if v == 0 {
+		return nil
+	}
+	return &v
+}
+
+func optString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+// Update* записывают только заданные поля патча и возвращают строку после
+// изменения; если строки нет — ErrNotFound. Пустой патч просто читает строку.
+//
+// Поля-указатели: nil — не менять. Столбцы, где NULL имеет смысл
+// (reviews.coach_id, promotions.max_uses, ...), описаны через Nullable,
+// чтобы можно было явно записать NULL.
+
+// Nullable — значение для обнуляемого столбца: Set == false — не менять,
+// Set == true и Value == nil — записать NULL.
+type Nullable[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func SetValue[T any](v T) Nullable[T] {
+	return Nullable[T]{Set: true, Value: &v}
+}
+
+func SetNull[T any]() Nullable[T] {
+	return Nullable[T]{Set: true}
+}
+
+func (n Nullable[T]) nullable() (any, bool) {
+	return n.Value, n.Set
+}
+
+type nullableField interface {
+	nullable() (value any, set bool)
+}
+
+// updateRow выполняет UPDATE table SET ... WHERE key = keyValue RETURNING returning.
+func updateRow(
+	ctx context.Context,
+	db Executor,
+	table string,
+	key string,
+	keyValue any,
+	sets []column,
+	returning string,
+) *sql.Row {
+	var (
+		parts []string
+		args  []any
+	)
+	for _, c := range sets {
+		value := c.value
+		if n, ok := value.(nullableField); ok {
+			v, set := n.nullable()
+			if !set {
+				continue
+			}
+			value = v
+		} else if isNil(value) {
+			continue
+		}
+		args = append(args, value)
+		parts = append(parts, fmt.Sprintf("%s = $%d", c.name, len(args)))
+	}
+
+	args = append(args, keyValue)
+	if len(parts) == 0 {
+		query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = $1", returning, table, key)
+		return db.QueryRowContext(ctx, query, args...)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = $%d RETURNING %s",
+		table, strings.Join(parts, ", "), key, len(args), returning)
+	return db.QueryRowContext(ctx, query, args...)
+}
+
+// --- 1. users ---
+type UserPatch struct {
+	Email *string
+}
+
+func UpdateUser(ctx context.Context, db Executor, id int, p UserPatch) (model.User, error) {
+	u, err := scanUser(updateRow(ctx, db, "users", "id", id, []column{
+		{"email", p.Email},
+	}, userColumns))
+	return u, notFound(err, "user", id)
+}
+
+// --- 2. coaches ---
+
+// CoachPatch переносит роль тренера на другого пользователя; при наличии
+// занятий у тренера изменение отклонит FK classes.coach_id.
+type CoachPatch struct {
+	UserID *int
+}
+
+func UpdateCoach(ctx context.Context, db Executor, userID int, p CoachPatch) (model.Coach, error) {
+	c, err := scanCoach(updateRow(ctx, db, "coaches", "user_id", userID, []column{
+		{"user_id", p.UserID},
+	}, coachColumns))
+	return c, notFound(err, "coach", userID)
+}
+
+// --- 3. sports ---
+type SportPatch struct {
+	Name *string
+}
+
+func UpdateSport(ctx context.Context, db Executor, id int, p SportPatch) (model.Sport, error) {
+	s, err := scanSport(updateRow(ctx, db, "sports", "id", id, []column{
+		{"name", p.Name},
+	}, sportColumns))
+	return s, notFound(err, "sport", id)
+}
+
+// --- 4. classes ---
+type ClassPatch struct {
+	SportID *int
+	CoachID *int
+}
+
+func UpdateClass(ctx context.Context, db Executor, id int, p ClassPatch) (model.Class, error) {
+	c, err := scanClass(updateRow(ctx, db, "classes", "id", id, []column{
+		{"sport_id", p.SportID},
+		{"coach_id", p.CoachID},
+	}, classColumns))
+	return c, notFound(err, "class", id)
+}
+
+// --- 5. rooms ---
+type RoomPatch struct {
+	Capacity *int
+}
+
+func UpdateRoom(ctx context.Context, db Executor, id int, p RoomPatch) (model.Room, error) {
+	r, err := scanRoom(updateRow(ctx, db, "rooms", "id", id, []column{
+		{"capacity", p.Capacity},
+	}, roomColumns))
+	return r, notFound(err, "room", id)
+}
+
+// --- 6. schedules ---
+type SchedulePatch struct {
+	ClassID   *int
+	RoomID    *int
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+func UpdateSchedule(ctx context.Context, db Executor, id int, p SchedulePatch) (model.Schedule, error) {
+	s, err := scanSchedule(updateRow(ctx, db, "schedules", "id", id, []column{
+		{"class_id", p.ClassID},
+		{"room_id", p.RoomID},
+		{"start_time", p.StartTime},
+		{"end_time", p.EndTime},
+	}, scheduleColumns))
+	return s, notFound(err, "schedule", id)
+}
+
+// --- 7. bookings ---
+type BookingPatch struct {
+	Status *string
+}
+
+func UpdateBooking(ctx context.Context, db Executor, id int, p BookingPatch) (model.Booking, error) {
+	b, err := scanBooking(updateRow(ctx, db, "bookings", "id", id, []column{
+		{"status", p.Status},
+	}, bookingColumns))
+	return b, notFound(err, "booking", id)
+}
+
+func CancelBooking(ctx context.Context, db Executor, id int) (model.Booking, error) {
+	status := model.BookingCancelled
+	return UpdateBooking(ctx, db, id, BookingPatch{Status: &status})
+}
+
+// --- 8. memberships ---
+type MembershipPatch struct {
+	DurationDays *int
+	Price        *float64
+}
+
+func UpdateMembership(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p MembershipPatch,
+) (model.Membership, error) {
+	m, err := scanMembership(updateRow(ctx, db, "memberships", "id", id, []column{
+		{"duration_days", p.DurationDays},
+		{"price", p.Price},
+	}, membershipColumns))
+	return m, notFound(err, "membership", id)
+}
+
+// --- 9. user_memberships ---
+type UserMembershipPatch struct {
+	MembershipID *int
+	StartedAt    *time.Time
+	EndedAt      *time.Time
+	IsActive     *bool
+}
+
+func UpdateUserMembership(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p UserMembershipPatch,
+) (model.UserMembership, error) {
+	um, err := scanUserMembership(updateRow(ctx, db, "user_memberships", "id", id, []column{
+		{"membership_id", p.MembershipID},
+		{"started_at", p.StartedAt},
+		{"ended_at", p.EndedAt},
+		{"is_active", p.IsActive},
+	}, userMembershipColumns))
+	return um, notFound(err, "user membership", id)
+}
+
+func DeactivateUserMembership(ctx context.Context, db Executor, id int) (model.UserMembership, error) {
+	active := false
+	return UpdateUserMembership(ctx, db, id, UserMembershipPatch{IsActive: &active})
+}
+
+// --- 10. payments ---
+type PaymentPatch struct {
+	Amount *float64
+	Status *string
+}
+
+func UpdatePayment(ctx context.Context, db Executor, id int, p PaymentPatch) (model.Payment, error) {
+	pm, err := scanPayment(updateRow(ctx, db, "payments", "id", id, []column{
+		{"amount", p.Amount},
+		{"status", p.Status},
+	}, paymentColumns))
+	return pm, notFound(err, "payment", id)
+}
+
+func MarkPaymentFailed(ctx context.Context, db Executor, id int) (model.Payment, error) {
+	status := model.PaymentFailed
+	return UpdatePayment(ctx, db, id, PaymentPatch{Status: &status})
+}
+
+// --- 11. attendance_logs ---
+type AttendanceLogPatch struct {
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+func UpdateAttendanceLog(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p AttendanceLogPatch,
+) (model.AttendanceLog, error) {
+	a, err := scanAttendanceLog(updateRow(ctx, db, "attendance_logs", "id", id, []column{
+		{"start_time", p.StartTime},
+		{"end_time", p.EndTime},
+	}, attendanceLogColumns))
+	return a, notFound(err, "attendance log", id)
+}
+
+// --- 12. reviews ---
+type ReviewPatch struct {
+	CoachID Nullable[int]
+	ClassID Nullable[int]
+	Rating  *int
+}
+
+func UpdateReview(ctx context.Context, db Executor, id int, p ReviewPatch) (model.Review, error) {
+	r, err := scanReview(updateRow(ctx, db, "reviews", "id", id, []column{
+		{"coach_id", p.CoachID},
+		{"class_id", p.ClassID},
+		{"rating", p.Rating},
+	}, reviewColumns))
+	return r, notFound(err, "review", id)
+}
+
+// --- 13. promotions ---
+type PromotionPatch struct {
+	Code            *string
+	DiscountPercent *int
+	ValidFrom       *time.Time
+	ValidUntil      *time.Time
+	MaxUses         Nullable[int]
+	UsedCount       *int
+}
+
+func UpdatePromotion(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p PromotionPatch,
+) (model.Promotion, error) {
+	pr, err := scanPromotion(updateRow(ctx, db, "promotions", "id", id, []column{
+		{"code", p.Code},
+		{"discount_percent", p.DiscountPercent},
+		{"valid_from", p.ValidFrom},
+		{"valid_until", p.ValidUntil},
+		{"max_uses", p.MaxUses},
+		{"used_count", p.UsedCount},
+	}, promotionColumns))
+	return pr, notFound(err, "promotion", id)
+}
+
+// --- 14. promotion_usage ---
+type PromotionUsagePatch struct {
+	UserID      *int
+	PromotionID *int
+}
+
+func UpdatePromotionUsage(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p PromotionUsagePatch,
+) (model.PromotionUsage, error) {
+	pu, err := scanPromotionUsage(updateRow(ctx, db, "promotion_usage", "id", id, []column{
+		{"user_id", p.UserID},
+		{"promotion_id", p.PromotionID},
+	}, promotionUsageColumns))
+	return pu, notFound(err, "promotion usage", id)
+}
+
+// --- 15. notifications ---
+type NotificationPatch struct {
+	IsRead *bool
+}
+
+func UpdateNotification(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p NotificationPatch,
+) (model.Notification, error) {
+	n, err := scanNotification(updateRow(ctx, db, "notifications", "id", id, []column{
+		{"is_read", p.IsRead},
+	}, notificationColumns))
+	return n, notFound(err, "notification", id)
+}
+
+func MarkNotificationRead(ctx context.Context, db Executor, id int) (model.Notification, error) {
+	read := true
+	return UpdateNotification(ctx, db, id, NotificationPatch{IsRead: &read})
+}
+
+// --- 16. loyalty_points ---
+type LoyaltyPointsPatch struct {
+	Points *int
+}
+
+func UpdateLoyaltyPoints(
+	ctx context.Context,
+	db Executor,
+	userID int,
+	p LoyaltyPointsPatch,
+) (model.LoyaltyPoints, error) {
+	lp, err := scanLoyaltyPoints(updateRow(ctx, db, "loyalty_points", "user_id", userID, []column{
+		{"points", p.Points},
+	}, loyaltyPointsColumns))
+	return lp, notFound(err, "loyalty points of user", userID)
+}
+
+// --- 17. referrals ---
+type ReferralPatch struct {
+	Rewarded *bool
+}
+
+func UpdateReferral(ctx context.Context, db Executor, id int, p ReferralPatch) (model.Referral, error) {
+	r, err := scanReferral(updateRow(ctx, db, "referrals", "id", id, []column{
+		{"rewarded", p.Rewarded},
+	}, referralColumns))
+	return r, notFound(err, "referral", id)
+}
+
+// --- 18. audit_logs ---
+type AuditLogPatch struct {
+	UserID     Nullable[int]
+	Action     *string
+	EntityType Nullable[string]
+	EntityID   Nullable[int]
+}
+
+func UpdateAuditLog(ctx context.Context, db Executor, id int, p AuditLogPatch) (model.AuditLog, error) {
+	a, err := scanAuditLog(updateRow(ctx, db, "audit_logs", "id", id, []column{
+		{"user_id", p.UserID},
+		{"action", p.Action},
+		{"entity_type", p.EntityType},
+		{"entity_id", p.EntityID},
+	}, auditLogColumns))
+	return a, notFound(err, "audit log", id)
+}
+
+// --- 19. system_settings ---
+type SystemSettingPatch struct {
+	Value Nullable[string]
+}
+
+func UpdateSystemSetting(
+	ctx context.Context,
+	db Executor,
+	key string,
+	p SystemSettingPatch,
+) (model.SystemSetting, error) {
+	s, err := scanSystemSetting(updateRow(ctx, db, "system_settings", "key", key, []column{
+		{"value", p.Value},
+	}, systemSettingColumns))
+	return s, notFound(err, "system setting", key)
+}
+
+// --- 20. temp_bookings ---
+type TempBookingPatch struct {
+	ExpiresAt *time.Time
+}
+
+func UpdateTempBooking(
+	ctx context.Context,
+	db Executor,
+	id int,
+	p TempBookingPatch,
+) (model.TempBooking, error) {
+	tb, err := scanTempBooking(updateRow(ctx, db, "temp_bookings", "id", id, []column{
+		{"expires_at", p.ExpiresAt},
+	}, tempBookingColumns))
+	return tb, notFound(err, "temp booking", id)
+}
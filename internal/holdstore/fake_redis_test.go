@@ -0,0 +1,371 @@
+package holdstore
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis — замена сервера Redis для тестов: RESP2 поверх TCP и ровно
+// те команды, которые использует Redis, с истечением ключей по PX.
+type fakeRedis struct {
+	password string
+
+	mu      sync.Mutex
+	strings map[string]fakeValue
+	zsets   map[string]map[string]float64
+	sets    map[string]map[string]bool
+}
+
+type fakeValue struct {
+	data    string
+	expires time.Time // нулевое — без срока
+}
+
+func startFakeRedis(t *testing.T, password string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{
+		password: password,
+		strings:  map[string]fakeValue{},
+		zsets:    map[string]map[string]float64{},
+		sets:     map[string]map[string]bool{},
+	}
+	var wg sync.WaitGroup
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				f.serve(conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := bufio.NewReader(conn), bufio.NewWriter(conn)
+	authed := f.password == ""
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		var reply any
+		switch {
+		case name == "AUTH":
+			if len(args) == 2 && args[1] == f.password {
+				authed = true
+				reply = "OK"
+			} else {
+				reply = RedisError("WRONGPASS invalid password")
+			}
+		case !authed:
+			reply = RedisError("NOAUTH Authentication required.")
+		default:
+			reply = f.exec(name, args[1:])
+		}
+		writeReply(w, reply)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	reply, err := readReply(r)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) == 0 {
+		return nil, fmt.Errorf("expected a command array, got %T", reply)
+	}
+	args := make([]string, len(items))
+	for i, item := range items {
+		args[i] = fmt.Sprint(item)
+	}
+	return args, nil
+}
+
+func writeReply(w io.Writer, v any) {
+	switch v := v.(type) {
+	case nil:
+		fmt.Fprint(w, "$-1\r\n")
+	case RedisError:
+		fmt.Fprintf(w, "-%s\r\n", string(v))
+	case int:
+		fmt.Fprintf(w, ":%d\r\n", v)
+	case string:
+		fmt.Fprintf(w, "+%s\r\n", v)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+	case []any:
+		fmt.Fprintf(w, "*%d\r\n", len(v))
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	default:
+		panic(fmt.Sprintf("unsupported reply %T", v))
+	}
+}
+
+func (f *fakeRedis) exec(name string, args []string) any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wrongArgs := RedisError("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+
+	switch name {
+	case "SELECT":
+		return "OK"
+	case "INCR":
+		if len(args) != 1 {
+			return wrongArgs
+		}
+		n, _ := strconv.Atoi(f.get(args[0]))
+		n++
+		f.strings[args[0]] = fakeValue{data: strconv.Itoa(n)}
+		return n
+	case "SET":
+		if len(args) < 2 {
+			return wrongArgs
+		}
+		v := fakeValue{data: args[1]}
+		nx := false
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				i++
+				ms, err := strconv.Atoi(args[i])
+				if err != nil || ms <= 0 {
+					return RedisError("ERR invalid expire time in 'set' command")
+				}
+				v.expires = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			default:
+				return RedisError("ERR syntax error")
+			}
+		}
+		if _, ok := f.live(args[0]); ok && nx {
+			return nil
+		}
+		f.strings[args[0]] = v
+		return "OK"
+	case "GET":
+		if len(args) != 1 {
+			return wrongArgs
+		}
+		if v, ok := f.live(args[0]); ok {
+			return []byte(v.data)
+		}
+		return nil
+	case "MGET":
+		result := make([]any, len(args))
+		for i, key := range args {
+			if v, ok := f.live(key); ok {
+				result[i] = []byte(v.data)
+			}
+		}
+		return result
+	case "DEL":
+		n := 0
+		for _, key := range args {
+			if _, ok := f.live(key); ok {
+				n++
+			}
+			delete(f.strings, key)
+		}
+		return n
+	case "ZADD":
+		if len(args) < 3 {
+			return wrongArgs
+		}
+		key, rest, nx := args[0], args[1:], false
+		if strings.ToUpper(rest[0]) == "NX" {
+			rest, nx = rest[1:], true
+		}
+		if len(rest)%2 != 0 {
+			return RedisError("ERR syntax error")
+		}
+		z := f.zsets[key]
+		if z == nil {
+			z = map[string]float64{}
+			f.zsets[key] = z
+		}
+		added := 0
+		for i := 0; i < len(rest); i += 2 {
+			score, err := strconv.ParseFloat(rest[i], 64)
+			if err != nil {
+				return RedisError("ERR value is not a valid float")
+			}
+			if _, ok := z[rest[i+1]]; ok {
+				if !nx {
+					z[rest[i+1]] = score
+				}
+				continue
+			}
+			z[rest[i+1]] = score
+			added++
+		}
+		return added
+	case "ZREM":
+		if len(args) < 2 {
+			return wrongArgs
+		}
+		n := 0
+		for _, m := range args[1:] {
+			if _, ok := f.zsets[args[0]][m]; ok {
+				delete(f.zsets[args[0]], m)
+				n++
+			}
+		}
+		return n
+	case "ZCARD":
+		if len(args) != 1 {
+			return wrongArgs
+		}
+		return len(f.zsets[args[0]])
+	case "ZRANGEBYSCORE", "ZREMRANGEBYSCORE":
+		if len(args) != 3 {
+			return wrongArgs
+		}
+		inRange, err := scoreRange(args[1], args[2])
+		if err != nil {
+			return RedisError("ERR " + err.Error())
+		}
+		z := f.zsets[args[0]]
+		var members []string
+		for m, score := range z {
+			if inRange(score) {
+				members = append(members, m)
+			}
+		}
+		slices.SortFunc(members, func(a, b string) int {
+			if c := cmp.Compare(z[a], z[b]); c != 0 {
+				return c
+			}
+			return strings.Compare(a, b)
+		})
+		if name == "ZREMRANGEBYSCORE" {
+			for _, m := range members {
+				delete(z, m)
+			}
+			return len(members)
+		}
+		result := make([]any, len(members))
+		for i, m := range members {
+			result[i] = []byte(m)
+		}
+		return result
+	case "SADD", "SREM":
+		if len(args) < 2 {
+			return wrongArgs
+		}
+		s := f.sets[args[0]]
+		if s == nil {
+			s = map[string]bool{}
+			f.sets[args[0]] = s
+		}
+		n := 0
+		for _, m := range args[1:] {
+			if s[m] == (name == "SREM") {
+				n++
+			}
+			if name == "SADD" {
+				s[m] = true
+			} else {
+				delete(s, m)
+			}
+		}
+		return n
+	case "SMEMBERS":
+		if len(args) != 1 {
+			return wrongArgs
+		}
+		members := slices.Sorted(func(yield func(string) bool) {
+			for m := range f.sets[args[0]] {
+				if !yield(m) {
+					return
+				}
+			}
+		})
+		result := make([]any, len(members))
+		for i, m := range members {
+			result[i] = []byte(m)
+		}
+		return result
+	}
+	return RedisError("ERR unknown command '" + name + "'")
+}
+
+// live возвращает значение ключа, если он есть и не истёк; истёкший ключ
+// удаляется, как при ленивом истечении в Redis.
+func (f *fakeRedis) live(key string) (fakeValue, bool) {
+	v, ok := f.strings[key]
+	if ok && !v.expires.IsZero() && !time.Now().Before(v.expires) {
+		delete(f.strings, key)
+		return fakeValue{}, false
+	}
+	return v, ok
+}
+
+func (f *fakeRedis) get(key string) string {
+	v, _ := f.live(key)
+	return v.data
+}
+
+// scoreRange разбирает границы ZRANGEBYSCORE: число, "(число", -inf, +inf.
+func scoreRange(minArg, maxArg string) (func(float64) bool, error) {
+	parse := func(s string) (float64, bool, error) {
+		switch s {
+		case "-inf":
+			return math.Inf(-1), false, nil
+		case "+inf", "inf":
+			return math.Inf(1), false, nil
+		}
+		exclusive := strings.HasPrefix(s, "(")
+		v, err := strconv.ParseFloat(strings.TrimPrefix(s, "("), 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("min or max is not a float")
+		}
+		return v, exclusive, nil
+	}
+	lo, loEx, err := parse(minArg)
+	if err != nil {
+		return nil, err
+	}
+	hi, hiEx, err := parse(maxArg)
+	if err != nil {
+		return nil, err
+	}
+	return func(v float64) bool {
+		return (v > lo || !loEx && v == lo) && (v < hi || !hiEx && v == hi)
+	}, nil
+}
@@ -0,0 +1,59 @@
+// Package holdstore хранит временные брони мест (model.TempBooking) —
+// короткоживущие записи, которые не обязательно держать в основной базе.
+//
+// Реализации:
+//   - Repository — таблица temp_bookings через repository.Store;
+//   - Memory — в памяти процесса, с истечением по ExpiresAt;
+//   - Redis — любой сервер с протоколом Redis (RESP2): ключ брони живёт
+//     до ExpiresAt, а отсортированное множество по занятию служит индексом.
+//
+// Ошибки — как у handler: ErrNotFound для отсутствующего токена,
+// ErrDuplicate для повторного токена.
+package holdstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+type Store interface {
+	// Put сохраняет бронь до tb.ExpiresAt; ID выдаёт хранилище.
+	Put(ctx context.Context, tb model.TempBooking) (model.TempBooking, error)
+	// Get находит бронь по токену. Истёкшая, но ещё не удалённая бронь
+	// может вернуться — проверять ExpiresAt должен вызывающий.
+	Get(ctx context.Context, token string) (model.TempBooking, error)
+	// Delete удаляет бронь; из двух конкурентных Delete успешен только один.
+	Delete(ctx context.Context, token string) error
+	// Active возвращает все брони занятия, действующие в момент at.
+	Active(ctx context.Context, scheduleID int, at time.Time) ([]model.TempBooking, error)
+	// Count — число броней занятия, действующих в момент at.
+	Count(ctx context.Context, scheduleID int, at time.Time) (int, error)
+	// Sweep удаляет брони, истёкшие к моменту at, и возвращает число
+	// удалённых по занятиям.
+	Sweep(ctx context.Context, at time.Time) (map[int]int, error)
+}
+
+// Transactional — хранилище в той же базе, что и брони: WithTx возвращает
+// его копию, работающую внутри транзакции tx.
+type Transactional interface {
+	Store
+	WithTx(tx repository.Store) Store
+}
+
+func notFound(token string) error {
+	return fmt.Errorf("temp booking %v: %w", token, handler.ErrNotFound)
+}
+
+func duplicate() error {
+	return &handler.ConstraintError{
+		Kind:       handler.ErrDuplicate,
+		Table:      "temp_bookings",
+		Constraint: "temp_bookings_token_key",
+		Columns:    []string{"token"},
+	}
+}
@@ -0,0 +1,309 @@
+package holdstore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository/memory"
+	"databases2026/pkg/model"
+)
+
+// Одни и те же сценарии выполняются на всех реализациях; Redis работает
+// с fakeRedis.
+func forEachStore(t *testing.T, fn func(t *testing.T, st Store)) {
+	t.Helper()
+	t.Run("memory", func(t *testing.T) { fn(t, NewMemory()) })
+	t.Run("repository", func(t *testing.T) { fn(t, NewRepository(memory.New())) })
+	t.Run("redis", func(t *testing.T) {
+		opts, err := ParseRedisURL("redis://:secret@" + startFakeRedis(t, "secret") + "/2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewRedis(opts)
+		t.Cleanup(func() { r.Close() })
+		fn(t, r)
+	})
+}
+
+func hold(token string, scheduleID int, ttl time.Duration) model.TempBooking {
+	return model.TempBooking{
+		UserID:     1,
+		ScheduleID: scheduleID,
+		ExpiresAt:  time.Now().Add(ttl),
+		Token:      token,
+	}
+}
+
+func put(t *testing.T, st Store, tb model.TempBooking) model.TempBooking {
+	t.Helper()
+	v, err := st.Put(context.Background(), tb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func tokens(holds []model.TempBooking) []string {
+	var result []string
+	for _, tb := range holds {
+		result = append(result, tb.Token)
+	}
+	return result
+}
+
+func TestPutGetDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		a := put(t, st, hold("a", 1, time.Hour))
+		b := put(t, st, hold("b", 1, time.Hour))
+		if a.ID == 0 || a.ID == b.ID {
+			t.Fatalf("ids %d and %d", a.ID, b.ID)
+		}
+
+		got, err := st.Get(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ID != a.ID || got.ScheduleID != 1 || got.UserID != 1 || !got.ExpiresAt.Equal(a.ExpiresAt) {
+			t.Fatalf("Get = %+v, want %+v", got, a)
+		}
+
+		if _, err := st.Put(ctx, hold("a", 2, time.Hour)); !errors.Is(err, handler.ErrDuplicate) {
+			t.Fatalf("Put of a live token: %v", err)
+		}
+		if _, err := st.Get(ctx, "missing"); !errors.Is(err, handler.ErrNotFound) {
+			t.Fatalf("Get of a missing token: %v", err)
+		}
+
+		if err := st.Delete(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := st.Delete(ctx, "a"); !errors.Is(err, handler.ErrNotFound) {
+			t.Fatalf("second Delete: %v", err)
+		}
+		if _, err := st.Get(ctx, "a"); !errors.Is(err, handler.ErrNotFound) {
+			t.Fatalf("Get after Delete: %v", err)
+		}
+		if active, err := st.Active(ctx, 1, time.Now()); err != nil || !reflect.DeepEqual(tokens(active), []string{"b"}) {
+			t.Fatalf("Active after Delete = %v, %v", tokens(active), err)
+		}
+	})
+}
+
+func TestConcurrentDelete(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		const n = 10
+		put(t, st, hold("a", 1, time.Hour))
+
+		errs := make([]error, n)
+		var wg sync.WaitGroup
+		for i := range n {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = st.Delete(context.Background(), "a")
+			}()
+		}
+		wg.Wait()
+
+		deleted := 0
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				deleted++
+			case !errors.Is(err, handler.ErrNotFound):
+				t.Errorf("Delete: %v", err)
+			}
+		}
+		if deleted != 1 {
+			t.Fatalf("%d deletes succeeded, want 1", deleted)
+		}
+	})
+}
+
+func TestActiveAndCount(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		put(t, st, hold("short", 1, 10*time.Minute))
+		put(t, st, hold("long", 1, time.Hour))
+		put(t, st, hold("other", 2, time.Hour))
+
+		for _, tt := range []struct {
+			at   time.Time
+			want []string
+		}{
+			{time.Now(), []string{"short", "long"}},
+			{time.Now().Add(30 * time.Minute), []string{"long"}},
+			{time.Now().Add(2 * time.Hour), nil},
+		} {
+			active, err := st.Active(ctx, 1, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tokens(active); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Active at %s = %v, want %v", tt.at, got, tt.want)
+			}
+			n, err := st.Count(ctx, 1, tt.at)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != len(tt.want) {
+				t.Errorf("Count at %s = %d, want %d", tt.at, n, len(tt.want))
+			}
+		}
+	})
+}
+
+// Броней у занятия может быть больше, чем помещается в одну страницу.
+func TestCountBeyondPageLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		const n = handler.MaxPageLimit + 5
+		for i := range n {
+			put(t, st, hold(fmt.Sprintf("t%d", i), 1, time.Hour))
+		}
+		count, err := st.Count(ctx, 1, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		active, err := st.Active(ctx, 1, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != n || len(active) != n {
+			t.Fatalf("Count = %d, len(Active) = %d, want %d", count, len(active), n)
+		}
+	})
+}
+
+func TestExpiryAndSweep(t *testing.T) {
+	forEachStore(t, func(t *testing.T, st Store) {
+		ctx := context.Background()
+		const ttl = 100 * time.Millisecond
+		put(t, st, hold("a", 1, ttl))
+		put(t, st, hold("b", 1, ttl))
+		put(t, st, hold("c", 2, ttl))
+		put(t, st, hold("live", 2, time.Hour))
+		time.Sleep(2 * ttl)
+
+		// Истёкшая бронь уже не считается, даже если её ещё не вымели.
+		n, err := st.Count(ctx, 1, time.Now())
+		if err != nil || n != 0 {
+			t.Fatalf("Count of expired holds = %d, %v", n, err)
+		}
+
+		swept, err := st.Sweep(ctx, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[int]int{1: 2, 2: 1}; !maps.Equal(swept, want) {
+			t.Fatalf("Sweep = %v, want %v", swept, want)
+		}
+		if swept, err := st.Sweep(ctx, time.Now()); err != nil || len(swept) != 0 {
+			t.Fatalf("second Sweep = %v, %v", swept, err)
+		}
+
+		if active, err := st.Active(ctx, 2, time.Now()); err != nil || !reflect.DeepEqual(tokens(active), []string{"live"}) {
+			t.Fatalf("Active after Sweep = %v, %v", tokens(active), err)
+		}
+		// Токен истёкшей брони можно занять снова.
+		put(t, st, hold("a", 1, time.Hour))
+	})
+}
+
+func TestRedisPutRejectsPastExpiry(t *testing.T) {
+	r := NewRedis(RedisOptions{Addr: startFakeRedis(t, "")})
+	defer r.Close()
+	if _, err := r.Put(context.Background(), hold("a", 1, -time.Second)); err == nil {
+		t.Fatal("Put of an expired hold succeeded")
+	}
+}
+
+func TestRedisWrongPassword(t *testing.T) {
+	r := NewRedis(RedisOptions{Addr: startFakeRedis(t, "secret"), Password: "guess"})
+	defer r.Close()
+	_, err := r.Get(context.Background(), "a")
+	var rerr RedisError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Get with a wrong password: %v", err)
+	}
+}
+
+func TestParseRedisURL(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    RedisOptions
+		wantErr bool
+	}{
+		{raw: "redis://localhost", want: RedisOptions{Addr: "localhost:6379"}},
+		{raw: "redis://cache:6380", want: RedisOptions{Addr: "cache:6380"}},
+		{raw: "redis://:secret@cache/3", want: RedisOptions{Addr: "cache:6379", Password: "secret", DB: 3}},
+		{raw: "redis://user:secret@[::1]:6390/0", want: RedisOptions{Addr: "[::1]:6390", Password: "secret"}},
+		{raw: "redis://", want: RedisOptions{}},
+		{raw: "http://localhost", wantErr: true},
+		{raw: "redis://localhost/db", wantErr: true},
+		{raw: "redis://%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRedisURL(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseRedisURL(%q) = %+v, want an error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseRedisURL(%q) = %+v, %v, want %+v", tt.raw, got, err, tt.want)
+		}
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    any
+		wantErr bool
+	}{
+		{name: "simple string", in: "+OK\r\n", want: "OK"},
+		{name: "integer", in: ":-42\r\n", want: int64(-42)},
+		{name: "bulk string", in: "$5\r\na\r\nbc\r\n", want: "a\r\nbc"},
+		{name: "empty bulk string", in: "$0\r\n\r\n", want: ""},
+		{name: "nil bulk string", in: "$-1\r\n", want: nil},
+		{name: "nil array", in: "*-1\r\n", want: nil},
+		{
+			name: "nested array",
+			in:   "*3\r\n$1\r\na\r\n$-1\r\n*2\r\n:1\r\n-ERR inner\r\n",
+			want: []any{"a", nil, []any{int64(1), RedisError("ERR inner")}},
+		},
+		{name: "error", in: "-WRONGTYPE bad key\r\n", wantErr: true},
+		{name: "unknown type", in: "?1\r\n", wantErr: true},
+		{name: "missing CR", in: "+OK\n", wantErr: true},
+		{name: "bad integer", in: ":x\r\n", wantErr: true},
+		{name: "short bulk string", in: "$10\r\nabc\r\n", wantErr: true},
+		{name: "short array", in: "*2\r\n:1\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readReply(bufio.NewReader(strings.NewReader(tt.in)))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("readReply = %#v, want an error", got)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("readReply = %#v, %v, want %#v", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package holdstore
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+// Memory хранит брони в памяти процесса: подходит для одного экземпляра
+// приложения и для тестов. При перезапуске брони пропадают — это лишь
+// отпускает места раньше срока. Истёкшие брони не видны Get и Active, а
+// удаляются при Sweep.
+type Memory struct {
+	mu    sync.Mutex
+	holds map[string]model.TempBooking
+	seq   int
+}
+
+var _ Store = (*Memory)(nil)
+
+func NewMemory() *Memory {
+	return &Memory{holds: map[string]model.TempBooking{}}
+}
+
+func (m *Memory) Put(ctx context.Context, tb model.TempBooking) (model.TempBooking, error) {
+	if err := ctx.Err(); err != nil {
+		return tb, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if other, ok := m.holds[tb.Token]; ok && other.ExpiresAt.After(time.Now()) {
+		return tb, duplicate()
+	}
+	m.seq++
+	tb.ID = m.seq
+	m.holds[tb.Token] = tb
+	return tb, nil
+}
+
+func (m *Memory) Get(ctx context.Context, token string) (model.TempBooking, error) {
+	if err := ctx.Err(); err != nil {
+		return model.TempBooking{}, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tb, ok := m.holds[token]
+	if !ok || !tb.ExpiresAt.After(time.Now()) {
+		return model.TempBooking{}, notFound(token)
+	}
+	return tb, nil
+}
+
+func (m *Memory) Delete(ctx context.Context, token string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tb, ok := m.holds[token]
+	if !ok || !tb.ExpiresAt.After(time.Now()) {
+		return notFound(token)
+	}
+	delete(m.holds, token)
+	return nil
+}
+
+func (m *Memory) Active(ctx context.Context, scheduleID int, at time.Time) ([]model.TempBooking, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []model.TempBooking
+	for _, tb := range m.holds {
+		if tb.ScheduleID == scheduleID && tb.ExpiresAt.After(at) {
+			result = append(result, tb)
+		}
+	}
+	slices.SortFunc(result, func(a, b model.TempBooking) int { return a.ID - b.ID })
+	return result, nil
+}
+
+func (m *Memory) Count(ctx context.Context, scheduleID int, at time.Time) (int, error) {
+	active, err := m.Active(ctx, scheduleID, at)
+	return len(active), err
+}
+
+func (m *Memory) Sweep(ctx context.Context, at time.Time) (map[int]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	swept := make(map[int]int)
+	for token, tb := range m.holds {
+		if !tb.ExpiresAt.After(at) {
+			delete(m.holds, token)
+			swept[tb.ScheduleID]++
+		}
+	}
+	return swept, nil
+}
@@ -0,0 +1,208 @@
+package holdstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+// Redis хранит брони на сервере с протоколом Redis. Ключи (с Prefix):
+//
+//	hold:<token>          JSON брони, истекает в ExpiresAt (SET PX NX)
+//	schedule:<id>:holds   ZSET токенов со ExpiresAt в мс — индекс по занятию
+//	schedules             SET занятий, у которых есть брони
+//	hold:seq              счётчик ID
+//
+// Индекс — надмножество живых броней: Put пишет его раньше ключа брони,
+// а Delete чистит позже. Поэтому сбой между командами оставляет в индексе
+// лишний токен (Active и Count его отбрасывают, Sweep удаляет), но никогда
+// не бронь, которую Active не видит: место не продаётся дважды.
+type Redis struct {
+	c      *respClient
+	prefix string
+}
+
+var _ Store = (*Redis)(nil)
+
+func NewRedis(opts RedisOptions) *Redis {
+	prefix := opts.Prefix
+	if prefix == "" {
+		prefix = "sports:"
+	}
+	return &Redis{c: newRespClient(opts), prefix: prefix}
+}
+
+// Close закрывает простаивающие соединения.
+func (r *Redis) Close() error {
+	return r.c.Close()
+}
+
+func (r *Redis) holdKey(token string) string { return r.prefix + "hold:" + token }
+func (r *Redis) indexKey(scheduleID int) string {
+	return r.prefix + "schedule:" + strconv.Itoa(scheduleID) + ":holds"
+}
+func (r *Redis) schedulesKey() string { return r.prefix + "schedules" }
+
+func millis(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10)
+}
+
+func (r *Redis) Put(ctx context.Context, tb model.TempBooking) (model.TempBooking, error) {
+	ttl := time.Until(tb.ExpiresAt).Milliseconds()
+	if ttl <= 0 {
+		return tb, fmt.Errorf("temp booking %s: expires_at %s is in the past", tb.Token, tb.ExpiresAt)
+	}
+
+	id, err := r.c.doInt(ctx, "INCR", r.prefix+"hold:seq")
+	if err != nil {
+		return tb, err
+	}
+	tb.ID = int(id)
+
+	data, err := json.Marshal(tb)
+	if err != nil {
+		return tb, err
+	}
+
+	if _, err := r.c.do(ctx, "SADD", r.schedulesKey(), strconv.Itoa(tb.ScheduleID)); err != nil {
+		return tb, err
+	}
+	// ZADD NX не трогает чужой элемент индекса с тем же токеном.
+	added, err := r.c.doInt(ctx, "ZADD", r.indexKey(tb.ScheduleID), "NX", millis(tb.ExpiresAt), tb.Token)
+	if err != nil {
+		return tb, err
+	}
+	if added == 0 {
+		return tb, duplicate()
+	}
+
+	reply, err := r.c.do(ctx, "SET", r.holdKey(tb.Token), string(data), "PX", strconv.FormatInt(ttl, 10), "NX")
+	if err == nil && reply == nil {
+		err = duplicate()
+	}
+	if err != nil {
+		// Без ключа элемент индекса безвреден, но убрать его лучше сразу.
+		r.c.do(context.WithoutCancel(ctx), "ZREM", r.indexKey(tb.ScheduleID), tb.Token)
+		return tb, err
+	}
+	return tb, nil
+}
+
+func (r *Redis) Get(ctx context.Context, token string) (model.TempBooking, error) {
+	reply, err := r.c.do(ctx, "GET", r.holdKey(token))
+	if err != nil {
+		return model.TempBooking{}, err
+	}
+	if reply == nil {
+		return model.TempBooking{}, notFound(token)
+	}
+	return decodeHold(reply)
+}
+
+func (r *Redis) Delete(ctx context.Context, token string) error {
+	tb, err := r.Get(ctx, token)
+	if err != nil {
+		return err
+	}
+	// DEL решает, кто из конкурентов удалил бронь первым.
+	n, err := r.c.doInt(ctx, "DEL", r.holdKey(token))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound(token)
+	}
+	_, err = r.c.do(ctx, "ZREM", r.indexKey(tb.ScheduleID), token)
+	return err
+}
+
+func (r *Redis) Active(ctx context.Context, scheduleID int, at time.Time) ([]model.TempBooking, error) {
+	tokens, err := r.c.doArray(ctx, "ZRANGEBYSCORE", r.indexKey(scheduleID), "("+millis(at), "+inf")
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+
+	keys := []string{"MGET"}
+	for _, t := range tokens {
+		keys = append(keys, r.holdKey(fmt.Sprint(t)))
+	}
+	values, err := r.c.doArray(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.TempBooking
+	for _, v := range values {
+		if v == nil {
+			continue // бронь удалена или истекла, а индекс ещё не очищен
+		}
+		tb, err := decodeHold(v)
+		if err != nil {
+			return nil, err
+		}
+		if tb.ExpiresAt.After(at) {
+			result = append(result, tb)
+		}
+	}
+	return result, nil
+}
+
+// Count проверяет ключи броней, а не только индекс: в индексе могут
+// остаться токены удалённых броней.
+func (r *Redis) Count(ctx context.Context, scheduleID int, at time.Time) (int, error) {
+	active, err := r.Active(ctx, scheduleID, at)
+	return len(active), err
+}
+
+func (r *Redis) Sweep(ctx context.Context, at time.Time) (map[int]int, error) {
+	members, err := r.c.doArray(ctx, "SMEMBERS", r.schedulesKey())
+	if err != nil {
+		return nil, err
+	}
+
+	swept := make(map[int]int)
+	for _, v := range members {
+		member := fmt.Sprint(v)
+		id, err := strconv.Atoi(member)
+		if err != nil {
+			return swept, fmt.Errorf("invalid schedule id %q in %s", member, r.schedulesKey())
+		}
+		n, err := r.c.doInt(ctx, "ZREMRANGEBYSCORE", r.indexKey(id), "-inf", millis(at))
+		if err != nil {
+			return swept, err
+		}
+		if n > 0 {
+			swept[id] = int(n)
+		}
+
+		// Пустой индекс убирается из списка занятий. Если Put успеет между
+		// ZCARD и SREM, его бронь всё равно истечёт по TTL, но Sweep о ней
+		// не сообщит.
+		left, err := r.c.doInt(ctx, "ZCARD", r.indexKey(id))
+		if err != nil {
+			return swept, err
+		}
+		if left == 0 {
+			if _, err := r.c.do(ctx, "SREM", r.schedulesKey(), member); err != nil {
+				return swept, err
+			}
+		}
+	}
+	return swept, nil
+}
+
+func decodeHold(v any) (model.TempBooking, error) {
+	var tb model.TempBooking
+	s, ok := v.(string)
+	if !ok {
+		return tb, fmt.Errorf("unexpected redis reply %T for a hold", v)
+	}
+	if err := json.Unmarshal([]byte(s), &tb); err != nil {
+		return tb, fmt.Errorf("invalid hold in redis: %w", err)
+	}
+	return tb, nil
+}
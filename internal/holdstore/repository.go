@@ -0,0 +1,92 @@
+package holdstore
+
+import (
+	"context"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+// Repository хранит брони в temp_bookings. Внутри транзакции (WithTx)
+// изменения броней фиксируются и откатываются вместе с бронированиями.
+type Repository struct {
+	store repository.Store
+}
+
+var _ Transactional = Repository{}
+
+func NewRepository(store repository.Store) Repository {
+	return Repository{store: store}
+}
+
+func (r Repository) WithTx(tx repository.Store) Store {
+	return Repository{store: tx}
+}
+
+func (r Repository) Put(ctx context.Context, tb model.TempBooking) (model.TempBooking, error) {
+	return r.store.TempBookings().Create(ctx, tb)
+}
+
+func (r Repository) Get(ctx context.Context, token string) (model.TempBooking, error) {
+	return r.store.TempBookings().GetByToken(ctx, token)
+}
+
+func (r Repository) Delete(ctx context.Context, token string) error {
+	tb, err := r.store.TempBookings().GetByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	return r.store.TempBookings().Delete(ctx, tb.ID)
+}
+
+// Active читает брони страницами: вместимость зала не ограничена сверху,
+// поэтому броней может быть больше MaxPageLimit.
+func (r Repository) Active(ctx context.Context, scheduleID int, at time.Time) ([]model.TempBooking, error) {
+	var result []model.TempBooking
+	for {
+		page, err := r.store.TempBookings().List(ctx, handler.TempBookingFilter{
+			ScheduleID: scheduleID,
+			ActiveAt:   at,
+			Page:       handler.Page{Limit: handler.MaxPageLimit, Offset: len(result)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if len(page) < handler.MaxPageLimit {
+			return result, nil
+		}
+	}
+}
+
+func (r Repository) Count(ctx context.Context, scheduleID int, at time.Time) (int, error) {
+	return r.store.TempBookings().Count(ctx, handler.TempBookingFilter{ScheduleID: scheduleID, ActiveAt: at})
+}
+
+func (r Repository) Sweep(ctx context.Context, at time.Time) (map[int]int, error) {
+	swept := make(map[int]int)
+	for {
+		expired, err := r.store.TempBookings().List(ctx, handler.TempBookingFilter{
+			ExpiredBefore: at,
+			Page:          handler.Page{Limit: handler.MaxPageLimit},
+		})
+		if err != nil || len(expired) == 0 {
+			return swept, err
+		}
+		// Каждое занятие из пачки очищается целиком, поэтому цикл сходится.
+		seen := make(map[int]bool)
+		for _, tb := range expired {
+			if seen[tb.ScheduleID] {
+				continue
+			}
+			seen[tb.ScheduleID] = true
+			n, err := r.store.TempBookings().DeleteExpired(ctx, tb.ScheduleID, at)
+			if err != nil {
+				return swept, err
+			}
+			swept[tb.ScheduleID] += n
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package holdstore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Минимальный клиент протокола Redis (RESP2): только то, что нужно Redis.
+// Ответы: string (простая и bulk-строка), int64, nil, []any и RedisError.
+
+// RedisOptions — параметры подключения к серверу Redis.
+type RedisOptions struct {
+	Addr     string // host:port, по умолчанию localhost:6379
+	Password string
+	DB       int
+	// Prefix добавляется ко всем ключам (по умолчанию "sports:").
+	Prefix string
+	// PoolSize — сколько простаивающих соединений держать (по умолчанию 10).
+	PoolSize    int
+	DialTimeout time.Duration
+}
+
+// ParseRedisURL разбирает строку вида redis://[:password@]host[:port][/db].
+func ParseRedisURL(raw string) (RedisOptions, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return RedisOptions{}, err
+	}
+	if u.Scheme != "redis" {
+		return RedisOptions{}, fmt.Errorf("redis url %q: scheme must be redis", raw)
+	}
+
+	opts := RedisOptions{Addr: u.Host}
+	if u.Port() == "" && u.Host != "" {
+		opts.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if p, ok := u.User.Password(); ok {
+		opts.Password = p
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if opts.DB, err = strconv.Atoi(db); err != nil {
+			return RedisOptions{}, fmt.Errorf("redis url %q: invalid database %q", raw, db)
+		}
+	}
+	return opts, nil
+}
+
+// RedisError — ответ сервера с ошибкой (-ERR ...).
+type RedisError string
+
+func (e RedisError) Error() string { return "redis: " + string(e) }
+
+type respClient struct {
+	opts RedisOptions
+	idle chan *respConn
+}
+
+type respConn struct {
+	c net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+func newRespClient(opts RedisOptions) *respClient {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	return &respClient{opts: opts, idle: make(chan *respConn, opts.PoolSize)}
+}
+
+// do выполняет команду. Соединение возвращается в пул только после
+// полностью прочитанного ответа; при сетевой ошибке оно закрывается.
+func (c *respClient) do(ctx context.Context, args ...string) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.roundTrip(ctx, args)
+	var rerr RedisError
+	if err != nil && !errors.As(err, &rerr) {
+		conn.c.Close()
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	c.put(conn)
+	return reply, err
+}
+
+// doInt выполняет команду с целочисленным ответом.
+func (c *respClient) doInt(ctx context.Context, args ...string) (int64, error) {
+	reply, err := c.do(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis %s: unexpected reply %T", args[0], reply)
+	}
+	return n, nil
+}
+
+// doArray выполняет команду с ответом-массивом; nil — пустой массив.
+func (c *respClient) doArray(ctx context.Context, args ...string) ([]any, error) {
+	reply, err := c.do(ctx, args...)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("redis %s: unexpected reply %T", args[0], reply)
+	}
+	return items, nil
+}
+
+func (c *respClient) get(ctx context.Context) (*respConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	d := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", c.opts.Addr, err)
+	}
+	conn := &respConn{c: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+
+	var setup [][]string
+	if c.opts.Password != "" {
+		setup = append(setup, []string{"AUTH", c.opts.Password})
+	}
+	if c.opts.DB != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.DB)})
+	}
+	for _, args := range setup {
+		if _, err := conn.roundTrip(ctx, args); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("redis %s: %w", args[0], err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *respClient) put(conn *respConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.c.Close()
+	}
+}
+
+// Close закрывает простаивающие соединения.
+func (c *respClient) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+func (conn *respConn) roundTrip(ctx context.Context, args []string) (any, error) {
+	// Без дедлайна у ctx нулевое время снимает ограничение.
+	deadline, _ := ctx.Deadline()
+	if err := conn.c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(conn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(conn.w, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if err := conn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(conn.r)
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, RedisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]any, n)
+		for i := range items {
+			// Ошибка элемента массива не прерывает разбор ответа.
+			item, err := readReply(r)
+			var rerr RedisError
+			switch {
+			case errors.As(err, &rerr):
+				items[i] = rerr
+			case err != nil:
+				return nil, err
+			default:
+				items[i] = item
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown reply type %q", kind)
+	}
+}
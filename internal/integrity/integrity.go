@@ -0,0 +1,234 @@
+// Package integrity проверяет инварианты данных, которые схема сама не
+// гарантирует: пересечения расписания, переполнение залов, рассинхрон
+// счётчиков и т.п.
+package integrity
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"databases2026/internal/handler"
+)
+
+// Check — проверка; query возвращает id нарушающих строк (столбец id).
+type Check struct {
+	Name        string
+	Table       string // таблица, к которой относятся id
+	Description string
+	query       string
+}
+
+var checks = []Check{
+	{
+		Name:        "schedule_room_overlap",
+		Table:       "schedules",
+		Description: "schedule overlaps an earlier schedule in the same room",
+		query: `
+			SELECT id FROM (
+				SELECT id, start_time, max(end_time) OVER (
+					PARTITION BY room_id ORDER BY start_time, id
+					ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS prev_end
+				FROM schedules
+			) s
+			WHERE start_time < prev_end`,
+	},
+	{
+		Name:        "schedule_coach_overlap",
+		Table:       "schedules",
+		Description: "schedule overlaps an earlier schedule of the same coach",
+		query: `
+			SELECT id FROM (
+				SELECT s.id, s.start_time, max(s.end_time) OVER (
+					PARTITION BY c.coach_id ORDER BY s.start_time, s.id
+					ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING) AS prev_end
+				FROM schedules s
+				JOIN classes c ON c.id = s.class_id
+			) s
+			WHERE start_time < prev_end`,
+	},
+	{
+		Name:        "schedule_over_capacity",
+		Table:       "schedules",
+		Description: "confirmed bookings exceed the room capacity",
+		query: `
+			SELECT s.id
+			FROM schedules s
+			JOIN rooms r ON r.id = s.room_id
+			JOIN bookings b ON b.schedule_id = s.id AND b.status IS DISTINCT FROM 'cancelled'
+			GROUP BY s.id, r.capacity
+			HAVING count(*) > r.capacity`,
+	},
+	{
+		Name:        "promotion_used_count",
+		Table:       "promotions",
+		Description: "used_count differs from the number of promotion_usage rows",
+		query: `
+			SELECT p.id
+			FROM promotions p
+			LEFT JOIN promotion_usage pu ON pu.promotion_id = p.id
+			GROUP BY p.id, p.used_count
+			HAVING COALESCE(p.used_count, 0) <> count(pu.id)`,
+	},
+	{
+		Name:        "temp_booking_missing_schedule",
+		Table:       "temp_bookings",
+		Description: "temp booking references a schedule that does not exist",
+		query: `
+			SELECT t.id
+			FROM temp_bookings t
+			WHERE NOT EXISTS (SELECT 1 FROM schedules s WHERE s.id = t.schedule_id)`,
+	},
+	{
+		Name:        "temp_booking_missing_user",
+		Table:       "temp_bookings",
+		Description: "temp booking references a user that does not exist",
+		query: `
+			SELECT t.id
+			FROM temp_bookings t
+			WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = t.user_id)`,
+	},
+	{
+		Name:        "user_multiple_active_memberships",
+		Table:       "users",
+		Description: "user has more than one active membership",
+		query: `
+			SELECT user_id AS id
+			FROM user_memberships
+			WHERE is_active
+			GROUP BY user_id
+			HAVING count(*) > 1`,
+	},
+	{
+		Name:        "expired_membership_active",
+		Table:       "user_memberships",
+		Description: "membership ended but is still marked active",
+		query: `
+			SELECT id
+			FROM user_memberships
+			WHERE is_active AND ended_at < CURRENT_DATE`,
+	},
+}
+
+// Checks возвращает каталог проверок в порядке выполнения.
+func Checks() []Check {
+	return slices.Clone(checks)
+}
+
+type Options struct {
+	// Only — имена проверок; пусто — все.
+	Only []string
+	// Limit — сколько id нарушителей возвращать на проверку (по умолчанию 20);
+	// Count при этом всегда полный.
+	Limit int
+}
+
+const defaultLimit = 20
+
+type Result struct {
+	Check       string `json:"check"`
+	Table       string `json:"table"`
+	Description string `json:"description"`
+	Count       int    `json:"count"`
+	IDs         []int  `json:"ids"`
+}
+
+func (r Result) OK() bool {
+	return r.Count == 0
+}
+
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// OK — ни одна проверка не нашла нарушений.
+func (r Report) OK() bool {
+	for _, res := range r.Results {
+		if !res.OK() {
+			return false
+		}
+	}
+	return true
+}
+
+// Failed возвращает проверки с нарушениями.
+func (r Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.OK() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Run выполняет проверки в одной транзакции только для чтения, чтобы все
+// они видели один снимок данных.
+func Run(ctx context.Context, db *sql.DB, opts Options) (Report, error) {
+	var report Report
+	txOpts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err := handler.WithTx(ctx, db, txOpts, func(tx *sql.Tx) error {
+		var err error
+		report, err = RunTx(ctx, tx, opts)
+		return err
+	})
+	return report, err
+}
+
+// RunTx выполняет проверки внутри уже открытой транзакции tx.
+func RunTx(ctx context.Context, tx *sql.Tx, opts Options) (Report, error) {
+	selected, err := selectChecks(opts.Only)
+	if err != nil {
+		return Report{}, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	var report Report
+	for _, c := range selected {
+		res, err := run(ctx, tx, c, limit)
+		if err != nil {
+			return report, fmt.Errorf("check %s: %w", c.Name, err)
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+func selectChecks(only []string) ([]Check, error) {
+	if len(only) == 0 {
+		return checks, nil
+	}
+	var selected []Check
+	for _, name := range only {
+		i := slices.IndexFunc(checks, func(c Check) bool { return c.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown check %q", name)
+		}
+		selected = append(selected, checks[i])
+	}
+	return selected, nil
+}
+
+func run(ctx context.Context, tx *sql.Tx, c Check, limit int) (Result, error) {
+	res := Result{Check: c.Name, Table: c.Table, Description: c.Description, IDs: []int{}}
+
+	query := "SELECT count(*) OVER (), id FROM (" + c.query + ") v ORDER BY id LIMIT $1"
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return res, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&res.Count, &id); err != nil {
+			return res, err
+		}
+		res.IDs = append(res.IDs, id)
+	}
+	return res, rows.Err()
+}
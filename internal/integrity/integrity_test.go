@@ -0,0 +1,136 @@
+package integrity_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"databases2026/internal/integrity"
+
+	_ "github.com/lib/pq"
+)
+
+// Нарушения заводятся в транзакции, которая в конце откатывается, поэтому
+// тест можно запускать на любой базе с применёнными миграциями
+// (TEST_DATABASE_URL). Данные, уже лежащие в базе, тоже могут нарушать
+// инварианты, так что проверяется только, что найдены свои строки.
+func TestChecks(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	insert := func(query string, args ...any) int {
+		t.Helper()
+		var id int
+		if err := tx.QueryRowContext(ctx, query+" RETURNING id", args...).Scan(&id); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		return id
+	}
+	exec := func(query string) {
+		t.Helper()
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+	}
+	suffix := fmt.Sprint(time.Now().UnixNano())
+
+	// Пересечение в зале запрещает схема (0003); здесь нужно именно
+	// нарушение, поэтому ограничение снимается до отката.
+	exec("ALTER TABLE schedules DROP CONSTRAINT schedules_room_overlap")
+	exec("ALTER TABLE schedules DISABLE TRIGGER schedules_coach_overlap")
+
+	user := insert("INSERT INTO users (email) VALUES ($1)", "integrity-"+suffix+"@example.com")
+	other := insert("INSERT INTO users (email) VALUES ($1)", "integrity-other-"+suffix+"@example.com")
+	exec(fmt.Sprintf("INSERT INTO coaches (user_id) VALUES (%d)", user))
+	sport := insert("INSERT INTO sports (name) VALUES ($1)", "integrity-"+suffix)
+	class := insert("INSERT INTO classes (sport_id, coach_id) VALUES ($1, $2)", sport, user)
+	room := insert("INSERT INTO rooms (capacity) VALUES (1)")
+	start := time.Date(2031, 1, 1, 10, 0, 0, 0, time.UTC)
+	insert("INSERT INTO schedules (class_id, room_id, start_time, end_time) VALUES ($1, $2, $3, $4)",
+		class, room, start, start.Add(2*time.Hour))
+	overlapping := insert("INSERT INTO schedules (class_id, room_id, start_time, end_time) VALUES ($1, $2, $3, $4)",
+		class, room, start.Add(time.Hour), start.Add(3*time.Hour))
+
+	// Два подтверждённых места в зале на одного.
+	insert("INSERT INTO bookings (user_id, schedule_id) VALUES ($1, $2)", user, overlapping)
+	insert("INSERT INTO bookings (user_id, schedule_id) VALUES ($1, $2)", other, overlapping)
+
+	promotion := insert(`INSERT INTO promotions (code, discount_percent, valid_from, valid_until, used_count)
+		VALUES ($1, 10, '2031-01-01', '2031-12-31', 5)`, "integrity-"+suffix)
+	insert("INSERT INTO promotion_usage (user_id, promotion_id) VALUES ($1, $2)", user, promotion)
+
+	orphan := insert(`INSERT INTO temp_bookings (user_id, schedule_id, expires_at, token)
+		VALUES ($1, -1, '2031-01-01', $2)`, user, "integrity-"+suffix)
+
+	membership := insert("INSERT INTO memberships (duration_days, price) VALUES (30, 10)")
+	insert(`INSERT INTO user_memberships (user_id, membership_id, started_at, ended_at, is_active)
+		VALUES ($1, $2, CURRENT_DATE, CURRENT_DATE + 30, TRUE)`, user, membership)
+	expired := insert(`INSERT INTO user_memberships (user_id, membership_id, started_at, ended_at, is_active)
+		VALUES ($1, $2, CURRENT_DATE - 60, CURRENT_DATE - 30, TRUE)`, user, membership)
+
+	want := map[string]int{
+		"schedule_room_overlap":            overlapping,
+		"schedule_coach_overlap":           overlapping,
+		"schedule_over_capacity":           overlapping,
+		"promotion_used_count":             promotion,
+		"temp_booking_missing_schedule":    orphan,
+		"user_multiple_active_memberships": user,
+		"expired_membership_active":        expired,
+	}
+	var only []string
+	for name := range want {
+		only = append(only, name)
+	}
+
+	report, err := integrity.RunTx(ctx, tx, integrity.Options{Only: only, Limit: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() {
+		t.Fatal("report is OK, want failures")
+	}
+	var failed []string
+	for _, res := range report.Failed() {
+		failed = append(failed, res.Check)
+	}
+	for _, res := range report.Results {
+		id := want[res.Check]
+		if !slices.Contains(failed, res.Check) {
+			t.Errorf("%s passed, want row %d reported", res.Check, id)
+			continue
+		}
+		if !slices.Contains(res.IDs, id) {
+			t.Errorf("%s reported %v, want it to include %d", res.Check, res.IDs, id)
+		}
+		if res.Count != len(res.IDs) {
+			t.Errorf("%s: count %d, but %d ids", res.Check, res.Count, len(res.IDs))
+		}
+	}
+	if len(report.Results) != len(want) {
+		t.Errorf("%d results, want %d", len(report.Results), len(want))
+	}
+}
+
+func TestRunTxUnknownCheck(t *testing.T) {
+	if _, err := integrity.RunTx(context.Background(), nil, integrity.Options{Only: []string{"nope"}}); err == nil {
+		t.Fatal("RunTx with an unknown check succeeded")
+	}
+}
@@ -0,0 +1,416 @@
+// Package migrate применяет версионированные миграции схемы.
+//
+// Миграция — пара файлов NNNN_name.up.sql / NNNN_name.down.sql; версия —
+// число NNNN. Применённые версии хранятся в таблице schema_migrations,
+// одновременный запуск нескольких миграторов исключает advisory lock.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// lockKey — ключ pg_advisory_lock, общий для всех процессов проекта.
+const lockKey int64 = 2026_0001
+
+const createTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+)`
+
+// BaselineVersion — миграция, повторяющая схему бывшего init_db.sql.
+const BaselineVersion = 1
+
+var (
+	ErrNoDownScript = errors.New("migration has no down script")
+	// ErrNotInitialized — в базе нет schema_migrations: мигратор её ещё не
+	// запускал.
+	ErrNotInitialized = errors.New("schema_migrations does not exist")
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+var fileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load читает миграции из корня fsys и сортирует их по версии.
+// Файлы, не подходящие под шаблон имени, пропускаются.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileRe.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, err := strconv.Atoi(m[1])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", e.Name())
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(".", e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", e.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m)
+		}
+		result = append(result, *m)
+	}
+	slices.SortFunc(result, func(a, b Migration) int { return a.Version - b.Version })
+	return result, nil
+}
+
+// Status — состояние одной версии. Migration пуста у версий, которые
+// есть в schema_migrations, но отсутствуют среди файлов.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Missing   bool
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	// Logf вызывается перед каждым шагом; nil — без вывода.
+	Logf func(format string, args ...any)
+}
+
+func New(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up применяет все ещё не применённые миграции и ничего не откатывает,
+// даже если в базе есть версии новее известных файлов.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.adopt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		target := 0
+		for v := range applied {
+			target = max(target, v)
+		}
+		if len(m.migrations) > 0 {
+			target = max(target, m.migrations[len(m.migrations)-1].Version)
+		}
+		return m.migrate(ctx, conn, applied, target)
+	})
+}
+
+// Down откатывает steps последних применённых миграций и ничего не
+// применяет: неприменённые версии ниже откатываемых (пропуски после force
+// или добавленные задним числом файлы) остаются как есть.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	if steps < 0 {
+		return fmt.Errorf("invalid number of steps %d", steps)
+	}
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.rollback(ctx, conn, lastApplied(applied, steps))
+	})
+}
+
+// lastApplied возвращает steps старших применённых версий, начиная со
+// старшей.
+func lastApplied(applied map[int]time.Time, steps int) []int {
+	versions := slices.Sorted(maps.Keys(applied))
+	versions = versions[len(versions)-min(steps, len(versions)):]
+	slices.Reverse(versions)
+	return versions
+}
+
+// Goto приводит схему к версии target: применяет недостающие миграции
+// с версией <= target и откатывает применённые с версией > target.
+// target = 0 откатывает всё.
+func (m *Migrator) Goto(ctx context.Context, target int) error {
+	if target != 0 && !slices.ContainsFunc(m.migrations, func(mig Migration) bool {
+		return mig.Version == target
+	}) {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.adopt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, applied, target)
+	})
+}
+
+// Force записывает в schema_migrations, что применены ровно версии
+// <= version, не выполняя скриптов. Нужен, чтобы принять под управление
+// базу, схема которой создана вручную, или вернуть учёт в соответствие
+// со схемой после неудачной миграции. version = 0 очищает учёт.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(mig Migration) bool {
+		return mig.Version == version
+	}) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version > $1`, version); err != nil {
+			return fmt.Errorf("failed to update schema_migrations: %w", err)
+		}
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO schema_migrations (version, name) VALUES ($1, $2)
+				ON CONFLICT (version) DO NOTHING`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("failed to record %s: %w", mig, err)
+			}
+		}
+		m.logf("📌 schema version forced to %d", version)
+		return tx.Commit()
+	})
+}
+
+// adopt читает применённые версии. База, созданная ещё одноразовым
+// init_db.sql, уже содержит схему BaselineVersion, но не schema_migrations:
+// если учёт пуст, а таблица users есть, BaselineVersion записывается как
+// применённая, иначе Up упал бы на CREATE TABLE users.
+func (m *Migrator) adopt(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil || len(applied) > 0 {
+		return applied, err
+	}
+	i := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == BaselineVersion })
+	if i < 0 {
+		return applied, nil
+	}
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('users') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to inspect existing schema: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	baseline := m.migrations[i]
+	m.logf("📌 %s: existing schema found, recorded as applied", baseline)
+	if _, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`,
+		baseline.Version, baseline.Name); err != nil {
+		return nil, fmt.Errorf("failed to record %s: %w", baseline, err)
+	}
+	return appliedVersions(ctx, conn)
+}
+
+// Status возвращает состояние всех известных версий по возрастанию.
+// Только читает: без advisory lock и без создания schema_migrations,
+// поэтому работает под ролью только для чтения и не ждёт идущую миграцию.
+// Если schema_migrations нет, все версии возвращаются неприменёнными
+// вместе с ErrNotInitialized.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to inspect schema_migrations: %w", err)
+	}
+	applied := map[int]time.Time{}
+	if exists {
+		var err error
+		if applied, err = appliedVersions(ctx, m.db); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []Status
+	for _, mig := range m.migrations {
+		at, ok := applied[mig.Version]
+		result = append(result, Status{Migration: mig, Applied: ok, AppliedAt: at})
+		delete(applied, mig.Version)
+	}
+	for v, at := range applied {
+		result = append(result, Status{
+			Migration: Migration{Version: v},
+			Applied:   true,
+			AppliedAt: at,
+			Missing:   true,
+		})
+	}
+	slices.SortFunc(result, func(a, b Status) int { return a.Version - b.Version })
+	if !exists {
+		return result, ErrNotInitialized
+	}
+	return result, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, applied map[int]time.Time, target int) error {
+	// Сначала откат сверху вниз, затем применение снизу вверх.
+	above := 0
+	for v := range applied {
+		if v > target {
+			above++
+		}
+	}
+	if err := m.rollback(ctx, conn, lastApplied(applied, above)); err != nil {
+		return err
+	}
+
+	for _, mig := range m.migrations {
+		if mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		m.logf("⬆️  %s", mig)
+		if err := m.step(ctx, conn, mig.Up,
+			`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name); err != nil {
+			return fmt.Errorf("up %s: %w", mig, err)
+		}
+	}
+	return nil
+}
+
+// rollback откатывает versions в указанном порядке.
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, versions []int) error {
+	known := map[int]Migration{}
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+	for _, v := range versions {
+		mig, ok := known[v]
+		if !ok {
+			return fmt.Errorf("applied migration %d is missing from the migrations directory", v)
+		}
+		if mig.Down == "" {
+			return fmt.Errorf("%s: %w", mig, ErrNoDownScript)
+		}
+		m.logf("⬇️  %s", mig)
+		if err := m.step(ctx, conn, mig.Down,
+			`DELETE FROM schema_migrations WHERE version = $1`, mig.Version); err != nil {
+			return fmt.Errorf("down %s: %w", mig, err)
+		}
+	}
+	return nil
+}
+
+// step выполняет скрипт и обновление schema_migrations в одной транзакции.
+func (m *Migrator) step(ctx context.Context, conn *sql.Conn, script, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// locked выполняет fn на выделенном соединении под advisory lock:
+// блокировка сессионная, поэтому все шаги идут через одно соединение.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Отпускаем даже при отменённом ctx, иначе блокировка останется
+		// до закрытия соединения пулом.
+		_, unlockErr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+		if unlockErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release migration lock: %w", unlockErr))
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func appliedVersions(ctx context.Context, q querier) (map[int]time.Time, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]time.Time{}
+	for rows.Next() {
+		var v int
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
+		}
+		applied[v] = at
+	}
+	return applied, rows.Err()
+}
+
+func (m *Migrator) logf(format string, args ...any) {
+	if m.Logf != nil {
+		m.Logf(format, args...)
+	}
+}
@@ -0,0 +1,75 @@
+package migrate
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_waitlist.up.sql":   {Data: []byte("CREATE TABLE waitlist ();")},
+		"0002_waitlist.down.sql": {Data: []byte("DROP TABLE waitlist;")},
+		"0001_init_db.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"README.md":              {Data: []byte("not a migration")},
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 || migrations[0].String() != "0001_init_db" || migrations[1].String() != "0002_waitlist" {
+		t.Fatalf("Load = %v", migrations)
+	}
+	if migrations[0].Down != "" || migrations[1].Down == "" {
+		t.Fatalf("down scripts: %q, %q", migrations[0].Down, migrations[1].Down)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"no up script": {"0001_init_db.down.sql": {Data: []byte("DROP TABLE users;")}},
+		"two names": {
+			"0001_init_db.up.sql": {Data: []byte("CREATE TABLE users ();")},
+			"0001_other.down.sql": {Data: []byte("DROP TABLE users;")},
+		},
+		"zero version": {"0000_init_db.up.sql": {Data: []byte("CREATE TABLE users ();")}},
+	} {
+		if _, err := Load(fsys); err == nil {
+			t.Errorf("%s: Load succeeded", name)
+		}
+	}
+}
+
+// Проверки аргументов срабатывают до обращения к базе.
+func TestArgumentErrors(t *testing.T) {
+	m := New(nil, []Migration{{Version: 1, Name: "init_db", Up: "SELECT 1"}})
+	ctx := context.Background()
+	if err := m.Down(ctx, -1); err == nil {
+		t.Error("Down(-1) succeeded")
+	}
+	if err := m.Goto(ctx, 7); err == nil {
+		t.Error("Goto(7) succeeded")
+	}
+	if err := m.Force(ctx, 7); err == nil {
+		t.Error("Force(7) succeeded")
+	}
+}
+
+// Down откатывает только верхние применённые версии, даже если ниже
+// остались неприменённые (2 здесь — пропуск).
+func TestLastApplied(t *testing.T) {
+	applied := map[int]time.Time{1: {}, 3: {}, 4: {}}
+	for steps, want := range map[int][]int{
+		0: {},
+		1: {4},
+		2: {4, 3},
+		3: {4, 3, 1},
+		9: {4, 3, 1},
+	} {
+		if got := lastApplied(applied, steps); !slices.Equal(got, want) {
+			t.Errorf("lastApplied(%d) = %v, want %v", steps, got, want)
+		}
+	}
+}
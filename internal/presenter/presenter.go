@@ -0,0 +1,115 @@
+// Package presenter печатает результаты отчётов internal/service в
+// человекочитаемом виде.
+package presenter
+
+import (
+	"fmt"
+	"io"
+
+	"databases2026/internal/service"
+)
+
+type Text struct {
+	W io.Writer
+}
+
+func (t Text) Section(title string) {
+	fmt.Fprintf(t.W, "\n%s\n", title)
+}
+
+// --- Агрегирующие ---
+func (t Text) TotalRevenue(total float64) {
+	fmt.Fprintf(t.W, "Общий доход: $%.2f\n", total)
+}
+
+func (t Text) AvgClassRating(avg float64) {
+	fmt.Fprintf(t.W, "Средний рейтинг: %.2f\n", avg)
+}
+
+func (t Text) BookingsPerDay(rows []service.DayBookings) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "📅 %s: %d bookings\n", v.Day.Format("2006-01-02"), v.Bookings)
+	}
+}
+
+func (t Text) TopSportsByAttendance(rows []service.SportVisits) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "🏆 %s: %d visits\n", v.Sport, v.Visits)
+	}
+}
+
+// --- Оконные функции ---
+func (t Text) UserRankByLoyalty(rows []service.LoyaltyRank) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "🏅 User %d: %d pts (rank %d)\n", v.UserID, v.Points, v.Rank)
+	}
+}
+
+func (t Text) RunningTotalRevenue(rows []service.RunningRevenue) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "💰 Payment %d: $%.2f → Total: $%.2f\n", v.PaymentID, v.Amount, v.RunningTotal)
+	}
+}
+
+func (t Text) ClassBookingsWithMovingAvg(rows []service.ClassBookings) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "📚 Class %d: %d bookings (avg: %.2f)\n", v.ClassID, v.Bookings, v.MovingAvg)
+	}
+}
+
+func (t Text) CoachRatingWithRowNumber(rows []service.CoachRating) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "👨‍🏫 Coach %d: %.2f ★ (rank %d)\n", v.CoachID, v.AvgRating, v.RowNumber)
+	}
+}
+
+// --- JOIN-запросы ---
+func (t Text) UsersWithLoyalty(rows []service.UserLoyalty) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "📧 %s → %d pts\n", v.Email, v.Points)
+	}
+}
+
+func (t Text) ActiveMemberships(rows []service.ActiveMembership) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "🎫 %s: %d days since %s\n", v.Email, v.DurationDays, v.StartedAt.Format("2006-01-02"))
+	}
+}
+
+func (t Text) BookingsWithDetails(rows []service.BookingDetails) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "📅 %s booked %s at %s\n", v.Email, v.Sport, v.StartTime.Format("15:04"))
+	}
+}
+
+func (t Text) PaymentsWithMembership(rows []service.PaymentMembership) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "💳 %s paid $%.2f for %d-day plan\n", v.Email, v.Amount, v.DurationDays)
+	}
+}
+
+func (t Text) ReviewsWithCoachInfo(rows []service.CoachReview) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "⭐ %s rated %s: %d\n", v.Email, v.Coach, v.Rating)
+	}
+}
+
+func (t Text) ReferralRewards(rows []service.ReferralReward) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "🤝 %s referred %s (rewarded: %t)\n", v.Referrer, v.Referred, v.Rewarded)
+	}
+}
+
+func (t Text) ScheduleWithRoomAndSport(rows []service.ScheduleInfo) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "🏋️ %s at %s in room (cap %d) by coach %d\n",
+			v.Sport, v.StartTime.Format("15:04"), v.Capacity, v.CoachID)
+	}
+}
+
+func (t Text) FullBookingInfo(rows []service.FullBooking) {
+	for _, v := range rows {
+		fmt.Fprintf(t.W, "✅ %s booked %s (coach %d) in room (cap %d) at %s\n",
+			v.Email, v.Sport, v.CoachID, v.Capacity, v.StartTime.Format("15:04"))
+	}
+}
@@ -0,0 +1,341 @@
+package memory_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/internal/repository/memory"
+	"databases2026/internal/repository/postgres"
+	"databases2026/pkg/model"
+
+	_ "github.com/lib/pq"
+)
+
+// Сценарии выполняются на memory и, если TEST_DATABASE_URL указывает на
+// базу с применёнными миграциями, на PostgreSQL: так проверяется, что
+// in-memory хранилище ведёт себя как схема. Каждый сценарий идёт в своей
+// транзакции, которая в конце откатывается.
+
+var errRollback = errors.New("rollback")
+
+func forEachStore(t *testing.T, fn func(t *testing.T, tx repository.Store)) {
+	t.Helper()
+	run := func(t *testing.T, st repository.Store) {
+		err := st.InTx(context.Background(), func(tx repository.Store) error {
+			fn(t, tx)
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("InTx: %v", err)
+		}
+	}
+
+	t.Run("memory", func(t *testing.T) { run(t, memory.New()) })
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv("TEST_DATABASE_URL")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_URL is not set")
+		}
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		run(t, postgres.New(db))
+	})
+}
+
+// fixture создаёт связанные строки; имена уникальны, чтобы не задеть
+// данные, уже лежащие в тестовой базе.
+type fixture struct {
+	t   *testing.T
+	ctx context.Context
+	tx  repository.Store
+}
+
+var seq atomic.Int64
+
+func unique(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), seq.Add(1))
+}
+
+func noErr(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f fixture) user() model.User {
+	v, err := f.tx.Users().Create(f.ctx, model.User{Email: unique("user") + "@example.com"})
+	noErr(f.t, err)
+	return v
+}
+
+func (f fixture) coach() model.Coach {
+	v, err := f.tx.Coaches().Create(f.ctx, model.Coach{UserID: f.user().ID})
+	noErr(f.t, err)
+	return v
+}
+
+func (f fixture) class(coachID int) model.Class {
+	sport, err := f.tx.Sports().Create(f.ctx, model.Sport{Name: unique("sport")})
+	noErr(f.t, err)
+	v, err := f.tx.Classes().Create(f.ctx, model.Class{SportID: sport.ID, CoachID: coachID})
+	noErr(f.t, err)
+	return v
+}
+
+func (f fixture) schedule() model.Schedule {
+	room, err := f.tx.Rooms().Create(f.ctx, model.Room{Capacity: 10})
+	noErr(f.t, err)
+	start := time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC).Add(time.Duration(seq.Add(1)) * 24 * time.Hour)
+	v, err := f.tx.Schedules().Create(f.ctx, model.Schedule{
+		ClassID:   f.class(f.coach().UserID).ID,
+		RoomID:    room.ID,
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+	})
+	noErr(f.t, err)
+	return v
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestConstraintErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		op         func(f fixture) error
+		kind       error
+		constraint string
+	}{
+		{
+			name: "duplicate email",
+			op: func(f fixture) error {
+				u := f.user()
+				_, err := f.tx.Users().Create(f.ctx, model.User{Email: u.Email})
+				return err
+			},
+			kind: handler.ErrDuplicate, constraint: "users_email_key",
+		},
+		{
+			name: "duplicate booking",
+			op: func(f fixture) error {
+				u, s := f.user(), f.schedule()
+				_, err := f.tx.Bookings().Create(f.ctx, model.Booking{UserID: u.ID, ScheduleID: s.ID})
+				noErr(f.t, err)
+				_, err = f.tx.Bookings().Create(f.ctx, model.Booking{UserID: u.ID, ScheduleID: s.ID})
+				return err
+			},
+			kind: handler.ErrDuplicate, constraint: "bookings_user_id_schedule_id_key",
+		},
+		{
+			name: "missing sport",
+			op: func(f fixture) error {
+				_, err := f.tx.Classes().Create(f.ctx, model.Class{SportID: -1, CoachID: f.coach().UserID})
+				return err
+			},
+			kind: handler.ErrForeignKey, constraint: "classes_sport_id_fkey",
+		},
+		{
+			name: "booking of missing schedule",
+			op: func(f fixture) error {
+				_, err := f.tx.Bookings().Create(f.ctx, model.Booking{UserID: f.user().ID, ScheduleID: -1})
+				return err
+			},
+			kind: handler.ErrForeignKey, constraint: "bookings_schedule_id_fkey",
+		},
+		{
+			name: "delete coach restricted by class",
+			op: func(f fixture) error {
+				c := f.coach()
+				f.class(c.UserID)
+				return f.tx.Coaches().Delete(f.ctx, c.UserID)
+			},
+			kind: handler.ErrForeignKey, constraint: "classes_coach_id_fkey",
+		},
+		{
+			name: "zero room capacity",
+			op: func(f fixture) error {
+				_, err := f.tx.Rooms().Create(f.ctx, model.Room{Capacity: 0})
+				return err
+			},
+			kind: handler.ErrCheckViolation, constraint: "rooms_capacity_check",
+		},
+		{
+			name: "schedule ends before start",
+			op: func(f fixture) error {
+				s := f.schedule()
+				_, err := f.tx.Schedules().Update(f.ctx, s.ID, handler.SchedulePatch{EndTime: &s.StartTime})
+				return err
+			},
+			kind: handler.ErrCheckViolation, constraint: "schedules_check",
+		},
+		{
+			name: "unknown booking status",
+			op: func(f fixture) error {
+				_, err := f.tx.Bookings().Create(f.ctx, model.Booking{
+					UserID: f.user().ID, ScheduleID: f.schedule().ID, Status: ptr("lost"),
+				})
+				return err
+			},
+			kind: handler.ErrCheckViolation, constraint: "bookings_status_check",
+		},
+		{
+			name: "review rating out of range",
+			op: func(f fixture) error {
+				_, err := f.tx.Reviews().Create(f.ctx, model.Review{
+					UserID: f.user().ID, CoachID: ptr(f.coach().UserID), Rating: 6,
+				})
+				return err
+			},
+			kind: handler.ErrCheckViolation, constraint: "reviews_rating_check",
+		},
+		{
+			// ON DELETE SET NULL оставляет отзыв без класса и тренера.
+			name: "delete class of class-only review",
+			op: func(f fixture) error {
+				c := f.class(f.coach().UserID)
+				_, err := f.tx.Reviews().Create(f.ctx, model.Review{UserID: f.user().ID, ClassID: &c.ID, Rating: 5})
+				noErr(f.t, err)
+				return f.tx.Classes().Delete(f.ctx, c.ID)
+			},
+			kind: handler.ErrCheckViolation, constraint: "reviews_check",
+		},
+		{
+			name: "get missing user",
+			op: func(f fixture) error {
+				_, err := f.tx.Users().Get(f.ctx, -1)
+				return err
+			},
+			kind: handler.ErrNotFound,
+		},
+		{
+			name: "delete missing schedule",
+			op: func(f fixture) error {
+				return f.tx.Schedules().Delete(f.ctx, -1)
+			},
+			kind: handler.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, tx repository.Store) {
+				err := tt.op(fixture{t: t, ctx: context.Background(), tx: tx})
+				if !errors.Is(err, tt.kind) {
+					t.Fatalf("got %v, want %v", err, tt.kind)
+				}
+				if tt.constraint == "" {
+					return
+				}
+				var cerr *handler.ConstraintError
+				if !errors.As(err, &cerr) || cerr.Constraint != tt.constraint {
+					t.Fatalf("got %v, want constraint %s", err, tt.constraint)
+				}
+			})
+		})
+	}
+}
+
+func TestCascades(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(f fixture)
+	}{
+		{
+			name: "delete schedule removes bookings and waitlist",
+			check: func(f fixture) {
+				s, u := f.schedule(), f.user()
+				b, err := f.tx.Bookings().Create(f.ctx, model.Booking{UserID: u.ID, ScheduleID: s.ID})
+				noErr(f.t, err)
+				w, err := f.tx.Waitlist().Create(f.ctx, model.WaitlistEntry{UserID: f.user().ID, ScheduleID: s.ID})
+				noErr(f.t, err)
+				noErr(f.t, f.tx.Schedules().Delete(f.ctx, s.ID))
+				if _, err := f.tx.Bookings().Get(f.ctx, b.ID); !errors.Is(err, handler.ErrNotFound) {
+					f.t.Errorf("booking after schedule delete: %v", err)
+				}
+				if _, err := f.tx.Waitlist().Get(f.ctx, w.ID); !errors.Is(err, handler.ErrNotFound) {
+					f.t.Errorf("waitlist entry after schedule delete: %v", err)
+				}
+			},
+		},
+		{
+			name: "delete user removes bookings",
+			check: func(f fixture) {
+				u := f.user()
+				b, err := f.tx.Bookings().Create(f.ctx, model.Booking{UserID: u.ID, ScheduleID: f.schedule().ID})
+				noErr(f.t, err)
+				noErr(f.t, f.tx.Users().Delete(f.ctx, u.ID))
+				if _, err := f.tx.Bookings().Get(f.ctx, b.ID); !errors.Is(err, handler.ErrNotFound) {
+					f.t.Errorf("booking after user delete: %v", err)
+				}
+			},
+		},
+		{
+			name: "delete class keeps review of its coach",
+			check: func(f fixture) {
+				coach := f.coach()
+				c := f.class(coach.UserID)
+				r, err := f.tx.Reviews().Create(f.ctx, model.Review{
+					UserID: f.user().ID, CoachID: &coach.UserID, ClassID: &c.ID, Rating: 4,
+				})
+				noErr(f.t, err)
+				noErr(f.t, f.tx.Classes().Delete(f.ctx, c.ID))
+				got, err := f.tx.Reviews().Get(f.ctx, r.ID)
+				noErr(f.t, err)
+				if got.ClassID != nil || got.CoachID == nil || *got.CoachID != coach.UserID {
+					f.t.Errorf("review after class delete: class %v, coach %v", got.ClassID, got.CoachID)
+				}
+			},
+		},
+		{
+			name: "defaults are filled in",
+			check: func(f fixture) {
+				b, err := f.tx.Bookings().Create(f.ctx, model.Booking{UserID: f.user().ID, ScheduleID: f.schedule().ID})
+				noErr(f.t, err)
+				if b.Status == nil || *b.Status != model.BookingConfirmed {
+					f.t.Errorf("booking status = %v, want %s", b.Status, model.BookingConfirmed)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, tx repository.Store) {
+				tt.check(fixture{t: t, ctx: context.Background(), tx: tx})
+			})
+		})
+	}
+}
+
+func TestInTxRollback(t *testing.T) {
+	ctx := context.Background()
+	st := memory.New()
+	var id int
+	err := st.InTx(ctx, func(tx repository.Store) error {
+		u, err := tx.Users().Create(ctx, model.User{Email: "rollback@example.com"})
+		id = u.ID
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("InTx: %v", err)
+	}
+	if _, err := st.Users().Get(ctx, id); !errors.Is(err, handler.ErrNotFound) {
+		t.Fatalf("user after rollback: %v", err)
+	}
+}
@@ -0,0 +1,277 @@
+// Package memory — in-memory реализация repository.Store для тестов.
+//
+// Повторяет ограничения из configs/sql/migrations: UNIQUE, FOREIGN KEY
+// (включая ON DELETE CASCADE / RESTRICT / SET NULL), CHECK и значения
+// DEFAULT, и возвращает те же ошибки, что и handler.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+type state struct {
+	users           map[int]model.User
+	coaches         map[int]model.Coach
+	sports          map[int]model.Sport
+	classes         map[int]model.Class
+	rooms           map[int]model.Room
+	schedules       map[int]model.Schedule
+	bookings        map[int]model.Booking
+	memberships     map[int]model.Membership
+	userMemberships map[int]model.UserMembership
+	payments        map[int]model.Payment
+	attendanceLogs  map[int]model.AttendanceLog
+	reviews         map[int]model.Review
+	promotions      map[int]model.Promotion
+	promotionUsage  map[int]model.PromotionUsage
+	notifications   map[int]model.Notification
+	loyaltyPoints   map[int]model.LoyaltyPoints
+	referrals       map[int]model.Referral
+	auditLogs       map[int]model.AuditLog
+	systemSettings  map[string]model.SystemSetting
+	tempBookings    map[int]model.TempBooking
+	waitlist        map[int]model.WaitlistEntry
+}
+
+func newState() *state {
+	return &state{
+		users:           map[int]model.User{},
+		coaches:         map[int]model.Coach{},
+		sports:          map[int]model.Sport{},
+		classes:         map[int]model.Class{},
+		rooms:           map[int]model.Room{},
+		schedules:       map[int]model.Schedule{},
+		bookings:        map[int]model.Booking{},
+		memberships:     map[int]model.Membership{},
+		userMemberships: map[int]model.UserMembership{},
+		payments:        map[int]model.Payment{},
+		attendanceLogs:  map[int]model.AttendanceLog{},
+		reviews:         map[int]model.Review{},
+		promotions:      map[int]model.Promotion{},
+		promotionUsage:  map[int]model.PromotionUsage{},
+		notifications:   map[int]model.Notification{},
+		loyaltyPoints:   map[int]model.LoyaltyPoints{},
+		referrals:       map[int]model.Referral{},
+		auditLogs:       map[int]model.AuditLog{},
+		systemSettings:  map[string]model.SystemSetting{},
+		tempBookings:    map[int]model.TempBooking{},
+		waitlist:        map[int]model.WaitlistEntry{},
+	}
+}
+
+// clone копирует карты; строки — значения, указатели внутри них
+// никогда не изменяются на месте, поэтому поверхностной копии достаточно.
+func (st *state) clone() *state {
+	return &state{
+		users:           maps.Clone(st.users),
+		coaches:         maps.Clone(st.coaches),
+		sports:          maps.Clone(st.sports),
+		classes:         maps.Clone(st.classes),
+		rooms:           maps.Clone(st.rooms),
+		schedules:       maps.Clone(st.schedules),
+		bookings:        maps.Clone(st.bookings),
+		memberships:     maps.Clone(st.memberships),
+		userMemberships: maps.Clone(st.userMemberships),
+		payments:        maps.Clone(st.payments),
+		attendanceLogs:  maps.Clone(st.attendanceLogs),
+		reviews:         maps.Clone(st.reviews),
+		promotions:      maps.Clone(st.promotions),
+		promotionUsage:  maps.Clone(st.promotionUsage),
+		notifications:   maps.Clone(st.notifications),
+		loyaltyPoints:   maps.Clone(st.loyaltyPoints),
+		referrals:       maps.Clone(st.referrals),
+		auditLogs:       maps.Clone(st.auditLogs),
+		systemSettings:  maps.Clone(st.systemSettings),
+		tempBookings:    maps.Clone(st.tempBookings),
+		waitlist:        maps.Clone(st.waitlist),
+	}
+}
+
+// nextID — аналог SERIAL: значения не переиспользуются и не откатываются
+// вместе с транзакцией (как и sequence в PostgreSQL), поэтому счётчики
+// живут в root, а не в state.
+func (s *Store) nextID(table string) int {
+	s.root.seqMu.Lock()
+	defer s.root.seqMu.Unlock()
+	s.root.seq[table]++
+	return s.root.seq[table]
+}
+
+type root struct {
+	mu sync.Mutex
+	st *state
+
+	seqMu sync.Mutex
+	seq   map[string]int
+}
+
+// Store безопасен для конкурентного использования; транзакции
+// сериализуются целиком, что строже любого уровня изоляции PostgreSQL.
+type Store struct {
+	root *root
+	// tx — рабочая копия состояния внутри InTx; nil вне транзакции.
+	tx *state
+}
+
+var _ repository.Store = (*Store)(nil)
+
+func New() *Store {
+	return &Store{root: &root{st: newState(), seq: map[string]int{}}}
+}
+
+func (s *Store) InTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.root.mu.Lock()
+	defer s.root.mu.Unlock()
+
+	tx := &Store{root: s.root, tx: s.root.st.clone()}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	s.root.st = tx.tx
+	return nil
+}
+
+// read выполняет fn над текущим состоянием.
+func (s *Store) read(ctx context.Context, fn func(st *state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+
+	s.root.mu.Lock()
+	defer s.root.mu.Unlock()
+	return fn(s.root.st)
+}
+
+// write выполняет fn над копией состояния и применяет её только при
+// успехе — так каскадные операции атомарны, как отдельный оператор SQL.
+func (s *Store) write(ctx context.Context, fn func(st *state) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.tx != nil {
+		// Внутри транзакции частично выполненный оператор откатится
+		// вместе со всей транзакцией, если вызывающий вернёт ошибку.
+		st := s.tx.clone()
+		if err := fn(st); err != nil {
+			return err
+		}
+		*s.tx = *st
+		return nil
+	}
+
+	s.root.mu.Lock()
+	defer s.root.mu.Unlock()
+
+	st := s.root.st.clone()
+	if err := fn(st); err != nil {
+		return err
+	}
+	s.root.st = st
+	return nil
+}
+
+// --- ошибки в формате handler ---
+
+func constraintErr(kind error, table, constraint string, columns ...string) error {
+	return &handler.ConstraintError{
+		Kind:       kind,
+		Table:      table,
+		Constraint: constraint,
+		Columns:    columns,
+	}
+}
+
+func duplicate(table, constraint string, columns ...string) error {
+	return constraintErr(handler.ErrDuplicate, table, constraint, columns...)
+}
+
+func foreignKey(table, constraint string, columns ...string) error {
+	return constraintErr(handler.ErrForeignKey, table, constraint, columns...)
+}
+
+func checkViolation(table, constraint string, columns ...string) error {
+	return constraintErr(handler.ErrCheckViolation, table, constraint, columns...)
+}
+
+func exclusion(table, constraint string, columns ...string) error {
+	return constraintErr(handler.ErrExclusion, table, constraint, columns...)
+}
+
+func notFound(what string, key any) error {
+	return fmt.Errorf("%s %v: %w", what, key, handler.ErrNotFound)
+}
+
+// --- выборки ---
+
+// list фильтрует, сортирует по ключу и режет страницу, как List* в handler.
+func list[K cmp.Ordered, T any](
+	rows map[K]T,
+	page handler.Page,
+	match func(T) bool,
+	less func(a, b T) int,
+) []T {
+	keys := slices.Sorted(maps.Keys(rows))
+
+	var result []T
+	for _, k := range keys {
+		if match == nil || match(rows[k]) {
+			result = append(result, rows[k])
+		}
+	}
+	if less != nil {
+		slices.SortStableFunc(result, less)
+	}
+
+	limit, offset := page.Window()
+	if offset >= len(result) {
+		return nil
+	}
+	result = result[offset:]
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// ilike повторяет ILIKE с шаблонами % и _.
+func ilike(value, pattern string) bool {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String()).MatchString(value)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
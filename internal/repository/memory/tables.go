@@ -0,0 +1,1890 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+func (s *Store) Users() repository.Users                     { return users{s} }
+func (s *Store) Coaches() repository.Coaches                 { return coaches{s} }
+func (s *Store) Sports() repository.Sports                   { return sports{s} }
+func (s *Store) Classes() repository.Classes                 { return classes{s} }
+func (s *Store) Rooms() repository.Rooms                     { return rooms{s} }
+func (s *Store) Schedules() repository.Schedules             { return schedules{s} }
+func (s *Store) Bookings() repository.Bookings               { return bookings{s} }
+func (s *Store) Memberships() repository.Memberships         { return memberships{s} }
+func (s *Store) UserMemberships() repository.UserMemberships { return userMemberships{s} }
+func (s *Store) Payments() repository.Payments               { return payments{s} }
+func (s *Store) AttendanceLogs() repository.AttendanceLogs   { return attendanceLogs{s} }
+func (s *Store) Reviews() repository.Reviews                 { return reviews{s} }
+func (s *Store) Promotions() repository.Promotions           { return promotions{s} }
+func (s *Store) Notifications() repository.Notifications     { return notifications{s} }
+func (s *Store) LoyaltyPoints() repository.LoyaltyPoints     { return loyaltyPoints{s} }
+func (s *Store) Referrals() repository.Referrals             { return referrals{s} }
+func (s *Store) AuditLogs() repository.AuditLogs             { return auditLogs{s} }
+func (s *Store) SystemSettings() repository.SystemSettings   { return systemSettings{s} }
+func (s *Store) TempBookings() repository.TempBookings       { return tempBookings{s} }
+func (s *Store) Waitlist() repository.Waitlist               { return waitlist{s} }
+
+// --- каскады ON DELETE ---
+
+func (st *state) deleteUser(id int) error {
+	if _, ok := st.coaches[id]; ok {
+		if err := st.deleteCoach(id); err != nil {
+			return err
+		}
+	}
+	for k, v := range st.bookings {
+		if v.UserID == id {
+			delete(st.bookings, k)
+		}
+	}
+	for k, v := range st.userMemberships {
+		if v.UserID == id {
+			delete(st.userMemberships, k)
+		}
+	}
+	for k, v := range st.payments {
+		if v.UserID == id {
+			delete(st.payments, k)
+		}
+	}
+	for k, v := range st.attendanceLogs {
+		if v.UserID == id {
+			delete(st.attendanceLogs, k)
+		}
+	}
+	for k, v := range st.reviews {
+		if v.UserID == id {
+			delete(st.reviews, k)
+		}
+	}
+	for k, v := range st.promotionUsage {
+		if v.UserID == id {
+			delete(st.promotionUsage, k)
+		}
+	}
+	for k, v := range st.notifications {
+		if v.UserID == id {
+			delete(st.notifications, k)
+		}
+	}
+	delete(st.loyaltyPoints, id)
+	for k, v := range st.referrals {
+		if v.ReferrerID == id || v.ReferredID == id {
+			delete(st.referrals, k)
+		}
+	}
+	for k, v := range st.waitlist {
+		if v.UserID == id {
+			delete(st.waitlist, k)
+		}
+	}
+	delete(st.users, id)
+	return nil
+}
+
+func (st *state) deleteCoach(userID int) error {
+	for _, c := range st.classes {
+		if c.CoachID == userID {
+			return foreignKey("classes", "classes_coach_id_fkey", "coach_id")
+		}
+	}
+	for k, r := range st.reviews {
+		if r.CoachID != nil && *r.CoachID == userID {
+			r.CoachID = nil
+			if err := checkReview(r); err != nil {
+				return err
+			}
+			st.reviews[k] = r
+		}
+	}
+	delete(st.coaches, userID)
+	return nil
+}
+
+func (st *state) deleteClass(id int) error {
+	for k, r := range st.reviews {
+		if r.ClassID != nil && *r.ClassID == id {
+			r.ClassID = nil
+			if err := checkReview(r); err != nil {
+				return err
+			}
+			st.reviews[k] = r
+		}
+	}
+	for k, s := range st.schedules {
+		if s.ClassID == id {
+			st.deleteSchedule(k)
+		}
+	}
+	delete(st.classes, id)
+	return nil
+}
+
+func (st *state) deleteSchedule(id int) {
+	for k, b := range st.bookings {
+		if b.ScheduleID == id {
+			delete(st.bookings, k)
+		}
+	}
+	for k, w := range st.waitlist {
+		if w.ScheduleID == id {
+			delete(st.waitlist, k)
+		}
+	}
+	delete(st.schedules, id)
+}
+
+// --- 1. users ---
+type users struct{ s *Store }
+
+func (r users) Create(ctx context.Context, u model.User) (model.User, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		for _, other := range st.users {
+			if other.Email == u.Email {
+				return duplicate("users", "users_email_key", "email")
+			}
+		}
+		u.ID = r.s.nextID("users")
+		st.users[u.ID] = u
+		return nil
+	})
+	return u, err
+}
+
+func (r users) Get(ctx context.Context, id int) (model.User, error) {
+	var u model.User
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if u, ok = st.users[id]; !ok {
+			return notFound("user", id)
+		}
+		return nil
+	})
+	return u, err
+}
+
+func (r users) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	var u model.User
+	err := r.s.read(ctx, func(st *state) error {
+		for _, v := range st.users {
+			if v.Email == email {
+				u = v
+				return nil
+			}
+		}
+		return notFound("user", email)
+	})
+	return u, err
+}
+
+func (r users) List(ctx context.Context, f handler.UserFilter) ([]model.User, error) {
+	var result []model.User
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.users, f.Page, func(u model.User) bool {
+			return f.EmailLike == "" || ilike(u.Email, f.EmailLike)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r users) Update(ctx context.Context, id int, p handler.UserPatch) (model.User, error) {
+	var u model.User
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if u, ok = st.users[id]; !ok {
+			return notFound("user", id)
+		}
+		if p.Email != nil {
+			for _, other := range st.users {
+				if other.ID != id && other.Email == *p.Email {
+					return duplicate("users", "users_email_key", "email")
+				}
+			}
+			u.Email = *p.Email
+		}
+		st.users[id] = u
+		return nil
+	})
+	return u, err
+}
+
+func (r users) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[id]; !ok {
+			return notFound("user", id)
+		}
+		return st.deleteUser(id)
+	})
+}
+
+// --- 2. coaches ---
+type coaches struct{ s *Store }
+
+func (r coaches) Create(ctx context.Context, c model.Coach) (model.Coach, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.coaches[c.UserID]; ok {
+			return duplicate("coaches", "coaches_pkey", "user_id")
+		}
+		if _, ok := st.users[c.UserID]; !ok {
+			return foreignKey("coaches", "coaches_user_id_fkey", "user_id")
+		}
+		st.coaches[c.UserID] = c
+		return nil
+	})
+	return c, err
+}
+
+func (r coaches) Get(ctx context.Context, userID int) (model.Coach, error) {
+	var c model.Coach
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if c, ok = st.coaches[userID]; !ok {
+			return notFound("coach", userID)
+		}
+		return nil
+	})
+	return c, err
+}
+
+func (r coaches) List(ctx context.Context, page handler.Page) ([]model.Coach, error) {
+	var result []model.Coach
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.coaches, page, nil, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r coaches) Update(ctx context.Context, userID int, p handler.CoachPatch) (model.Coach, error) {
+	var c model.Coach
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if c, ok = st.coaches[userID]; !ok {
+			return notFound("coach", userID)
+		}
+		if p.UserID == nil || *p.UserID == userID {
+			return nil
+		}
+		if _, ok := st.coaches[*p.UserID]; ok {
+			return duplicate("coaches", "coaches_pkey", "user_id")
+		}
+		if _, ok := st.users[*p.UserID]; !ok {
+			return foreignKey("coaches", "coaches_user_id_fkey", "user_id")
+		}
+		// Ссылки из classes и reviews объявлены без ON UPDATE, т.е. NO ACTION.
+		for _, cl := range st.classes {
+			if cl.CoachID == userID {
+				return foreignKey("classes", "classes_coach_id_fkey", "coach_id")
+			}
+		}
+		for _, rv := range st.reviews {
+			if rv.CoachID != nil && *rv.CoachID == userID {
+				return foreignKey("reviews", "reviews_coach_id_fkey", "coach_id")
+			}
+		}
+		delete(st.coaches, userID)
+		c.UserID = *p.UserID
+		st.coaches[c.UserID] = c
+		return nil
+	})
+	return c, err
+}
+
+func (r coaches) Delete(ctx context.Context, userID int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.coaches[userID]; !ok {
+			return notFound("coach", userID)
+		}
+		return st.deleteCoach(userID)
+	})
+}
+
+// --- 3. sports ---
+type sports struct{ s *Store }
+
+func (st *state) checkSportName(id int, name string) error {
+	for _, other := range st.sports {
+		if other.ID != id && other.Name == name {
+			return duplicate("sports", "sports_name_key", "name")
+		}
+	}
+	return nil
+}
+
+func (r sports) Create(ctx context.Context, s model.Sport) (model.Sport, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkSportName(0, s.Name); err != nil {
+			return err
+		}
+		s.ID = r.s.nextID("sports")
+		st.sports[s.ID] = s
+		return nil
+	})
+	return s, err
+}
+
+func (r sports) Get(ctx context.Context, id int) (model.Sport, error) {
+	var s model.Sport
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if s, ok = st.sports[id]; !ok {
+			return notFound("sport", id)
+		}
+		return nil
+	})
+	return s, err
+}
+
+func (r sports) List(ctx context.Context, page handler.Page) ([]model.Sport, error) {
+	var result []model.Sport
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.sports, page, nil, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r sports) Update(ctx context.Context, id int, p handler.SportPatch) (model.Sport, error) {
+	var s model.Sport
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if s, ok = st.sports[id]; !ok {
+			return notFound("sport", id)
+		}
+		if p.Name != nil {
+			if err := st.checkSportName(id, *p.Name); err != nil {
+				return err
+			}
+			s.Name = *p.Name
+		}
+		st.sports[id] = s
+		return nil
+	})
+	return s, err
+}
+
+func (r sports) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.sports[id]; !ok {
+			return notFound("sport", id)
+		}
+		for _, c := range st.classes {
+			if c.SportID == id {
+				return foreignKey("classes", "classes_sport_id_fkey", "sport_id")
+			}
+		}
+		delete(st.sports, id)
+		return nil
+	})
+}
+
+// --- 4. classes ---
+type classes struct{ s *Store }
+
+func (st *state) checkClass(c model.Class) error {
+	if _, ok := st.sports[c.SportID]; !ok {
+		return foreignKey("classes", "classes_sport_id_fkey", "sport_id")
+	}
+	if _, ok := st.coaches[c.CoachID]; !ok {
+		return foreignKey("classes", "classes_coach_id_fkey", "coach_id")
+	}
+	return nil
+}
+
+func (r classes) Create(ctx context.Context, c model.Class) (model.Class, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkClass(c); err != nil {
+			return err
+		}
+		c.ID = r.s.nextID("classes")
+		st.classes[c.ID] = c
+		return nil
+	})
+	return c, err
+}
+
+func (r classes) Get(ctx context.Context, id int) (model.Class, error) {
+	var c model.Class
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if c, ok = st.classes[id]; !ok {
+			return notFound("class", id)
+		}
+		return nil
+	})
+	return c, err
+}
+
+func (r classes) List(ctx context.Context, f handler.ClassFilter) ([]model.Class, error) {
+	var result []model.Class
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.classes, f.Page, func(c model.Class) bool {
+			return (f.SportID == 0 || c.SportID == f.SportID) &&
+				(f.CoachID == 0 || c.CoachID == f.CoachID)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r classes) Update(ctx context.Context, id int, p handler.ClassPatch) (model.Class, error) {
+	var c model.Class
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if c, ok = st.classes[id]; !ok {
+			return notFound("class", id)
+		}
+		if p.SportID != nil {
+			c.SportID = *p.SportID
+		}
+		if p.CoachID != nil {
+			c.CoachID = *p.CoachID
+		}
+		if err := st.checkClass(c); err != nil {
+			return err
+		}
+		// Триггер classes_coach_overlap: занятия класса переходят к новому
+		// тренеру и не должны пересекаться с его занятиями.
+		for _, s := range st.schedules {
+			if s.ClassID != id {
+				continue
+			}
+			for _, o := range st.schedules {
+				if o.ClassID != id && st.classes[o.ClassID].CoachID == c.CoachID && overlaps(s, o) {
+					return exclusion("schedules", "schedules_coach_overlap")
+				}
+			}
+		}
+		st.classes[id] = c
+		return nil
+	})
+	return c, err
+}
+
+func (r classes) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.classes[id]; !ok {
+			return notFound("class", id)
+		}
+		return st.deleteClass(id)
+	})
+}
+
+// --- 5. rooms ---
+type rooms struct{ s *Store }
+
+func checkRoom(rm model.Room) error {
+	if rm.Capacity <= 0 {
+		return checkViolation("rooms", "rooms_capacity_check", "capacity")
+	}
+	return nil
+}
+
+func (r rooms) Create(ctx context.Context, rm model.Room) (model.Room, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := checkRoom(rm); err != nil {
+			return err
+		}
+		rm.ID = r.s.nextID("rooms")
+		st.rooms[rm.ID] = rm
+		return nil
+	})
+	return rm, err
+}
+
+func (r rooms) Get(ctx context.Context, id int) (model.Room, error) {
+	var rm model.Room
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if rm, ok = st.rooms[id]; !ok {
+			return notFound("room", id)
+		}
+		return nil
+	})
+	return rm, err
+}
+
+func (r rooms) List(ctx context.Context, f handler.RoomFilter) ([]model.Room, error) {
+	var result []model.Room
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.rooms, f.Page, func(rm model.Room) bool {
+			return f.MinCapacity <= 0 || rm.Capacity >= f.MinCapacity
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r rooms) Update(ctx context.Context, id int, p handler.RoomPatch) (model.Room, error) {
+	var rm model.Room
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if rm, ok = st.rooms[id]; !ok {
+			return notFound("room", id)
+		}
+		if p.Capacity != nil {
+			rm.Capacity = *p.Capacity
+		}
+		if err := checkRoom(rm); err != nil {
+			return err
+		}
+		st.rooms[id] = rm
+		return nil
+	})
+	return rm, err
+}
+
+func (r rooms) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.rooms[id]; !ok {
+			return notFound("room", id)
+		}
+		for _, s := range st.schedules {
+			if s.RoomID == id {
+				return foreignKey("schedules", "schedules_room_id_fkey", "room_id")
+			}
+		}
+		delete(st.rooms, id)
+		return nil
+	})
+}
+
+// --- 6. schedules ---
+type schedules struct{ s *Store }
+
+func (st *state) checkSchedule(s model.Schedule) error {
+	if _, ok := st.classes[s.ClassID]; !ok {
+		return foreignKey("schedules", "schedules_class_id_fkey", "class_id")
+	}
+	if _, ok := st.rooms[s.RoomID]; !ok {
+		return foreignKey("schedules", "schedules_room_id_fkey", "room_id")
+	}
+	if !s.EndTime.After(s.StartTime) {
+		return checkViolation("schedules", "schedules_check")
+	}
+
+	// Триггер schedules_coach_overlap срабатывает раньше, чем
+	// schedules_room_overlap, поэтому и здесь тренер проверяется первым.
+	coach := st.classes[s.ClassID].CoachID
+	roomClash := false
+	for _, o := range st.schedules {
+		if o.ID == s.ID || !overlaps(s, o) {
+			continue
+		}
+		if st.classes[o.ClassID].CoachID == coach {
+			return exclusion("schedules", "schedules_coach_overlap")
+		}
+		roomClash = roomClash || o.RoomID == s.RoomID
+	}
+	if roomClash {
+		return exclusion("schedules", "schedules_room_overlap")
+	}
+	return nil
+}
+
+// overlaps — пересечение полуоткрытых интервалов, как tsrange && tsrange.
+func overlaps(a, b model.Schedule) bool {
+	return a.StartTime.Before(b.EndTime) && b.StartTime.Before(a.EndTime)
+}
+
+func (r schedules) Create(ctx context.Context, s model.Schedule) (model.Schedule, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkSchedule(s); err != nil {
+			return err
+		}
+		s.ID = r.s.nextID("schedules")
+		st.schedules[s.ID] = s
+		return nil
+	})
+	return s, err
+}
+
+func (r schedules) Get(ctx context.Context, id int) (model.Schedule, error) {
+	var s model.Schedule
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if s, ok = st.schedules[id]; !ok {
+			return notFound("schedule", id)
+		}
+		return nil
+	})
+	return s, err
+}
+
+// Lock равносилен Get: транзакции in-memory хранилища и так выполняются
+// по одной.
+func (r schedules) Lock(ctx context.Context, id int) (model.Schedule, error) {
+	return r.Get(ctx, id)
+}
+
+func (r schedules) List(ctx context.Context, f handler.ScheduleFilter) ([]model.Schedule, error) {
+	var result []model.Schedule
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.schedules, f.Page, func(s model.Schedule) bool {
+			return (f.ClassID == 0 || s.ClassID == f.ClassID) &&
+				(f.RoomID == 0 || s.RoomID == f.RoomID) &&
+				(f.CoachID == 0 || st.classes[s.ClassID].CoachID == f.CoachID) &&
+				(f.From.IsZero() || s.EndTime.After(f.From)) &&
+				(f.To.IsZero() || s.StartTime.Before(f.To))
+		}, func(a, b model.Schedule) int {
+			return a.StartTime.Compare(b.StartTime)
+		})
+		return nil
+	})
+	return result, err
+}
+
+func (r schedules) Update(ctx context.Context, id int, p handler.SchedulePatch) (model.Schedule, error) {
+	var s model.Schedule
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if s, ok = st.schedules[id]; !ok {
+			return notFound("schedule", id)
+		}
+		if p.ClassID != nil {
+			s.ClassID = *p.ClassID
+		}
+		if p.RoomID != nil {
+			s.RoomID = *p.RoomID
+		}
+		if p.StartTime != nil {
+			s.StartTime = *p.StartTime
+		}
+		if p.EndTime != nil {
+			s.EndTime = *p.EndTime
+		}
+		if err := st.checkSchedule(s); err != nil {
+			return err
+		}
+		st.schedules[id] = s
+		return nil
+	})
+	return s, err
+}
+
+func (r schedules) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.schedules[id]; !ok {
+			return notFound("schedule", id)
+		}
+		st.deleteSchedule(id)
+		return nil
+	})
+}
+
+// --- 7. bookings ---
+type bookings struct{ s *Store }
+
+func (st *state) checkBooking(b model.Booking) error {
+	if _, ok := st.users[b.UserID]; !ok {
+		return foreignKey("bookings", "bookings_user_id_fkey", "user_id")
+	}
+	if _, ok := st.schedules[b.ScheduleID]; !ok {
+		return foreignKey("bookings", "bookings_schedule_id_fkey", "schedule_id")
+	}
+	if b.Status != nil && *b.Status != model.BookingConfirmed && *b.Status != model.BookingCancelled {
+		return checkViolation("bookings", "bookings_status_check", "status")
+	}
+	for _, other := range st.bookings {
+		if other.ID != b.ID && other.UserID == b.UserID && other.ScheduleID == b.ScheduleID {
+			return duplicate("bookings", "bookings_user_id_schedule_id_key", "user_id", "schedule_id")
+		}
+	}
+	return nil
+}
+
+func (r bookings) Create(ctx context.Context, b model.Booking) (model.Booking, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if b.Status == nil {
+			b.Status = ptr(model.BookingConfirmed)
+		}
+		if err := st.checkBooking(b); err != nil {
+			return err
+		}
+		b.ID = r.s.nextID("bookings")
+		st.bookings[b.ID] = b
+		return nil
+	})
+	return b, err
+}
+
+func (r bookings) Get(ctx context.Context, id int) (model.Booking, error) {
+	var b model.Booking
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if b, ok = st.bookings[id]; !ok {
+			return notFound("booking", id)
+		}
+		return nil
+	})
+	return b, err
+}
+
+func (r bookings) List(ctx context.Context, f handler.BookingFilter) ([]model.Booking, error) {
+	var result []model.Booking
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.bookings, f.Page, bookingMatch(f), nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r bookings) Count(ctx context.Context, f handler.BookingFilter) (int, error) {
+	n := 0
+	err := r.s.read(ctx, func(st *state) error {
+		match := bookingMatch(f)
+		for _, b := range st.bookings {
+			if match(b) {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func bookingMatch(f handler.BookingFilter) func(model.Booking) bool {
+	return func(b model.Booking) bool {
+		return (f.UserID == 0 || b.UserID == f.UserID) &&
+			(f.ScheduleID == 0 || b.ScheduleID == f.ScheduleID) &&
+			(f.Status == "" || (b.Status != nil && *b.Status == f.Status))
+	}
+}
+
+func (r bookings) Update(ctx context.Context, id int, p handler.BookingPatch) (model.Booking, error) {
+	var b model.Booking
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if b, ok = st.bookings[id]; !ok {
+			return notFound("booking", id)
+		}
+		if p.Status != nil {
+			b.Status = ptr(*p.Status)
+		}
+		if err := st.checkBooking(b); err != nil {
+			return err
+		}
+		st.bookings[id] = b
+		return nil
+	})
+	return b, err
+}
+
+func (r bookings) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.bookings[id]; !ok {
+			return notFound("booking", id)
+		}
+		delete(st.bookings, id)
+		return nil
+	})
+}
+
+// --- 8. memberships ---
+type memberships struct{ s *Store }
+
+func checkMembership(m model.Membership) error {
+	if m.DurationDays <= 0 {
+		return checkViolation("memberships", "memberships_duration_days_check", "duration_days")
+	}
+	if m.Price < 0 {
+		return checkViolation("memberships", "memberships_price_check", "price")
+	}
+	return nil
+}
+
+func (r memberships) Create(ctx context.Context, m model.Membership) (model.Membership, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := checkMembership(m); err != nil {
+			return err
+		}
+		m.ID = r.s.nextID("memberships")
+		st.memberships[m.ID] = m
+		return nil
+	})
+	return m, err
+}
+
+func (r memberships) Get(ctx context.Context, id int) (model.Membership, error) {
+	var m model.Membership
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if m, ok = st.memberships[id]; !ok {
+			return notFound("membership", id)
+		}
+		return nil
+	})
+	return m, err
+}
+
+func (r memberships) List(ctx context.Context, page handler.Page) ([]model.Membership, error) {
+	var result []model.Membership
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.memberships, page, nil, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r memberships) Update(ctx context.Context, id int, p handler.MembershipPatch) (model.Membership, error) {
+	var m model.Membership
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if m, ok = st.memberships[id]; !ok {
+			return notFound("membership", id)
+		}
+		if p.DurationDays != nil {
+			m.DurationDays = *p.DurationDays
+		}
+		if p.Price != nil {
+			m.Price = *p.Price
+		}
+		if err := checkMembership(m); err != nil {
+			return err
+		}
+		st.memberships[id] = m
+		return nil
+	})
+	return m, err
+}
+
+func (r memberships) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.memberships[id]; !ok {
+			return notFound("membership", id)
+		}
+		for k, um := range st.userMemberships {
+			if um.MembershipID == id {
+				delete(st.userMemberships, k)
+			}
+		}
+		delete(st.memberships, id)
+		return nil
+	})
+}
+
+// --- 9. user_memberships ---
+type userMemberships struct{ s *Store }
+
+func (st *state) checkUserMembership(um model.UserMembership) error {
+	if _, ok := st.users[um.UserID]; !ok {
+		return foreignKey("user_memberships", "user_memberships_user_id_fkey", "user_id")
+	}
+	if _, ok := st.memberships[um.MembershipID]; !ok {
+		return foreignKey("user_memberships", "user_memberships_membership_id_fkey", "membership_id")
+	}
+	return nil
+}
+
+// truncateDate повторяет столбец типа DATE.
+func truncateDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func (r userMemberships) Create(ctx context.Context, um model.UserMembership) (model.UserMembership, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkUserMembership(um); err != nil {
+			return err
+		}
+		if um.IsActive == nil {
+			um.IsActive = ptr(true)
+		}
+		um.StartedAt = truncateDate(um.StartedAt)
+		um.EndedAt = truncateDate(um.EndedAt)
+		um.ID = r.s.nextID("user_memberships")
+		st.userMemberships[um.ID] = um
+		return nil
+	})
+	return um, err
+}
+
+func (r userMemberships) Get(ctx context.Context, id int) (model.UserMembership, error) {
+	var um model.UserMembership
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if um, ok = st.userMemberships[id]; !ok {
+			return notFound("user membership", id)
+		}
+		return nil
+	})
+	return um, err
+}
+
+func (r userMemberships) List(
+	ctx context.Context,
+	f handler.UserMembershipFilter,
+) ([]model.UserMembership, error) {
+	var result []model.UserMembership
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.userMemberships, f.Page, func(um model.UserMembership) bool {
+			active := um.IsActive != nil && *um.IsActive
+			return (f.UserID == 0 || um.UserID == f.UserID) &&
+				(f.MembershipID == 0 || um.MembershipID == f.MembershipID) &&
+				(f.Active == nil || active == *f.Active)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r userMemberships) Update(
+	ctx context.Context,
+	id int,
+	p handler.UserMembershipPatch,
+) (model.UserMembership, error) {
+	var um model.UserMembership
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if um, ok = st.userMemberships[id]; !ok {
+			return notFound("user membership", id)
+		}
+		if p.MembershipID != nil {
+			um.MembershipID = *p.MembershipID
+		}
+		if p.StartedAt != nil {
+			um.StartedAt = truncateDate(*p.StartedAt)
+		}
+		if p.EndedAt != nil {
+			um.EndedAt = truncateDate(*p.EndedAt)
+		}
+		if p.IsActive != nil {
+			um.IsActive = ptr(*p.IsActive)
+		}
+		if err := st.checkUserMembership(um); err != nil {
+			return err
+		}
+		st.userMemberships[id] = um
+		return nil
+	})
+	return um, err
+}
+
+func (r userMemberships) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.userMemberships[id]; !ok {
+			return notFound("user membership", id)
+		}
+		delete(st.userMemberships, id)
+		return nil
+	})
+}
+
+// --- 10. payments ---
+type payments struct{ s *Store }
+
+func (st *state) checkPayment(p model.Payment) error {
+	if _, ok := st.users[p.UserID]; !ok {
+		return foreignKey("payments", "payments_user_id_fkey", "user_id")
+	}
+	if p.Amount < 0 {
+		return checkViolation("payments", "payments_amount_check", "amount")
+	}
+	if p.Status != nil && *p.Status != model.PaymentCompleted && *p.Status != model.PaymentFailed {
+		return checkViolation("payments", "payments_status_check", "status")
+	}
+	return nil
+}
+
+func (r payments) Create(ctx context.Context, p model.Payment) (model.Payment, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if p.Status == nil {
+			p.Status = ptr(model.PaymentCompleted)
+		}
+		if err := st.checkPayment(p); err != nil {
+			return err
+		}
+		p.ID = r.s.nextID("payments")
+		st.payments[p.ID] = p
+		return nil
+	})
+	return p, err
+}
+
+func (r payments) Get(ctx context.Context, id int) (model.Payment, error) {
+	var p model.Payment
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if p, ok = st.payments[id]; !ok {
+			return notFound("payment", id)
+		}
+		return nil
+	})
+	return p, err
+}
+
+func (r payments) List(ctx context.Context, f handler.PaymentFilter) ([]model.Payment, error) {
+	var result []model.Payment
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.payments, f.Page, func(p model.Payment) bool {
+			return (f.UserID == 0 || p.UserID == f.UserID) &&
+				(f.Status == "" || (p.Status != nil && *p.Status == f.Status))
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r payments) Update(ctx context.Context, id int, p handler.PaymentPatch) (model.Payment, error) {
+	var pm model.Payment
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if pm, ok = st.payments[id]; !ok {
+			return notFound("payment", id)
+		}
+		if p.Amount != nil {
+			pm.Amount = *p.Amount
+		}
+		if p.Status != nil {
+			pm.Status = ptr(*p.Status)
+		}
+		if err := st.checkPayment(pm); err != nil {
+			return err
+		}
+		st.payments[id] = pm
+		return nil
+	})
+	return pm, err
+}
+
+func (r payments) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.payments[id]; !ok {
+			return notFound("payment", id)
+		}
+		delete(st.payments, id)
+		return nil
+	})
+}
+
+// --- 11. attendance_logs ---
+type attendanceLogs struct{ s *Store }
+
+func (r attendanceLogs) Create(ctx context.Context, a model.AttendanceLog) (model.AttendanceLog, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[a.UserID]; !ok {
+			return foreignKey("attendance_logs", "attendance_logs_user_id_fkey", "user_id")
+		}
+		a.ID = r.s.nextID("attendance_logs")
+		st.attendanceLogs[a.ID] = a
+		return nil
+	})
+	return a, err
+}
+
+func (r attendanceLogs) Get(ctx context.Context, id int) (model.AttendanceLog, error) {
+	var a model.AttendanceLog
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if a, ok = st.attendanceLogs[id]; !ok {
+			return notFound("attendance log", id)
+		}
+		return nil
+	})
+	return a, err
+}
+
+func (r attendanceLogs) List(
+	ctx context.Context,
+	f handler.AttendanceLogFilter,
+) ([]model.AttendanceLog, error) {
+	var result []model.AttendanceLog
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.attendanceLogs, f.Page, func(a model.AttendanceLog) bool {
+			return (f.UserID == 0 || a.UserID == f.UserID) &&
+				(f.From.IsZero() || !a.StartTime.Before(f.From)) &&
+				(f.To.IsZero() || a.StartTime.Before(f.To))
+		}, func(a, b model.AttendanceLog) int {
+			return a.StartTime.Compare(b.StartTime)
+		})
+		return nil
+	})
+	return result, err
+}
+
+func (r attendanceLogs) Update(
+	ctx context.Context,
+	id int,
+	p handler.AttendanceLogPatch,
+) (model.AttendanceLog, error) {
+	var a model.AttendanceLog
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if a, ok = st.attendanceLogs[id]; !ok {
+			return notFound("attendance log", id)
+		}
+		if p.StartTime != nil {
+			a.StartTime = *p.StartTime
+		}
+		if p.EndTime != nil {
+			a.EndTime = *p.EndTime
+		}
+		st.attendanceLogs[id] = a
+		return nil
+	})
+	return a, err
+}
+
+func (r attendanceLogs) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.attendanceLogs[id]; !ok {
+			return notFound("attendance log", id)
+		}
+		delete(st.attendanceLogs, id)
+		return nil
+	})
+}
+
+// --- 12. reviews ---
+type reviews struct{ s *Store }
+
+func checkReview(r model.Review) error {
+	if r.Rating < 1 || r.Rating > 5 {
+		return checkViolation("reviews", "reviews_rating_check", "rating")
+	}
+	if r.CoachID == nil && r.ClassID == nil {
+		return checkViolation("reviews", "reviews_check")
+	}
+	return nil
+}
+
+func (st *state) checkReviewRefs(r model.Review) error {
+	if _, ok := st.users[r.UserID]; !ok {
+		return foreignKey("reviews", "reviews_user_id_fkey", "user_id")
+	}
+	if r.CoachID != nil {
+		if _, ok := st.coaches[*r.CoachID]; !ok {
+			return foreignKey("reviews", "reviews_coach_id_fkey", "coach_id")
+		}
+	}
+	if r.ClassID != nil {
+		if _, ok := st.classes[*r.ClassID]; !ok {
+			return foreignKey("reviews", "reviews_class_id_fkey", "class_id")
+		}
+	}
+	return checkReview(r)
+}
+
+func (r reviews) Create(ctx context.Context, rv model.Review) (model.Review, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkReviewRefs(rv); err != nil {
+			return err
+		}
+		rv.ID = r.s.nextID("reviews")
+		st.reviews[rv.ID] = rv
+		return nil
+	})
+	return rv, err
+}
+
+func (r reviews) Get(ctx context.Context, id int) (model.Review, error) {
+	var rv model.Review
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if rv, ok = st.reviews[id]; !ok {
+			return notFound("review", id)
+		}
+		return nil
+	})
+	return rv, err
+}
+
+func (r reviews) List(ctx context.Context, f handler.ReviewFilter) ([]model.Review, error) {
+	var result []model.Review
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.reviews, f.Page, func(rv model.Review) bool {
+			return (f.UserID == 0 || rv.UserID == f.UserID) &&
+				(f.CoachID == 0 || (rv.CoachID != nil && *rv.CoachID == f.CoachID)) &&
+				(f.ClassID == 0 || (rv.ClassID != nil && *rv.ClassID == f.ClassID))
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r reviews) Update(ctx context.Context, id int, p handler.ReviewPatch) (model.Review, error) {
+	var rv model.Review
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if rv, ok = st.reviews[id]; !ok {
+			return notFound("review", id)
+		}
+		if p.CoachID.Set {
+			rv.CoachID = p.CoachID.Value
+		}
+		if p.ClassID.Set {
+			rv.ClassID = p.ClassID.Value
+		}
+		if p.Rating != nil {
+			rv.Rating = *p.Rating
+		}
+		if err := st.checkReviewRefs(rv); err != nil {
+			return err
+		}
+		st.reviews[id] = rv
+		return nil
+	})
+	return rv, err
+}
+
+func (r reviews) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.reviews[id]; !ok {
+			return notFound("review", id)
+		}
+		delete(st.reviews, id)
+		return nil
+	})
+}
+
+// --- 13-14. promotions, promotion_usage ---
+type promotions struct{ s *Store }
+
+func (st *state) checkPromotion(p model.Promotion) error {
+	for _, other := range st.promotions {
+		if other.ID != p.ID && other.Code == p.Code {
+			return duplicate("promotions", "promotions_code_key", "code")
+		}
+	}
+	if p.DiscountPercent < 1 || p.DiscountPercent > 100 {
+		return checkViolation("promotions", "promotions_discount_percent_check", "discount_percent")
+	}
+	return nil
+}
+
+func (r promotions) Create(ctx context.Context, p model.Promotion) (model.Promotion, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if err := st.checkPromotion(p); err != nil {
+			return err
+		}
+		if p.UsedCount == nil {
+			p.UsedCount = ptr(0)
+		}
+		p.ValidFrom = truncateDate(p.ValidFrom)
+		p.ValidUntil = truncateDate(p.ValidUntil)
+		p.ID = r.s.nextID("promotions")
+		st.promotions[p.ID] = p
+		return nil
+	})
+	return p, err
+}
+
+func (r promotions) Get(ctx context.Context, id int) (model.Promotion, error) {
+	var p model.Promotion
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if p, ok = st.promotions[id]; !ok {
+			return notFound("promotion", id)
+		}
+		return nil
+	})
+	return p, err
+}
+
+func (r promotions) GetByCode(ctx context.Context, code string) (model.Promotion, error) {
+	var p model.Promotion
+	err := r.s.read(ctx, func(st *state) error {
+		for _, v := range st.promotions {
+			if v.Code == code {
+				p = v
+				return nil
+			}
+		}
+		return notFound("promotion", code)
+	})
+	return p, err
+}
+
+func (r promotions) List(ctx context.Context, f handler.PromotionFilter) ([]model.Promotion, error) {
+	var result []model.Promotion
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.promotions, f.Page, func(p model.Promotion) bool {
+			return f.ValidAt.IsZero() ||
+				(!p.ValidFrom.After(f.ValidAt) && !p.ValidUntil.Before(truncateDate(f.ValidAt)))
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r promotions) Update(ctx context.Context, id int, p handler.PromotionPatch) (model.Promotion, error) {
+	var pr model.Promotion
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if pr, ok = st.promotions[id]; !ok {
+			return notFound("promotion", id)
+		}
+		if p.Code != nil {
+			pr.Code = *p.Code
+		}
+		if p.DiscountPercent != nil {
+			pr.DiscountPercent = *p.DiscountPercent
+		}
+		if p.ValidFrom != nil {
+			pr.ValidFrom = truncateDate(*p.ValidFrom)
+		}
+		if p.ValidUntil != nil {
+			pr.ValidUntil = truncateDate(*p.ValidUntil)
+		}
+		if p.MaxUses.Set {
+			pr.MaxUses = p.MaxUses.Value
+		}
+		if p.UsedCount != nil {
+			pr.UsedCount = ptr(*p.UsedCount)
+		}
+		if err := st.checkPromotion(pr); err != nil {
+			return err
+		}
+		st.promotions[id] = pr
+		return nil
+	})
+	return pr, err
+}
+
+func (r promotions) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.promotions[id]; !ok {
+			return notFound("promotion", id)
+		}
+		for k, pu := range st.promotionUsage {
+			if pu.PromotionID == id {
+				delete(st.promotionUsage, k)
+			}
+		}
+		delete(st.promotions, id)
+		return nil
+	})
+}
+
+func (r promotions) Use(ctx context.Context, pu model.PromotionUsage) (model.PromotionUsage, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[pu.UserID]; !ok {
+			return foreignKey("promotion_usage", "promotion_usage_user_id_fkey", "user_id")
+		}
+		if _, ok := st.promotions[pu.PromotionID]; !ok {
+			return foreignKey("promotion_usage", "promotion_usage_promotion_id_fkey", "promotion_id")
+		}
+		pu.ID = r.s.nextID("promotion_usage")
+		st.promotionUsage[pu.ID] = pu
+		return nil
+	})
+	return pu, err
+}
+
+func (r promotions) ListUsages(
+	ctx context.Context,
+	f handler.PromotionUsageFilter,
+) ([]model.PromotionUsage, error) {
+	var result []model.PromotionUsage
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.promotionUsage, f.Page, func(pu model.PromotionUsage) bool {
+			return (f.UserID == 0 || pu.UserID == f.UserID) &&
+				(f.PromotionID == 0 || pu.PromotionID == f.PromotionID)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r promotions) DeleteUsage(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.promotionUsage[id]; !ok {
+			return notFound("promotion usage", id)
+		}
+		delete(st.promotionUsage, id)
+		return nil
+	})
+}
+
+// --- 15. notifications ---
+type notifications struct{ s *Store }
+
+func (r notifications) Create(ctx context.Context, n model.Notification) (model.Notification, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[n.UserID]; !ok {
+			return foreignKey("notifications", "notifications_user_id_fkey", "user_id")
+		}
+		if n.IsRead == nil {
+			n.IsRead = ptr(false)
+		}
+		n.ID = r.s.nextID("notifications")
+		st.notifications[n.ID] = n
+		return nil
+	})
+	return n, err
+}
+
+func (r notifications) Get(ctx context.Context, id int) (model.Notification, error) {
+	var n model.Notification
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if n, ok = st.notifications[id]; !ok {
+			return notFound("notification", id)
+		}
+		return nil
+	})
+	return n, err
+}
+
+func (r notifications) List(
+	ctx context.Context,
+	f handler.NotificationFilter,
+) ([]model.Notification, error) {
+	var result []model.Notification
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.notifications, f.Page, func(n model.Notification) bool {
+			read := n.IsRead != nil && *n.IsRead
+			return (f.UserID == 0 || n.UserID == f.UserID) && (!f.UnreadOnly || !read)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r notifications) Update(
+	ctx context.Context,
+	id int,
+	p handler.NotificationPatch,
+) (model.Notification, error) {
+	var n model.Notification
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if n, ok = st.notifications[id]; !ok {
+			return notFound("notification", id)
+		}
+		if p.IsRead != nil {
+			n.IsRead = ptr(*p.IsRead)
+		}
+		st.notifications[id] = n
+		return nil
+	})
+	return n, err
+}
+
+func (r notifications) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.notifications[id]; !ok {
+			return notFound("notification", id)
+		}
+		delete(st.notifications, id)
+		return nil
+	})
+}
+
+// --- 16. loyalty_points ---
+type loyaltyPoints struct{ s *Store }
+
+func (r loyaltyPoints) Set(ctx context.Context, lp model.LoyaltyPoints) (model.LoyaltyPoints, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[lp.UserID]; !ok {
+			return foreignKey("loyalty_points", "loyalty_points_user_id_fkey", "user_id")
+		}
+		if lp.Points == nil {
+			if existing, ok := st.loyaltyPoints[lp.UserID]; ok {
+				lp = existing
+				return nil
+			}
+			lp.Points = ptr(0)
+		}
+		if *lp.Points < 0 {
+			return checkViolation("loyalty_points", "loyalty_points_points_check", "points")
+		}
+		lp.Points = ptr(*lp.Points)
+		st.loyaltyPoints[lp.UserID] = lp
+		return nil
+	})
+	return lp, err
+}
+
+func (r loyaltyPoints) Get(ctx context.Context, userID int) (model.LoyaltyPoints, error) {
+	var lp model.LoyaltyPoints
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if lp, ok = st.loyaltyPoints[userID]; !ok {
+			return notFound("loyalty points of user", userID)
+		}
+		return nil
+	})
+	return lp, err
+}
+
+func (r loyaltyPoints) List(ctx context.Context, page handler.Page) ([]model.LoyaltyPoints, error) {
+	var result []model.LoyaltyPoints
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.loyaltyPoints, page, nil, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r loyaltyPoints) Delete(ctx context.Context, userID int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.loyaltyPoints[userID]; !ok {
+			return notFound("loyalty points of user", userID)
+		}
+		delete(st.loyaltyPoints, userID)
+		return nil
+	})
+}
+
+// --- 17. referrals ---
+type referrals struct{ s *Store }
+
+func (r referrals) Create(ctx context.Context, rf model.Referral) (model.Referral, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[rf.ReferrerID]; !ok {
+			return foreignKey("referrals", "referrals_referrer_id_fkey", "referrer_id")
+		}
+		if _, ok := st.users[rf.ReferredID]; !ok {
+			return foreignKey("referrals", "referrals_referred_id_fkey", "referred_id")
+		}
+		if rf.ReferrerID == rf.ReferredID {
+			return checkViolation("referrals", "referrals_check")
+		}
+		if rf.Rewarded == nil {
+			rf.Rewarded = ptr(false)
+		}
+		rf.ID = r.s.nextID("referrals")
+		st.referrals[rf.ID] = rf
+		return nil
+	})
+	return rf, err
+}
+
+func (r referrals) Get(ctx context.Context, id int) (model.Referral, error) {
+	var rf model.Referral
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if rf, ok = st.referrals[id]; !ok {
+			return notFound("referral", id)
+		}
+		return nil
+	})
+	return rf, err
+}
+
+func (r referrals) List(ctx context.Context, f handler.ReferralFilter) ([]model.Referral, error) {
+	var result []model.Referral
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.referrals, f.Page, func(rf model.Referral) bool {
+			return (f.ReferrerID == 0 || rf.ReferrerID == f.ReferrerID) &&
+				(f.ReferredID == 0 || rf.ReferredID == f.ReferredID)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r referrals) Update(ctx context.Context, id int, p handler.ReferralPatch) (model.Referral, error) {
+	var rf model.Referral
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if rf, ok = st.referrals[id]; !ok {
+			return notFound("referral", id)
+		}
+		if p.Rewarded != nil {
+			rf.Rewarded = ptr(*p.Rewarded)
+		}
+		st.referrals[id] = rf
+		return nil
+	})
+	return rf, err
+}
+
+func (r referrals) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.referrals[id]; !ok {
+			return notFound("referral", id)
+		}
+		delete(st.referrals, id)
+		return nil
+	})
+}
+
+// --- 18. audit_logs ---
+type auditLogs struct{ s *Store }
+
+func (r auditLogs) Log(ctx context.Context, a model.AuditLog) (model.AuditLog, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if a.PerformedAt == nil {
+			a.PerformedAt = ptr(time.Now())
+		}
+		a.ID = r.s.nextID("audit_logs")
+		st.auditLogs[a.ID] = a
+		return nil
+	})
+	return a, err
+}
+
+func (r auditLogs) Get(ctx context.Context, id int) (model.AuditLog, error) {
+	var a model.AuditLog
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if a, ok = st.auditLogs[id]; !ok {
+			return notFound("audit log", id)
+		}
+		return nil
+	})
+	return a, err
+}
+
+func (r auditLogs) List(ctx context.Context, f handler.AuditLogFilter) ([]model.AuditLog, error) {
+	var result []model.AuditLog
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.auditLogs, f.Page, func(a model.AuditLog) bool {
+			at := time.Time{}
+			if a.PerformedAt != nil {
+				at = *a.PerformedAt
+			}
+			return (f.UserID == 0 || (a.UserID != nil && *a.UserID == f.UserID)) &&
+				(f.Action == "" || a.Action == f.Action) &&
+				(f.EntityType == "" || (a.EntityType != nil && *a.EntityType == f.EntityType)) &&
+				(f.EntityID == 0 || (a.EntityID != nil && *a.EntityID == f.EntityID)) &&
+				(f.From.IsZero() || (a.PerformedAt != nil && !at.Before(f.From))) &&
+				(f.To.IsZero() || (a.PerformedAt != nil && at.Before(f.To)))
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r auditLogs) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.auditLogs[id]; !ok {
+			return notFound("audit log", id)
+		}
+		delete(st.auditLogs, id)
+		return nil
+	})
+}
+
+// --- 19. system_settings ---
+type systemSettings struct{ s *Store }
+
+func (r systemSettings) Set(ctx context.Context, s model.SystemSetting) (model.SystemSetting, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		st.systemSettings[s.Key] = s
+		return nil
+	})
+	return s, err
+}
+
+func (r systemSettings) Get(ctx context.Context, key string) (model.SystemSetting, error) {
+	var s model.SystemSetting
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if s, ok = st.systemSettings[key]; !ok {
+			return notFound("system setting", key)
+		}
+		return nil
+	})
+	return s, err
+}
+
+func (r systemSettings) List(ctx context.Context, page handler.Page) ([]model.SystemSetting, error) {
+	var result []model.SystemSetting
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.systemSettings, page, nil, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r systemSettings) Delete(ctx context.Context, key string) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.systemSettings[key]; !ok {
+			return notFound("system setting", key)
+		}
+		delete(st.systemSettings, key)
+		return nil
+	})
+}
+
+// --- 20. temp_bookings ---
+type tempBookings struct{ s *Store }
+
+func (r tempBookings) Create(ctx context.Context, tb model.TempBooking) (model.TempBooking, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		for _, other := range st.tempBookings {
+			if other.Token == tb.Token {
+				return duplicate("temp_bookings", "temp_bookings_token_key", "token")
+			}
+		}
+		tb.ID = r.s.nextID("temp_bookings")
+		st.tempBookings[tb.ID] = tb
+		return nil
+	})
+	return tb, err
+}
+
+func (r tempBookings) Get(ctx context.Context, id int) (model.TempBooking, error) {
+	var tb model.TempBooking
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if tb, ok = st.tempBookings[id]; !ok {
+			return notFound("temp booking", id)
+		}
+		return nil
+	})
+	return tb, err
+}
+
+func (r tempBookings) GetByToken(ctx context.Context, token string) (model.TempBooking, error) {
+	var tb model.TempBooking
+	err := r.s.read(ctx, func(st *state) error {
+		for _, v := range st.tempBookings {
+			if v.Token == token {
+				tb = v
+				return nil
+			}
+		}
+		return notFound("temp booking", token)
+	})
+	return tb, err
+}
+
+func (r tempBookings) List(
+	ctx context.Context,
+	f handler.TempBookingFilter,
+) ([]model.TempBooking, error) {
+	var result []model.TempBooking
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.tempBookings, f.Page, tempBookingMatch(f), nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r tempBookings) Count(ctx context.Context, f handler.TempBookingFilter) (int, error) {
+	n := 0
+	err := r.s.read(ctx, func(st *state) error {
+		match := tempBookingMatch(f)
+		for _, tb := range st.tempBookings {
+			if match(tb) {
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+func tempBookingMatch(f handler.TempBookingFilter) func(model.TempBooking) bool {
+	return func(tb model.TempBooking) bool {
+		return (f.UserID == 0 || tb.UserID == f.UserID) &&
+			(f.ScheduleID == 0 || tb.ScheduleID == f.ScheduleID) &&
+			(f.ExpiredBefore.IsZero() || !tb.ExpiresAt.After(f.ExpiredBefore)) &&
+			(f.ActiveAt.IsZero() || tb.ExpiresAt.After(f.ActiveAt))
+	}
+}
+
+func (r tempBookings) Update(
+	ctx context.Context,
+	id int,
+	p handler.TempBookingPatch,
+) (model.TempBooking, error) {
+	var tb model.TempBooking
+	err := r.s.write(ctx, func(st *state) error {
+		var ok bool
+		if tb, ok = st.tempBookings[id]; !ok {
+			return notFound("temp booking", id)
+		}
+		if p.ExpiresAt != nil {
+			tb.ExpiresAt = *p.ExpiresAt
+		}
+		st.tempBookings[id] = tb
+		return nil
+	})
+	return tb, err
+}
+
+func (r tempBookings) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.tempBookings[id]; !ok {
+			return notFound("temp booking", id)
+		}
+		delete(st.tempBookings, id)
+		return nil
+	})
+}
+
+func (r tempBookings) DeleteExpired(ctx context.Context, scheduleID int, before time.Time) (int, error) {
+	n := 0
+	err := r.s.write(ctx, func(st *state) error {
+		for k, tb := range st.tempBookings {
+			if !tb.ExpiresAt.After(before) && (scheduleID == 0 || tb.ScheduleID == scheduleID) {
+				delete(st.tempBookings, k)
+				n++
+			}
+		}
+		return nil
+	})
+	return n, err
+}
+
+// --- 21. waitlist ---
+type waitlist struct{ s *Store }
+
+func (r waitlist) Create(ctx context.Context, w model.WaitlistEntry) (model.WaitlistEntry, error) {
+	err := r.s.write(ctx, func(st *state) error {
+		if _, ok := st.users[w.UserID]; !ok {
+			return foreignKey("waitlist", "waitlist_user_id_fkey", "user_id")
+		}
+		if _, ok := st.schedules[w.ScheduleID]; !ok {
+			return foreignKey("waitlist", "waitlist_schedule_id_fkey", "schedule_id")
+		}
+		for _, other := range st.waitlist {
+			if other.UserID == w.UserID && other.ScheduleID == w.ScheduleID {
+				return duplicate("waitlist", "waitlist_user_id_schedule_id_key", "user_id", "schedule_id")
+			}
+		}
+		if w.CreatedAt == nil {
+			w.CreatedAt = ptr(time.Now())
+		}
+		w.ID = r.s.nextID("waitlist")
+		st.waitlist[w.ID] = w
+		return nil
+	})
+	return w, err
+}
+
+func (r waitlist) Get(ctx context.Context, id int) (model.WaitlistEntry, error) {
+	var w model.WaitlistEntry
+	err := r.s.read(ctx, func(st *state) error {
+		var ok bool
+		if w, ok = st.waitlist[id]; !ok {
+			return notFound("waitlist entry", id)
+		}
+		return nil
+	})
+	return w, err
+}
+
+func (r waitlist) List(ctx context.Context, f handler.WaitlistFilter) ([]model.WaitlistEntry, error) {
+	var result []model.WaitlistEntry
+	err := r.s.read(ctx, func(st *state) error {
+		result = list(st.waitlist, f.Page, func(w model.WaitlistEntry) bool {
+			return (f.UserID == 0 || w.UserID == f.UserID) &&
+				(f.ScheduleID == 0 || w.ScheduleID == f.ScheduleID)
+		}, nil)
+		return nil
+	})
+	return result, err
+}
+
+func (r waitlist) Delete(ctx context.Context, id int) error {
+	return r.s.write(ctx, func(st *state) error {
+		if _, ok := st.waitlist[id]; !ok {
+			return notFound("waitlist entry", id)
+		}
+		delete(st.waitlist, id)
+		return nil
+	})
+}
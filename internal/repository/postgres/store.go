@@ -0,0 +1,548 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+// Store — реализация repository.Store поверх функций пакета handler.
+type Store struct {
+	db *sql.DB
+	q  handler.Executor
+}
+
+var _ repository.Store = (*Store)(nil)
+
+func New(db *sql.DB) *Store {
+	return &Store{db: db, q: db}
+}
+
+// NewTx — Store внутри уже открытой транзакции tx: InTx выполняет fn в ней
+// же, а фиксирует и откатывает её вызывающий.
+func NewTx(tx *sql.Tx) *Store {
+	return &Store{q: tx}
+}
+
+// InTx запускает fn через handler.WithTx, поэтому конфликты сериализации
+// повторяются автоматически. Вложенный InTx переиспользует текущую транзакцию.
+func (s *Store) InTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if _, ok := s.q.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	return handler.WithTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return fn(&Store{db: s.db, q: tx})
+	})
+}
+func (s *Store) Users() repository.Users                     { return users{s.q} }
+func (s *Store) Coaches() repository.Coaches                 { return coaches{s.q} }
+func (s *Store) Sports() repository.Sports                   { return sports{s.q} }
+func (s *Store) Classes() repository.Classes                 { return classes{s.q} }
+func (s *Store) Rooms() repository.Rooms                     { return rooms{s.q} }
+func (s *Store) Schedules() repository.Schedules             { return schedules{s.q} }
+func (s *Store) Bookings() repository.Bookings               { return bookings{s.q} }
+func (s *Store) Memberships() repository.Memberships         { return memberships{s.q} }
+func (s *Store) UserMemberships() repository.UserMemberships { return userMemberships{s.q} }
+func (s *Store) Payments() repository.Payments               { return payments{s.q} }
+func (s *Store) AttendanceLogs() repository.AttendanceLogs   { return attendanceLogs{s.q} }
+func (s *Store) Reviews() repository.Reviews                 { return reviews{s.q} }
+func (s *Store) Promotions() repository.Promotions           { return promotions{s.q} }
+func (s *Store) Notifications() repository.Notifications     { return notifications{s.q} }
+func (s *Store) LoyaltyPoints() repository.LoyaltyPoints     { return loyaltyPoints{s.q} }
+func (s *Store) Referrals() repository.Referrals             { return referrals{s.q} }
+func (s *Store) AuditLogs() repository.AuditLogs             { return auditLogs{s.q} }
+func (s *Store) SystemSettings() repository.SystemSettings   { return systemSettings{s.q} }
+func (s *Store) TempBookings() repository.TempBookings       { return tempBookings{s.q} }
+func (s *Store) Waitlist() repository.Waitlist               { return waitlist{s.q} }
+
+// --- Users ---
+type users struct{ q handler.Executor }
+
+func (r users) Create(ctx context.Context, v model.User) (model.User, error) {
+	return handler.CreateUserContext(ctx, r.q, v)
+}
+
+func (r users) Get(ctx context.Context, id int) (model.User, error) {
+	return handler.GetUserByID(ctx, r.q, id)
+}
+
+func (r users) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	return handler.GetUserByEmail(ctx, r.q, email)
+}
+
+func (r users) List(ctx context.Context, f handler.UserFilter) ([]model.User, error) {
+	return handler.ListUsers(ctx, r.q, f)
+}
+
+func (r users) Update(ctx context.Context, id int, p handler.UserPatch) (model.User, error) {
+	return handler.UpdateUser(ctx, r.q, id, p)
+}
+
+func (r users) Delete(ctx context.Context, id int) error {
+	return handler.DeleteUserContext(ctx, r.q, id)
+}
+
+// --- Coaches ---
+type coaches struct{ q handler.Executor }
+
+func (r coaches) Create(ctx context.Context, v model.Coach) (model.Coach, error) {
+	return handler.CreateCoachContext(ctx, r.q, v)
+}
+
+func (r coaches) Get(ctx context.Context, userID int) (model.Coach, error) {
+	return handler.GetCoachByID(ctx, r.q, userID)
+}
+
+func (r coaches) List(ctx context.Context, page handler.Page) ([]model.Coach, error) {
+	return handler.ListCoaches(ctx, r.q, page)
+}
+
+func (r coaches) Update(ctx context.Context, userID int, p handler.CoachPatch) (model.Coach, error) {
+	return handler.UpdateCoach(ctx, r.q, userID, p)
+}
+
+func (r coaches) Delete(ctx context.Context, userID int) error {
+	return handler.DeleteCoachContext(ctx, r.q, userID)
+}
+
+// --- Sports ---
+type sports struct{ q handler.Executor }
+
+func (r sports) Create(ctx context.Context, v model.Sport) (model.Sport, error) {
+	return handler.CreateSportContext(ctx, r.q, v)
+}
+
+func (r sports) Get(ctx context.Context, id int) (model.Sport, error) {
+	return handler.GetSportByID(ctx, r.q, id)
+}
+
+func (r sports) List(ctx context.Context, page handler.Page) ([]model.Sport, error) {
+	return handler.ListSports(ctx, r.q, page)
+}
+
+func (r sports) Update(ctx context.Context, id int, p handler.SportPatch) (model.Sport, error) {
+	return handler.UpdateSport(ctx, r.q, id, p)
+}
+
+func (r sports) Delete(ctx context.Context, id int) error {
+	return handler.DeleteSportContext(ctx, r.q, id)
+}
+
+// --- Classes ---
+type classes struct{ q handler.Executor }
+
+func (r classes) Create(ctx context.Context, v model.Class) (model.Class, error) {
+	return handler.CreateClassContext(ctx, r.q, v)
+}
+
+func (r classes) Get(ctx context.Context, id int) (model.Class, error) {
+	return handler.GetClassByID(ctx, r.q, id)
+}
+
+func (r classes) List(ctx context.Context, f handler.ClassFilter) ([]model.Class, error) {
+	return handler.ListClasses(ctx, r.q, f)
+}
+
+func (r classes) Update(ctx context.Context, id int, p handler.ClassPatch) (model.Class, error) {
+	return handler.UpdateClass(ctx, r.q, id, p)
+}
+
+func (r classes) Delete(ctx context.Context, id int) error {
+	return handler.DeleteClassContext(ctx, r.q, id)
+}
+
+// --- Rooms ---
+type rooms struct{ q handler.Executor }
+
+func (r rooms) Create(ctx context.Context, v model.Room) (model.Room, error) {
+	return handler.CreateRoomContext(ctx, r.q, v)
+}
+
+func (r rooms) Get(ctx context.Context, id int) (model.Room, error) {
+	return handler.GetRoomByID(ctx, r.q, id)
+}
+
+func (r rooms) List(ctx context.Context, f handler.RoomFilter) ([]model.Room, error) {
+	return handler.ListRooms(ctx, r.q, f)
+}
+
+func (r rooms) Update(ctx context.Context, id int, p handler.RoomPatch) (model.Room, error) {
+	return handler.UpdateRoom(ctx, r.q, id, p)
+}
+
+func (r rooms) Delete(ctx context.Context, id int) error {
+	return handler.DeleteRoomContext(ctx, r.q, id)
+}
+
+// --- Schedules ---
+type schedules struct{ q handler.Executor }
+
+func (r schedules) Create(ctx context.Context, v model.Schedule) (model.Schedule, error) {
+	return handler.CreateScheduleContext(ctx, r.q, v)
+}
+
+func (r schedules) Get(ctx context.Context, id int) (model.Schedule, error) {
+	return handler.GetScheduleByID(ctx, r.q, id)
+}
+
+func (r schedules) Lock(ctx context.Context, id int) (model.Schedule, error) {
+	return handler.LockSchedule(ctx, r.q, id)
+}
+
+func (r schedules) List(ctx context.Context, f handler.ScheduleFilter) ([]model.Schedule, error) {
+	return handler.ListSchedules(ctx, r.q, f)
+}
+
+func (r schedules) Update(ctx context.Context, id int, p handler.SchedulePatch) (model.Schedule, error) {
+	return handler.UpdateSchedule(ctx, r.q, id, p)
+}
+
+func (r schedules) Delete(ctx context.Context, id int) error {
+	return handler.DeleteScheduleContext(ctx, r.q, id)
+}
+
+// --- Bookings ---
+type bookings struct{ q handler.Executor }
+
+func (r bookings) Create(ctx context.Context, v model.Booking) (model.Booking, error) {
+	return handler.CreateBookingContext(ctx, r.q, v)
+}
+
+func (r bookings) Get(ctx context.Context, id int) (model.Booking, error) {
+	return handler.GetBookingByID(ctx, r.q, id)
+}
+
+func (r bookings) List(ctx context.Context, f handler.BookingFilter) ([]model.Booking, error) {
+	return handler.ListBookings(ctx, r.q, f)
+}
+
+func (r bookings) Count(ctx context.Context, f handler.BookingFilter) (int, error) {
+	return handler.CountBookings(ctx, r.q, f)
+}
+
+func (r bookings) Update(ctx context.Context, id int, p handler.BookingPatch) (model.Booking, error) {
+	return handler.UpdateBooking(ctx, r.q, id, p)
+}
+
+func (r bookings) Delete(ctx context.Context, id int) error {
+	return handler.DeleteBookingContext(ctx, r.q, id)
+}
+
+// --- Memberships ---
+type memberships struct{ q handler.Executor }
+
+func (r memberships) Create(ctx context.Context, v model.Membership) (model.Membership, error) {
+	return handler.CreateMembershipContext(ctx, r.q, v)
+}
+
+func (r memberships) Get(ctx context.Context, id int) (model.Membership, error) {
+	return handler.GetMembershipByID(ctx, r.q, id)
+}
+
+func (r memberships) List(ctx context.Context, page handler.Page) ([]model.Membership, error) {
+	return handler.ListMemberships(ctx, r.q, page)
+}
+
+func (r memberships) Update(ctx context.Context, id int, p handler.MembershipPatch) (model.Membership, error) {
+	return handler.UpdateMembership(ctx, r.q, id, p)
+}
+
+func (r memberships) Delete(ctx context.Context, id int) error {
+	return handler.DeleteMembershipContext(ctx, r.q, id)
+}
+
+// --- UserMemberships ---
+type userMemberships struct{ q handler.Executor }
+
+func (r userMemberships) Create(ctx context.Context, v model.UserMembership) (model.UserMembership, error) {
+	return handler.CreateUserMembershipContext(ctx, r.q, v)
+}
+
+func (r userMemberships) Get(ctx context.Context, id int) (model.UserMembership, error) {
+	return handler.GetUserMembershipByID(ctx, r.q, id)
+}
+
+func (r userMemberships) List(ctx context.Context, f handler.UserMembershipFilter) ([]model.UserMembership, error) {
+	return handler.ListUserMemberships(ctx, r.q, f)
+}
+
+func (r userMemberships) Update(ctx context.Context, id int, p handler.UserMembershipPatch) (model.UserMembership, error) {
+	return handler.UpdateUserMembership(ctx, r.q, id, p)
+}
+
+func (r userMemberships) Delete(ctx context.Context, id int) error {
+	return handler.DeleteUserMembershipContext(ctx, r.q, id)
+}
+
+// --- Payments ---
+type payments struct{ q handler.Executor }
+
+func (r payments) Create(ctx context.Context, v model.Payment) (model.Payment, error) {
+	return handler.CreatePaymentContext(ctx, r.q, v)
+}
+
+func (r payments) Get(ctx context.Context, id int) (model.Payment, error) {
+	return handler.GetPaymentByID(ctx, r.q, id)
+}
+
+func (r payments) List(ctx context.Context, f handler.PaymentFilter) ([]model.Payment, error) {
+	return handler.ListPayments(ctx, r.q, f)
+}
+
+func (r payments) Update(ctx context.Context, id int, p handler.PaymentPatch) (model.Payment, error) {
+	return handler.UpdatePayment(ctx, r.q, id, p)
+}
+
+func (r payments) Delete(ctx context.Context, id int) error {
+	return handler.DeletePaymentContext(ctx, r.q, id)
+}
+
+// --- AttendanceLogs ---
+type attendanceLogs struct{ q handler.Executor }
+
+func (r attendanceLogs) Create(ctx context.Context, v model.AttendanceLog) (model.AttendanceLog, error) {
+	return handler.CreateAttendanceLogContext(ctx, r.q, v)
+}
+
+func (r attendanceLogs) Get(ctx context.Context, id int) (model.AttendanceLog, error) {
+	return handler.GetAttendanceLogByID(ctx, r.q, id)
+}
+
+func (r attendanceLogs) List(ctx context.Context, f handler.AttendanceLogFilter) ([]model.AttendanceLog, error) {
+	return handler.ListAttendanceLogs(ctx, r.q, f)
+}
+
+func (r attendanceLogs) Update(ctx context.Context, id int, p handler.AttendanceLogPatch) (model.AttendanceLog, error) {
+	return handler.UpdateAttendanceLog(ctx, r.q, id, p)
+}
+
+func (r attendanceLogs) Delete(ctx context.Context, id int) error {
+	return handler.DeleteAttendanceLogContext(ctx, r.q, id)
+}
+
+// --- Reviews ---
+type reviews struct{ q handler.Executor }
+
+func (r reviews) Create(ctx context.Context, v model.Review) (model.Review, error) {
+	return handler.CreateReviewContext(ctx, r.q, v)
+}
+
+func (r reviews) Get(ctx context.Context, id int) (model.Review, error) {
+	return handler.GetReviewByID(ctx, r.q, id)
+}
+
+func (r reviews) List(ctx context.Context, f handler.ReviewFilter) ([]model.Review, error) {
+	return handler.ListReviews(ctx, r.q, f)
+}
+
+func (r reviews) Update(ctx context.Context, id int, p handler.ReviewPatch) (model.Review, error) {
+	return handler.UpdateReview(ctx, r.q, id, p)
+}
+
+func (r reviews) Delete(ctx context.Context, id int) error {
+	return handler.DeleteReviewContext(ctx, r.q, id)
+}
+
+// --- Promotions ---
+type promotions struct{ q handler.Executor }
+
+func (r promotions) Create(ctx context.Context, v model.Promotion) (model.Promotion, error) {
+	return handler.CreatePromotionContext(ctx, r.q, v)
+}
+
+func (r promotions) Get(ctx context.Context, id int) (model.Promotion, error) {
+	return handler.GetPromotionByID(ctx, r.q, id)
+}
+
+func (r promotions) GetByCode(ctx context.Context, code string) (model.Promotion, error) {
+	return handler.GetPromotionByCode(ctx, r.q, code)
+}
+
+func (r promotions) List(ctx context.Context, f handler.PromotionFilter) ([]model.Promotion, error) {
+	return handler.ListPromotions(ctx, r.q, f)
+}
+
+func (r promotions) Update(ctx context.Context, id int, p handler.PromotionPatch) (model.Promotion, error) {
+	return handler.UpdatePromotion(ctx, r.q, id, p)
+}
+
+func (r promotions) Delete(ctx context.Context, id int) error {
+	return handler.DeletePromotionContext(ctx, r.q, id)
+}
+
+func (r promotions) Use(ctx context.Context, pu model.PromotionUsage) (model.PromotionUsage, error) {
+	return handler.UsePromotionContext(ctx, r.q, pu)
+}
+
+func (r promotions) ListUsages(
+	ctx context.Context,
+	f handler.PromotionUsageFilter,
+) ([]model.PromotionUsage, error) {
+	return handler.ListPromotionUsages(ctx, r.q, f)
+}
+
+func (r promotions) DeleteUsage(ctx context.Context, id int) error {
+	return handler.DeletePromotionUsageContext(ctx, r.q, id)
+}
+
+// --- Notifications ---
+type notifications struct{ q handler.Executor }
+
+func (r notifications) Create(ctx context.Context, v model.Notification) (model.Notification, error) {
+	return handler.CreateNotificationContext(ctx, r.q, v)
+}
+
+func (r notifications) Get(ctx context.Context, id int) (model.Notification, error) {
+	return handler.GetNotificationByID(ctx, r.q, id)
+}
+
+func (r notifications) List(ctx context.Context, f handler.NotificationFilter) ([]model.Notification, error) {
+	return handler.ListNotifications(ctx, r.q, f)
+}
+
+func (r notifications) Update(ctx context.Context, id int, p handler.NotificationPatch) (model.Notification, error) {
+	return handler.UpdateNotification(ctx, r.q, id, p)
+}
+
+func (r notifications) Delete(ctx context.Context, id int) error {
+	return handler.DeleteNotificationContext(ctx, r.q, id)
+}
+
+// --- LoyaltyPoints ---
+type loyaltyPoints struct{ q handler.Executor }
+
+func (r loyaltyPoints) Set(ctx context.Context, v model.LoyaltyPoints) (model.LoyaltyPoints, error) {
+	return handler.SetLoyaltyPointsContext(ctx, r.q, v)
+}
+
+func (r loyaltyPoints) Get(ctx context.Context, userID int) (model.LoyaltyPoints, error) {
+	return handler.GetLoyaltyPointsByUserID(ctx, r.q, userID)
+}
+
+func (r loyaltyPoints) List(ctx context.Context, page handler.Page) ([]model.LoyaltyPoints, error) {
+	return handler.ListLoyaltyPoints(ctx, r.q, page)
+}
+
+func (r loyaltyPoints) Delete(ctx context.Context, userID int) error {
+	return handler.DeleteLoyaltyPointsContext(ctx, r.q, userID)
+}
+
+// --- Referrals ---
+type referrals struct{ q handler.Executor }
+
+func (r referrals) Create(ctx context.Context, v model.Referral) (model.Referral, error) {
+	return handler.CreateReferralContext(ctx, r.q, v)
+}
+
+func (r referrals) Get(ctx context.Context, id int) (model.Referral, error) {
+	return handler.GetReferralByID(ctx, r.q, id)
+}
+
+func (r referrals) List(ctx context.Context, f handler.ReferralFilter) ([]model.Referral, error) {
+	return handler.ListReferrals(ctx, r.q, f)
+}
+
+func (r referrals) Update(ctx context.Context, id int, p handler.ReferralPatch) (model.Referral, error) {
+	return handler.UpdateReferral(ctx, r.q, id, p)
+}
+
+func (r referrals) Delete(ctx context.Context, id int) error {
+	return handler.DeleteReferralContext(ctx, r.q, id)
+}
+
+// --- AuditLogs ---
+type auditLogs struct{ q handler.Executor }
+
+func (r auditLogs) Log(ctx context.Context, v model.AuditLog) (model.AuditLog, error) {
+	return handler.LogAuditContext(ctx, r.q, v)
+}
+
+func (r auditLogs) Get(ctx context.Context, id int) (model.AuditLog, error) {
+	return handler.GetAuditLogByID(ctx, r.q, id)
+}
+
+func (r auditLogs) List(ctx context.Context, f handler.AuditLogFilter) ([]model.AuditLog, error) {
+	return handler.ListAuditLogs(ctx, r.q, f)
+}
+
+func (r auditLogs) Delete(ctx context.Context, id int) error {
+	return handler.DeleteAuditLogContext(ctx, r.q, id)
+}
+
+// --- SystemSettings ---
+type systemSettings struct{ q handler.Executor }
+
+func (r systemSettings) Set(ctx context.Context, v model.SystemSetting) (model.SystemSetting, error) {
+	return handler.SetSystemSettingContext(ctx, r.q, v)
+}
+
+func (r systemSettings) Get(ctx context.Context, key string) (model.SystemSetting, error) {
+	return handler.GetSystemSettingByKey(ctx, r.q, key)
+}
+
+func (r systemSettings) List(ctx context.Context, page handler.Page) ([]model.SystemSetting, error) {
+	return handler.ListSystemSettings(ctx, r.q, page)
+}
+
+func (r systemSettings) Delete(ctx context.Context, key string) error {
+	return handler.DeleteSystemSettingContext(ctx, r.q, key)
+}
+
+// --- TempBookings ---
+type tempBookings struct{ q handler.Executor }
+
+func (r tempBookings) Create(ctx context.Context, v model.TempBooking) (model.TempBooking, error) {
+	return handler.CreateTempBookingContext(ctx, r.q, v)
+}
+
+func (r tempBookings) Get(ctx context.Context, id int) (model.TempBooking, error) {
+	return handler.GetTempBookingByID(ctx, r.q, id)
+}
+
+func (r tempBookings) GetByToken(ctx context.Context, token string) (model.TempBooking, error) {
+	return handler.GetTempBookingByToken(ctx, r.q, token)
+}
+
+func (r tempBookings) List(ctx context.Context, f handler.TempBookingFilter) ([]model.TempBooking, error) {
+	return handler.ListTempBookings(ctx, r.q, f)
+}
+
+func (r tempBookings) Count(ctx context.Context, f handler.TempBookingFilter) (int, error) {
+	return handler.CountTempBookings(ctx, r.q, f)
+}
+
+func (r tempBookings) Update(ctx context.Context, id int, p handler.TempBookingPatch) (model.TempBooking, error) {
+	return handler.UpdateTempBooking(ctx, r.q, id, p)
+}
+
+func (r tempBookings) Delete(ctx context.Context, id int) error {
+	return handler.DeleteTempBookingContext(ctx, r.q, id)
+}
+
+func (r tempBookings) DeleteExpired(ctx context.Context, scheduleID int, before time.Time) (int, error) {
+	return handler.DeleteExpiredTempBookings(ctx, r.q, scheduleID, before)
+}
+
+// --- Waitlist ---
+type waitlist struct{ q handler.Executor }
+
+func (r waitlist) Create(ctx context.Context, v model.WaitlistEntry) (model.WaitlistEntry, error) {
+	return handler.CreateWaitlistEntryContext(ctx, r.q, v)
+}
+
+func (r waitlist) Get(ctx context.Context, id int) (model.WaitlistEntry, error) {
+	return handler.GetWaitlistEntryByID(ctx, r.q, id)
+}
+
+func (r waitlist) List(ctx context.Context, f handler.WaitlistFilter) ([]model.WaitlistEntry, error) {
+	return handler.ListWaitlist(ctx, r.q, f)
+}
+
+func (r waitlist) Delete(ctx context.Context, id int) error {
+	return handler.DeleteWaitlistEntryContext(ctx, r.q, id)
+}
@@ -0,0 +1,219 @@
+// Package repository описывает доступ к данным через интерфейсы, чтобы
+// бизнес-код не зависел от *sql.DB. Реализации: postgres (поверх handler)
+// и memory (для тестов без живой базы, с теми же ограничениями схемы).
+//
+// Ошибки — из пакета handler: ErrNotFound, ErrDuplicate, ErrForeignKey,
+// ErrCheckViolation, ErrExclusion; фильтры и патчи — тоже handler.*Filter / handler.*Patch.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/pkg/model"
+)
+
+// Store — точка входа ко всем репозиториям.
+type Store interface {
+	Users() Users
+	Coaches() Coaches
+	Sports() Sports
+	Classes() Classes
+	Rooms() Rooms
+	Schedules() Schedules
+	Bookings() Bookings
+	Memberships() Memberships
+	UserMemberships() UserMemberships
+	Payments() Payments
+	AttendanceLogs() AttendanceLogs
+	Reviews() Reviews
+	Promotions() Promotions
+	Notifications() Notifications
+	LoyaltyPoints() LoyaltyPoints
+	Referrals() Referrals
+	AuditLogs() AuditLogs
+	SystemSettings() SystemSettings
+	TempBookings() TempBookings
+	Waitlist() Waitlist
+
+	// InTx выполняет fn атомарно: все изменения через tx применяются
+	// целиком или не применяются вовсе.
+	InTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+type Users interface {
+	Create(ctx context.Context, u model.User) (model.User, error)
+	Get(ctx context.Context, id int) (model.User, error)
+	GetByEmail(ctx context.Context, email string) (model.User, error)
+	List(ctx context.Context, f handler.UserFilter) ([]model.User, error)
+	Update(ctx context.Context, id int, p handler.UserPatch) (model.User, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Coaches interface {
+	Create(ctx context.Context, c model.Coach) (model.Coach, error)
+	Get(ctx context.Context, userID int) (model.Coach, error)
+	List(ctx context.Context, page handler.Page) ([]model.Coach, error)
+	Update(ctx context.Context, userID int, p handler.CoachPatch) (model.Coach, error)
+	Delete(ctx context.Context, userID int) error
+}
+
+type Sports interface {
+	Create(ctx context.Context, s model.Sport) (model.Sport, error)
+	Get(ctx context.Context, id int) (model.Sport, error)
+	List(ctx context.Context, page handler.Page) ([]model.Sport, error)
+	Update(ctx context.Context, id int, p handler.SportPatch) (model.Sport, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Classes interface {
+	Create(ctx context.Context, c model.Class) (model.Class, error)
+	Get(ctx context.Context, id int) (model.Class, error)
+	List(ctx context.Context, f handler.ClassFilter) ([]model.Class, error)
+	Update(ctx context.Context, id int, p handler.ClassPatch) (model.Class, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Rooms interface {
+	Create(ctx context.Context, r model.Room) (model.Room, error)
+	Get(ctx context.Context, id int) (model.Room, error)
+	List(ctx context.Context, f handler.RoomFilter) ([]model.Room, error)
+	Update(ctx context.Context, id int, p handler.RoomPatch) (model.Room, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Schedules interface {
+	Create(ctx context.Context, s model.Schedule) (model.Schedule, error)
+	Get(ctx context.Context, id int) (model.Schedule, error)
+	// Lock — Get с блокировкой занятия до конца InTx; конкурентные
+	// транзакции, блокирующие то же занятие, ждут её завершения.
+	Lock(ctx context.Context, id int) (model.Schedule, error)
+	List(ctx context.Context, f handler.ScheduleFilter) ([]model.Schedule, error)
+	Update(ctx context.Context, id int, p handler.SchedulePatch) (model.Schedule, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Bookings interface {
+	Create(ctx context.Context, b model.Booking) (model.Booking, error)
+	Get(ctx context.Context, id int) (model.Booking, error)
+	List(ctx context.Context, f handler.BookingFilter) ([]model.Booking, error)
+	// Count считает брони по фильтру без учёта страницы.
+	Count(ctx context.Context, f handler.BookingFilter) (int, error)
+	Update(ctx context.Context, id int, p handler.BookingPatch) (model.Booking, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Memberships interface {
+	Create(ctx context.Context, m model.Membership) (model.Membership, error)
+	Get(ctx context.Context, id int) (model.Membership, error)
+	List(ctx context.Context, page handler.Page) ([]model.Membership, error)
+	Update(ctx context.Context, id int, p handler.MembershipPatch) (model.Membership, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type UserMemberships interface {
+	Create(ctx context.Context, um model.UserMembership) (model.UserMembership, error)
+	Get(ctx context.Context, id int) (model.UserMembership, error)
+	List(ctx context.Context, f handler.UserMembershipFilter) ([]model.UserMembership, error)
+	Update(ctx context.Context, id int, p handler.UserMembershipPatch) (model.UserMembership, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Payments interface {
+	Create(ctx context.Context, p model.Payment) (model.Payment, error)
+	Get(ctx context.Context, id int) (model.Payment, error)
+	List(ctx context.Context, f handler.PaymentFilter) ([]model.Payment, error)
+	Update(ctx context.Context, id int, p handler.PaymentPatch) (model.Payment, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type AttendanceLogs interface {
+	Create(ctx context.Context, a model.AttendanceLog) (model.AttendanceLog, error)
+	Get(ctx context.Context, id int) (model.AttendanceLog, error)
+	List(ctx context.Context, f handler.AttendanceLogFilter) ([]model.AttendanceLog, error)
+	Update(ctx context.Context, id int, p handler.AttendanceLogPatch) (model.AttendanceLog, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type Reviews interface {
+	Create(ctx context.Context, r model.Review) (model.Review, error)
+	Get(ctx context.Context, id int) (model.Review, error)
+	List(ctx context.Context, f handler.ReviewFilter) ([]model.Review, error)
+	Update(ctx context.Context, id int, p handler.ReviewPatch) (model.Review, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// Promotions включает и promotion_usage.
+type Promotions interface {
+	Create(ctx context.Context, p model.Promotion) (model.Promotion, error)
+	Get(ctx context.Context, id int) (model.Promotion, error)
+	GetByCode(ctx context.Context, code string) (model.Promotion, error)
+	List(ctx context.Context, f handler.PromotionFilter) ([]model.Promotion, error)
+	Update(ctx context.Context, id int, p handler.PromotionPatch) (model.Promotion, error)
+	Delete(ctx context.Context, id int) error
+
+	Use(ctx context.Context, pu model.PromotionUsage) (model.PromotionUsage, error)
+	ListUsages(ctx context.Context, f handler.PromotionUsageFilter) ([]model.PromotionUsage, error)
+	DeleteUsage(ctx context.Context, id int) error
+}
+
+type Notifications interface {
+	Create(ctx context.Context, n model.Notification) (model.Notification, error)
+	Get(ctx context.Context, id int) (model.Notification, error)
+	List(ctx context.Context, f handler.NotificationFilter) ([]model.Notification, error)
+	Update(ctx context.Context, id int, p handler.NotificationPatch) (model.Notification, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type LoyaltyPoints interface {
+	Set(ctx context.Context, lp model.LoyaltyPoints) (model.LoyaltyPoints, error)
+	Get(ctx context.Context, userID int) (model.LoyaltyPoints, error)
+	List(ctx context.Context, page handler.Page) ([]model.LoyaltyPoints, error)
+	Delete(ctx context.Context, userID int) error
+}
+
+type Referrals interface {
+	Create(ctx context.Context, r model.Referral) (model.Referral, error)
+	Get(ctx context.Context, id int) (model.Referral, error)
+	List(ctx context.Context, f handler.ReferralFilter) ([]model.Referral, error)
+	Update(ctx context.Context, id int, p handler.ReferralPatch) (model.Referral, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type AuditLogs interface {
+	Log(ctx context.Context, a model.AuditLog) (model.AuditLog, error)
+	Get(ctx context.Context, id int) (model.AuditLog, error)
+	List(ctx context.Context, f handler.AuditLogFilter) ([]model.AuditLog, error)
+	Delete(ctx context.Context, id int) error
+}
+
+type SystemSettings interface {
+	Set(ctx context.Context, s model.SystemSetting) (model.SystemSetting, error)
+	Get(ctx context.Context, key string) (model.SystemSetting, error)
+	List(ctx context.Context, page handler.Page) ([]model.SystemSetting, error)
+	Delete(ctx context.Context, key string) error
+}
+
+type TempBookings interface {
+	Create(ctx context.Context, tb model.TempBooking) (model.TempBooking, error)
+	Get(ctx context.Context, id int) (model.TempBooking, error)
+	GetByToken(ctx context.Context, token string) (model.TempBooking, error)
+	List(ctx context.Context, f handler.TempBookingFilter) ([]model.TempBooking, error)
+	// Count считает брони по фильтру без учёта страницы.
+	Count(ctx context.Context, f handler.TempBookingFilter) (int, error)
+	Update(ctx context.Context, id int, p handler.TempBookingPatch) (model.TempBooking, error)
+	Delete(ctx context.Context, id int) error
+	// DeleteExpired удаляет брони, истёкшие к моменту before, на занятии
+	// scheduleID (0 — на всех) и возвращает их число.
+	DeleteExpired(ctx context.Context, scheduleID int, before time.Time) (int, error)
+}
+
+// Waitlist — очередь на заполненные занятия; List отдаёт записи в порядке
+// очереди.
+type Waitlist interface {
+	Create(ctx context.Context, w model.WaitlistEntry) (model.WaitlistEntry, error)
+	Get(ctx context.Context, id int) (model.WaitlistEntry, error)
+	List(ctx context.Context, f handler.WaitlistFilter) ([]model.WaitlistEntry, error)
+	Delete(ctx context.Context, id int) error
+}
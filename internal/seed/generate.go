@@ -0,0 +1,541 @@
+package seed
+
+import (
+	"fmt"
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+var sportNames = []string{
+	"Football", "Basketball", "Tennis", "Swimming", "Yoga",
+	"Boxing", "Cycling", "Running", "Gym", "Martial Arts",
+}
+
+var membershipTypes = []model.Membership{
+	{DurationDays: 30, Price: 29.99},
+	{DurationDays: 90, Price: 79.99},
+	{DurationDays: 180, Price: 149.99},
+	{DurationDays: 365, Price: 249.99},
+	{DurationDays: 7, Price: 9.99},
+}
+
+var systemSettings = []model.SystemSetting{
+	{Key: "club_name", Value: ptr("FitSport Club")},
+	{Key: "max_booking_days_ahead", Value: ptr("14")},
+	{Key: "loyalty_points_per_visit", Value: ptr("10")},
+}
+
+// События аудита и таблица, на строку которой ссылается entity_id.
+// Последнее событие относится к пользователю и служит запасным.
+var auditEvents = []struct{ action, entity, table string }{
+	{"booking_created", "booking", "bookings"},
+	{"booking_cancelled", "booking", "bookings"},
+	{"payment_made", "payment", "payments"},
+	{"attendance_logged", "user", "users"},
+	{"membership_activated", "user", "users"},
+}
+
+// Загрузка зала по часам: утренний и вечерний пик (индекс — час суток).
+var hourWeights = []float64{
+	6: 2, 7: 6, 8: 8, 9: 5, 10: 3, 11: 3, 12: 4, 13: 3,
+	14: 2, 15: 2, 16: 3, 17: 6, 18: 10, 19: 10, 20: 7, 21: 3,
+}
+
+// Сезонность посещений: январский всплеск, летний спад, сентябрь (индекс — месяц).
+var monthWeights = []float64{
+	time.January: 1.4, time.February: 1.2, time.March: 1.1, time.April: 1.0,
+	time.May: 0.9, time.June: 0.75, time.July: 0.65, time.August: 0.7,
+	time.September: 1.15, time.October: 1.1, time.November: 1.0, time.December: 0.8,
+}
+
+// Посещаемость по дням недели (индекс — time.Weekday).
+var weekdayWeights = []float64{
+	time.Sunday: 0.6, time.Monday: 1.2, time.Tuesday: 1.1, time.Wednesday: 1.1,
+	time.Thursday: 1.0, time.Friday: 0.9, time.Saturday: 0.8,
+}
+
+const (
+	historyDays  = 365 // глубина истории расписания и посещений
+	scheduleDays = 14  // на сколько дней вперёд есть расписание
+	// classZipfS — крутизна распределения популярности занятий.
+	classZipfS = 1.2
+)
+
+// Generator выдаёт строки таблиц как последовательности; повторный
+// обход последовательности даёт те же строки.
+type Generator struct {
+	opts   Options
+	counts Counts
+	now    time.Time
+	first  time.Time // начало истории
+	// holdsFrom — отсчёт сроков временных броней: Options.Now, если задан,
+	// иначе момент создания генератора. От начала суток почти все брони
+	// истекли бы ещё до загрузки.
+	holdsFrom time.Time
+
+	// Заполняются в plan.
+	classes       []model.Class
+	rooms         []int // вместимость по id-1
+	schedules     []slot
+	attendable    int // подтверждённые брони прошедших занятий
+	promos        []model.Promotion
+	promosByDay   [][]int32 // id промокодов, действующих в день (от first)
+	promoEligible int       // успешные оплаты в дни действия промокодов
+}
+
+func New(opts Options) (*Generator, error) {
+	counts, err := opts.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	now := opts.now()
+	g := &Generator{opts: opts, counts: counts, now: now, first: now.AddDate(0, 0, -historyDays), holdsFrom: opts.Now}
+	if g.holdsFrom.IsZero() {
+		g.holdsFrom = time.Now().UTC()
+	}
+	if err := g.plan(); err != nil {
+		return nil, fmt.Errorf("inconsistent counts: %w", err)
+	}
+	return g, nil
+}
+
+func (g *Generator) Counts() Counts {
+	return g.counts
+}
+
+// rng — независимый поток случайных чисел для таблицы.
+func (g *Generator) rng(table string) *rand.Rand {
+	return rand.New(rand.NewPCG(g.opts.Seed, uint64(slices.Index(Tables, table)+1)))
+}
+
+// 1. users
+func (g *Generator) Users() iter.Seq[model.User] {
+	return func(yield func(model.User) bool) {
+		for id := 1; id <= g.counts.Users; id++ {
+			if !yield(model.User{ID: id, Email: fmt.Sprintf("user%d@example.com", id)}) {
+				return
+			}
+		}
+	}
+}
+
+// 2. coaches — первые пользователи.
+func (g *Generator) Coaches() iter.Seq[model.Coach] {
+	return func(yield func(model.Coach) bool) {
+		for id := 1; id <= g.counts.Coaches; id++ {
+			if !yield(model.Coach{UserID: id}) {
+				return
+			}
+		}
+	}
+}
+
+// 3. sports
+func (g *Generator) Sports() iter.Seq[model.Sport] {
+	return func(yield func(model.Sport) bool) {
+		for id := 1; id <= g.counts.Sports; id++ {
+			name := fmt.Sprintf("Sport %d", id)
+			if id <= len(sportNames) {
+				name = sportNames[id-1]
+			}
+			if !yield(model.Sport{ID: id, Name: name}) {
+				return
+			}
+		}
+	}
+}
+
+// 4. classes — раскладываются в plan вместе с расписанием тренеров.
+func (g *Generator) Classes() iter.Seq[model.Class] {
+	return func(yield func(model.Class) bool) {
+		for _, c := range g.classes {
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// 5. rooms — от 10 до 50 мест.
+func (g *Generator) Rooms() iter.Seq[model.Room] {
+	return func(yield func(model.Room) bool) {
+		for i, capacity := range g.rooms {
+			if !yield(model.Room{ID: i + 1, Capacity: capacity}) {
+				return
+			}
+		}
+	}
+}
+
+// 6. schedules — популярные занятия (Zipf) идут чаще, время — по часам пик,
+// в одном зале занятия не пересекаются.
+func (g *Generator) Schedules() iter.Seq[model.Schedule] {
+	return func(yield func(model.Schedule) bool) {
+		for i, s := range g.schedules {
+			sc := model.Schedule{
+				ID:        i + 1,
+				ClassID:   int(s.class),
+				RoomID:    int(s.room),
+				StartTime: g.startOf(s),
+				EndTime:   g.endOf(s),
+			}
+			if !yield(sc) {
+				return
+			}
+		}
+	}
+}
+
+// 7. memberships
+func (g *Generator) Memberships() iter.Seq[model.Membership] {
+	return func(yield func(model.Membership) bool) {
+		for id := 1; id <= g.counts.Memberships; id++ {
+			if !yield(g.membership(id)) {
+				return
+			}
+		}
+	}
+}
+
+func (g *Generator) membership(id int) model.Membership {
+	if id <= len(membershipTypes) {
+		m := membershipTypes[id-1]
+		m.ID = id
+		return m
+	}
+	months := id - len(membershipTypes) + 1
+	return model.Membership{ID: id, DurationDays: 30 * months, Price: float64(25*months) - 0.01}
+}
+
+// 8. user_memberships — по одному на первых пользователей.
+func (g *Generator) UserMemberships() iter.Seq[model.UserMembership] {
+	return func(yield func(model.UserMembership) bool) {
+		r := g.rng("user_memberships")
+		for id := 1; id <= g.counts.UserMemberships; id++ {
+			m := g.membership(1 + r.IntN(g.counts.Memberships))
+			started := g.now.AddDate(0, 0, -r.IntN(historyDays))
+			ended := started.AddDate(0, 0, m.DurationDays)
+			um := model.UserMembership{
+				ID:           id,
+				UserID:       id,
+				MembershipID: m.ID,
+				StartedAt:    started,
+				EndedAt:      ended,
+				IsActive:     ptr(!ended.Before(g.now)),
+			}
+			if !yield(um) {
+				return
+			}
+		}
+	}
+}
+
+// 9. payments — по цене абонемента, со скидкой, если применён промокод.
+func (g *Generator) Payments() iter.Seq[model.Payment] {
+	return func(yield func(model.Payment) bool) {
+		for p := range g.purchases() {
+			if !yield(p.payment) {
+				return
+			}
+		}
+	}
+}
+
+// 10. bookings — сгруппированы по занятиям; сначала подтверждённые, затем отменённые.
+func (g *Generator) Bookings() iter.Seq[model.Booking] {
+	return func(yield func(model.Booking) bool) {
+		for b := range g.bookings() {
+			if !yield(b.Booking) {
+				return
+			}
+		}
+	}
+}
+
+type booking struct {
+	model.Booking
+	schedule slot
+}
+
+func (g *Generator) bookings() iter.Seq[booking] {
+	return func(yield func(booking) bool) {
+		id := 0
+		for i, s := range g.schedules {
+			confirmed := int(s.booked - s.cancelled)
+			for j, user := range g.scheduleUsers(i) {
+				id++
+				status := model.BookingConfirmed
+				if j >= confirmed {
+					status = model.BookingCancelled
+				}
+				b := model.Booking{ID: id, UserID: user, ScheduleID: i + 1, Status: &status}
+				if !yield(booking{Booking: b, schedule: s}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// 11. attendance_logs — случайная часть подтверждённых броней прошедших
+// занятий: приход до 15 минут раньше начала, уход до 30 минут после конца.
+// Сезонность наследуется от броней.
+func (g *Generator) AttendanceLogs() iter.Seq[model.AttendanceLog] {
+	return func(yield func(model.AttendanceLog) bool) {
+		r := g.rng("attendance_logs")
+		need, left := g.counts.AttendanceLogs, g.attendable
+		if need == 0 {
+			return
+		}
+		id := 0
+		for b := range g.bookings() {
+			if *b.Status != model.BookingConfirmed || g.endOf(b.schedule).After(g.now) {
+				continue
+			}
+			left--
+			if r.IntN(left+1) >= need {
+				continue
+			}
+			need--
+			id++
+			a := model.AttendanceLog{
+				ID:        id,
+				UserID:    b.UserID,
+				StartTime: g.startOf(b.schedule).Add(-time.Duration(r.IntN(16)) * time.Minute),
+				EndTime:   g.endOf(b.schedule).Add(time.Duration(r.IntN(31)) * time.Minute),
+			}
+			if !yield(a) || need == 0 {
+				return
+			}
+		}
+	}
+}
+
+// 12. reviews — поровну о тренерах и о занятиях, оценки смещены к 4–5.
+func (g *Generator) Reviews() iter.Seq[model.Review] {
+	return func(yield func(model.Review) bool) {
+		r := g.rng("reviews")
+		class := newZipf(r, g.counts.Classes)
+		rating := newWeighted([]float64{1: 5, 2: 8, 3: 17, 4: 35, 5: 35})
+		for id := 1; id <= g.counts.Reviews; id++ {
+			rv := model.Review{ID: id, UserID: 1 + r.IntN(g.counts.Users), Rating: rating.pick(r)}
+			if r.IntN(2) == 0 {
+				rv.CoachID = ptr(1 + r.IntN(g.counts.Coaches))
+			} else {
+				rv.ClassID = ptr(class.pick())
+			}
+			if !yield(rv) {
+				return
+			}
+		}
+	}
+}
+
+// 13. promotions — сроки действия покрывают историю (см. planPromotions).
+func (g *Generator) Promotions() iter.Seq[model.Promotion] {
+	return slices.Values(g.promos)
+}
+
+// 14. promotion_usage — по одной строке на оплату со скидкой.
+func (g *Generator) PromotionUsage() iter.Seq[model.PromotionUsage] {
+	return func(yield func(model.PromotionUsage) bool) {
+		id := 0
+		for p := range g.purchases() {
+			if p.promo == 0 {
+				continue
+			}
+			id++
+			if !yield(model.PromotionUsage{ID: id, UserID: p.payment.UserID, PromotionID: p.promo}) {
+				return
+			}
+		}
+	}
+}
+
+// 15. notifications — 70% прочитаны.
+func (g *Generator) Notifications() iter.Seq[model.Notification] {
+	return func(yield func(model.Notification) bool) {
+		r := g.rng("notifications")
+		for id := 1; id <= g.counts.Notifications; id++ {
+			n := model.Notification{ID: id, UserID: 1 + r.IntN(g.counts.Users), IsRead: ptr(r.Float64() < 0.7)}
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// 16. loyalty_points — первые пользователи.
+func (g *Generator) LoyaltyPoints() iter.Seq[model.LoyaltyPoints] {
+	return func(yield func(model.LoyaltyPoints) bool) {
+		r := g.rng("loyalty_points")
+		for id := 1; id <= g.counts.LoyaltyPoints; id++ {
+			if !yield(model.LoyaltyPoints{UserID: id, Points: ptr(r.IntN(5001))}) {
+				return
+			}
+		}
+	}
+}
+
+// 17. referrals — различные пары, 90% с начисленной наградой.
+func (g *Generator) Referrals() iter.Seq[model.Referral] {
+	return func(yield func(model.Referral) bool) {
+		r := g.rng("referrals")
+		seen := make(map[[2]int]struct{}, g.counts.Referrals)
+		for id := 1; id <= g.counts.Referrals; {
+			key := [2]int{1 + r.IntN(g.counts.Users), 1 + r.IntN(g.counts.Users)}
+			if _, dup := seen[key]; dup || key[0] == key[1] {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			rf := model.Referral{ID: id, ReferrerID: key[0], ReferredID: key[1], Rewarded: ptr(r.Float64() < 0.9)}
+			if !yield(rf) {
+				return
+			}
+			id++
+		}
+	}
+}
+
+// 18. audit_logs — entity_id ссылается на существующую строку своего типа.
+func (g *Generator) AuditLogs() iter.Seq[model.AuditLog] {
+	return func(yield func(model.AuditLog) bool) {
+		r := g.rng("audit_logs")
+		for id := 1; id <= g.counts.AuditLogs; id++ {
+			e := auditEvents[r.IntN(len(auditEvents))]
+			n := *g.counts.Field(e.table)
+			if n == 0 {
+				e, n = auditEvents[len(auditEvents)-1], g.counts.Users
+			}
+			a := model.AuditLog{
+				ID:          id,
+				Action:      e.action,
+				EntityType:  ptr(e.entity),
+				EntityID:    ptr(1 + r.IntN(n)),
+				PerformedAt: ptr(g.first.Add(time.Duration(r.Int64N(int64(historyDays * 24 * time.Hour))))),
+			}
+			if g.counts.Users > 0 {
+				a.UserID = ptr(1 + r.IntN(g.counts.Users))
+			}
+			if e.entity == "user" {
+				a.UserID = a.EntityID
+			}
+			if !yield(a) {
+				return
+			}
+		}
+	}
+}
+
+// 19. system_settings
+func (g *Generator) SystemSettings() iter.Seq[model.SystemSetting] {
+	return slices.Values(systemSettings[:g.counts.SystemSettings])
+}
+
+// 20. temp_bookings — удержание свободного места на предстоящее занятие
+// пользователем, который на него ещё не записан; истекают через 5–35 минут
+// после holdsFrom.
+func (g *Generator) TempBookings() iter.Seq[model.TempBooking] {
+	return func(yield func(model.TempBooking) bool) {
+		if g.counts.TempBookings == 0 {
+			return
+		}
+		r := g.rng("temp_bookings")
+
+		var upcoming []int
+		for i, s := range g.schedules {
+			if g.upcoming(s) {
+				upcoming = append(upcoming, i)
+			}
+		}
+		held := map[int][]int{} // занятие -> удерживающие пользователи
+		full := func(i int) bool {
+			return int(g.schedules[i].booked)+len(held[i]) >= g.seats(g.schedules[i])
+		}
+
+		for id := 1; id <= g.counts.TempBookings; id++ {
+			k := r.IntN(len(upcoming))
+			for tries := 0; full(upcoming[k]); tries++ {
+				if tries < 100 {
+					k = r.IntN(len(upcoming))
+				} else {
+					k = (k + 1) % len(upcoming)
+				}
+			}
+			i := upcoming[k]
+
+			taken := append(g.scheduleUsers(i), held[i]...)
+			user := 1 + r.IntN(g.counts.Users)
+			for slices.Contains(taken, user) {
+				user = 1 + r.IntN(g.counts.Users)
+			}
+			held[i] = append(held[i], user)
+
+			tb := model.TempBooking{
+				ID:         id,
+				UserID:     user,
+				ScheduleID: i + 1,
+				ExpiresAt:  g.holdsFrom.Add(time.Duration(5+r.IntN(31)) * time.Minute),
+				Token:      fmt.Sprintf("%016x%016x", r.Uint64(), r.Uint64()),
+			}
+			if !yield(tb) {
+				return
+			}
+		}
+	}
+}
+
+// --- распределения ---
+
+// weighted выбирает индекс с вероятностью, пропорциональной весу.
+type weighted struct {
+	cumulative []float64
+}
+
+func newWeighted(weights []float64) weighted {
+	cumulative := make([]float64, len(weights))
+	sum := 0.0
+	for i, w := range weights {
+		sum += w
+		cumulative[i] = sum
+	}
+	return weighted{cumulative: cumulative}
+}
+
+func (w weighted) pick(r *rand.Rand) int {
+	x := r.Float64() * w.cumulative[len(w.cumulative)-1]
+	i, _ := slices.BinarySearch(w.cumulative, x)
+	// Нулевые веса дают одинаковые соседние суммы; берём первый ненулевой.
+	for i < len(w.cumulative)-1 && w.cumulative[i] <= x {
+		i++
+	}
+	return i
+}
+
+// zipf выбирает id из 1..n по закону Zipf; самые популярные id
+// перемешаны, чтобы популярность не совпадала с порядком вставки.
+type zipf struct {
+	z    *rand.Zipf
+	perm []int
+}
+
+func newZipf(r *rand.Rand, n int) zipf {
+	if n == 0 {
+		return zipf{}
+	}
+	return zipf{z: rand.NewZipf(r, classZipfS, 1, uint64(n-1)), perm: r.Perm(n)}
+}
+
+func (z zipf) pick() int {
+	return z.perm[z.z.Uint64()] + 1
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
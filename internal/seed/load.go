@@ -0,0 +1,220 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/pkg/model"
+)
+
+// ErrNotEmpty — в базе уже есть данные, а LoadOptions.Truncate не задан.
+var ErrNotEmpty = errors.New("database is not empty")
+
+// insertBatch — строк в одном INSERT (с запасом до лимита в 65535 параметров).
+const insertBatch = 1000
+
+type LoadOptions struct {
+	// Truncate очищает все таблицы перед загрузкой; иначе непустая база — ошибка.
+	Truncate bool
+	// DropIndexes пересоздаёт вторичные индексы таблиц, загружаемых через
+	// COPY, после загрузки (см. handler.CopyOptions).
+	DropIndexes bool
+	// Logf вызывается после каждой таблицы и по ходу загрузки больших
+	// таблиц; nil — без вывода.
+	Logf func(format string, args ...any)
+}
+
+// Load записывает сгенерированные строки в db одной транзакцией. Строки
+// вставляются с явными id, после чего последовательности SERIAL
+// выставляются на максимум. Самые объёмные таблицы (users, payments,
+// bookings, attendance_logs, audit_logs) загружаются через COPY, остальные —
+// многострочными INSERT.
+func Load(ctx context.Context, db *sql.DB, g *Generator, opts LoadOptions) error {
+	return handler.WithTx(ctx, db, nil, func(tx *sql.Tx) error {
+		if err := prepare(ctx, tx, opts.Truncate); err != nil {
+			return err
+		}
+
+		for _, step := range g.steps(opts) {
+			start := time.Now()
+			n, err := step.load(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("%s: %w", step.table, err)
+			}
+			if opts.Logf != nil {
+				elapsed := time.Since(start)
+				opts.Logf("✅ %-16s %9d rows in %-8s (%.0f rows/s)", step.table, n, elapsed.Round(time.Millisecond),
+					handler.CopyStats{Rows: n, Elapsed: elapsed}.RowsPerSecond())
+			}
+		}
+
+		return resetSequences(ctx, tx)
+	})
+}
+
+func prepare(ctx context.Context, tx *sql.Tx, truncate bool) error {
+	if truncate {
+		_, err := tx.ExecContext(ctx, "TRUNCATE "+strings.Join(Tables, ", ")+" RESTART IDENTITY CASCADE")
+		return err
+	}
+
+	for _, table := range Tables {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+")").Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: table %s has rows", ErrNotEmpty, table)
+		}
+	}
+	return nil
+}
+
+// У этих таблиц ключ — не SERIAL id.
+var withoutSerial = []string{"coaches", "loyalty_points", "system_settings"}
+
+func resetSequences(ctx context.Context, tx *sql.Tx) error {
+	for _, table := range Tables {
+		if slices.Contains(withoutSerial, table) {
+			continue
+		}
+		if err := handler.ResetSequence(ctx, tx, table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type step struct {
+	table string
+	load  func(ctx context.Context, tx *sql.Tx) (int, error)
+}
+
+// steps описывает загрузку каждой таблицы в порядке Tables.
+func (g *Generator) steps(opts LoadOptions) []step {
+	copyOpts := handler.CopyOptions{KeepIDs: true, DropIndexes: opts.DropIndexes}
+	if opts.Logf != nil {
+		copyOpts.Progress = func(s handler.CopyStats) {
+			opts.Logf("   … %s", s)
+		}
+	}
+
+	return []step{
+		{"users", copier(g.Users(), handler.CopyUsers, copyOpts)},
+		{"coaches", inserter("coaches", []string{"user_id"}, g.Coaches(),
+			func(c model.Coach) []any { return []any{c.UserID} })},
+		{"sports", inserter("sports", []string{"id", "name"}, g.Sports(),
+			func(s model.Sport) []any { return []any{s.ID, s.Name} })},
+		{"classes", inserter("classes", []string{"id", "sport_id", "coach_id"}, g.Classes(),
+			func(c model.Class) []any { return []any{c.ID, c.SportID, c.CoachID} })},
+		{"rooms", inserter("rooms", []string{"id", "capacity"}, g.Rooms(),
+			func(r model.Room) []any { return []any{r.ID, r.Capacity} })},
+		{"schedules", inserter("schedules", []string{"id", "class_id", "room_id", "start_time", "end_time"},
+			g.Schedules(),
+			func(s model.Schedule) []any { return []any{s.ID, s.ClassID, s.RoomID, s.StartTime, s.EndTime} })},
+		{"memberships", inserter("memberships", []string{"id", "duration_days", "price"}, g.Memberships(),
+			func(m model.Membership) []any { return []any{m.ID, m.DurationDays, m.Price} })},
+		{"user_memberships", inserter("user_memberships",
+			[]string{"id", "user_id", "membership_id", "started_at", "ended_at", "is_active"},
+			g.UserMemberships(),
+			func(um model.UserMembership) []any {
+				return []any{um.ID, um.UserID, um.MembershipID, um.StartedAt, um.EndedAt, um.IsActive}
+			})},
+		{"payments", copier(g.Payments(), handler.CopyPayments, copyOpts)},
+		{"bookings", copier(g.Bookings(), handler.CopyBookings, copyOpts)},
+		{"attendance_logs", copier(g.AttendanceLogs(), handler.CopyAttendanceLogs, copyOpts)},
+		{"reviews", inserter("reviews", []string{"id", "user_id", "coach_id", "class_id", "rating"}, g.Reviews(),
+			func(r model.Review) []any { return []any{r.ID, r.UserID, r.CoachID, r.ClassID, r.Rating} })},
+		{"promotions", inserter("promotions",
+			[]string{"id", "code", "discount_percent", "valid_from", "valid_until", "max_uses", "used_count"},
+			g.Promotions(),
+			func(p model.Promotion) []any {
+				return []any{p.ID, p.Code, p.DiscountPercent, p.ValidFrom, p.ValidUntil, p.MaxUses, p.UsedCount}
+			})},
+		{"promotion_usage", inserter("promotion_usage", []string{"id", "user_id", "promotion_id"},
+			g.PromotionUsage(),
+			func(pu model.PromotionUsage) []any { return []any{pu.ID, pu.UserID, pu.PromotionID} })},
+		{"notifications", inserter("notifications", []string{"id", "user_id", "is_read"}, g.Notifications(),
+			func(n model.Notification) []any { return []any{n.ID, n.UserID, n.IsRead} })},
+		{"loyalty_points", inserter("loyalty_points", []string{"user_id", "points"}, g.LoyaltyPoints(),
+			func(lp model.LoyaltyPoints) []any { return []any{lp.UserID, lp.Points} })},
+		{"referrals", inserter("referrals", []string{"id", "referrer_id", "referred_id", "rewarded"},
+			g.Referrals(),
+			func(r model.Referral) []any { return []any{r.ID, r.ReferrerID, r.ReferredID, r.Rewarded} })},
+		{"audit_logs", copier(g.AuditLogs(), handler.CopyAuditLogs, copyOpts)},
+		{"system_settings", inserter("system_settings", []string{"key", "value"}, g.SystemSettings(),
+			func(s model.SystemSetting) []any { return []any{s.Key, s.Value} })},
+		{"temp_bookings", inserter("temp_bookings",
+			[]string{"id", "user_id", "schedule_id", "expires_at", "token"}, g.TempBookings(),
+			func(tb model.TempBooking) []any {
+				return []any{tb.ID, tb.UserID, tb.ScheduleID, tb.ExpiresAt, tb.Token}
+			})},
+	}
+}
+
+type copyFunc[T any] func(context.Context, *sql.Tx, iter.Seq[T], handler.CopyOptions) (handler.CopyStats, error)
+
+func copier[T any](rows iter.Seq[T], fn copyFunc[T], opts handler.CopyOptions) func(context.Context, *sql.Tx) (int, error) {
+	return func(ctx context.Context, tx *sql.Tx) (int, error) {
+		stats, err := fn(ctx, tx, rows, opts)
+		return stats.Rows, err
+	}
+}
+
+// inserter вставляет rows многострочными INSERT по insertBatch строк.
+func inserter[T any](
+	table string,
+	columns []string,
+	rows iter.Seq[T],
+	values func(T) []any,
+) func(ctx context.Context, tx *sql.Tx) (int, error) {
+	return func(ctx context.Context, tx *sql.Tx) (int, error) {
+		prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+		args := make([]any, 0, insertBatch*len(columns))
+		total := 0
+
+		flush := func() error {
+			if len(args) == 0 {
+				return nil
+			}
+			var b strings.Builder
+			b.WriteString(prefix)
+			for i := 0; i < len(args); i += len(columns) {
+				if i > 0 {
+					b.WriteString(", ")
+				}
+				b.WriteByte('(')
+				for j := range columns {
+					if j > 0 {
+						b.WriteString(", ")
+					}
+					fmt.Fprintf(&b, "$%d", i+j+1)
+				}
+				b.WriteByte(')')
+			}
+			if _, err := tx.ExecContext(ctx, b.String(), args...); err != nil {
+				return err
+			}
+			args = args[:0]
+			return nil
+		}
+
+		for row := range rows {
+			args = append(args, values(row)...)
+			total++
+			if len(args) == insertBatch*len(columns) {
+				if err := flush(); err != nil {
+					return total, err
+				}
+			}
+		}
+		return total, flush()
+	}
+}
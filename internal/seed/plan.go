@@ -0,0 +1,372 @@
+package seed
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"databases2026/pkg/model"
+)
+
+// Зависимые таблицы строятся из родительских, поэтому данные согласованы:
+//   - занятия в одном зале и занятия одного тренера не пересекаются по времени;
+//   - броней на занятие не больше, чем мест в зале;
+//   - посещения — подтверждённые брони уже прошедших занятий;
+//   - платёж равен цене абонемента за вычетом скидки применённого промокода,
+//     а promotions.used_count — числу строк promotion_usage.
+// Для этого New заранее раскладывает расписание и брони по занятиям
+// (16 байт на занятие), а строки генерируются при обходе.
+
+const (
+	openHour    = 6
+	slotMinutes = 30
+	daySlots    = 32 // 6:00–22:00 получасовыми слотами
+
+	// Занятие занимает не больше 3 слотов, поэтому при ≤ 7 занятиях в день
+	// в зале всегда остаётся 3 свободных слота подряд и раскладка не
+	// упирается во фрагментацию.
+	maxSchedulesPerRoomDay = 7
+
+	cancelRate = 0.1
+)
+
+var durations = []struct{ minutes, slots int }{{45, 2}, {60, 2}, {60, 2}, {90, 3}}
+
+// slot — занятие расписания.
+type slot struct {
+	class, room int32
+	start       int32 // минуты от g.first
+	minutes     int16
+	booked      uint8 // всего броней
+	cancelled   uint8 // из них отменённых
+}
+
+func (g *Generator) plan() error {
+	g.planClasses()
+	g.planRooms()
+	if err := g.planSchedules(); err != nil {
+		return err
+	}
+	if err := g.planBookings(); err != nil {
+		return err
+	}
+	return g.planPromotions()
+}
+
+func (g *Generator) planRooms() {
+	r := g.rng("rooms")
+	g.rooms = make([]int, g.counts.Rooms)
+	for i := range g.rooms {
+		g.rooms[i] = 10 + r.IntN(41)
+	}
+}
+
+// planClasses назначает классам вид спорта и тренера: по тренерам
+// planSchedules разводит занятия во времени.
+func (g *Generator) planClasses() {
+	r := g.rng("classes")
+	g.classes = make([]model.Class, g.counts.Classes)
+	for i := range g.classes {
+		g.classes[i] = model.Class{ID: i + 1, SportID: 1 + r.IntN(g.counts.Sports), CoachID: 1 + r.IntN(g.counts.Coaches)}
+	}
+}
+
+// planSchedules раскладывает занятия по залам и получасовым слотам так,
+// чтобы не пересекались ни занятия в одном зале, ни занятия одного тренера:
+// сначала случайно по часам пик, а если зал или тренер занят — первое
+// свободное место по кругу. Если у тренера места не осталось, занятие
+// достаётся следующему по id классу, у тренера которого место есть.
+func (g *Generator) planSchedules() error {
+	r := g.rng("schedules")
+	class := newZipf(r, g.counts.Classes)
+	hour := newWeighted(hourWeights)
+
+	days := historyDays + scheduleDays
+	rooms := make([]uint32, g.counts.Rooms*days)     // занятые слоты по (зал, день)
+	coaches := make([]uint32, g.counts.Coaches*days) // занятые слоты по (тренер, день)
+	// full[тренер-1] — длина занятия в слотах, для которой у тренера не
+	// нашлось места; такие и более длинные занятия ему больше не достаются.
+	full := make([]int, g.counts.Coaches)
+	place := func(coach, room, day, first, n int) bool {
+		if first < 0 || first+n > daySlots {
+			return false
+		}
+		mask := (uint32(1)<<n - 1) << first
+		rc, cc := room*days+day, (coach-1)*days+day
+		if rooms[rc]&mask != 0 || coaches[cc]&mask != 0 {
+			return false
+		}
+		rooms[rc] |= mask
+		coaches[cc] |= mask
+		return true
+	}
+	// scan ищет первое свободное место тренера по кругу, начиная со случайного дня.
+	scan := func(coach, n int) (room, day, first int, ok bool) {
+		d0, r0 := r.IntN(days), r.IntN(g.counts.Rooms)
+		for dd := range days {
+			day := (d0 + dd) % days
+			for f := 0; f+n <= daySlots; f++ {
+				if coaches[(coach-1)*days+day]&((uint32(1)<<n-1)<<f) != 0 {
+					continue
+				}
+				for rr := range g.counts.Rooms {
+					room := (r0 + rr) % g.counts.Rooms
+					if place(coach, room, day, f, n) {
+						return room, day, f, true
+					}
+				}
+			}
+		}
+		return 0, 0, 0, false
+	}
+
+	g.schedules = make([]slot, g.counts.Schedules)
+	for i := range g.schedules {
+		d := durations[r.IntN(len(durations))]
+		picked := class.pick()
+		for c := picked; ; {
+			coach := g.classes[c-1].CoachID
+			room, day, first, placed := 0, 0, 0, false
+			if full[coach-1] == 0 || d.slots < full[coach-1] {
+				for range 20 {
+					room, day = r.IntN(g.counts.Rooms), r.IntN(days)
+					first = (hour.pick(r)-openHour)*2 + r.IntN(2)
+					if place(coach, room, day, first, d.slots) {
+						placed = true
+						break
+					}
+				}
+				if !placed {
+					room, day, first, placed = scan(coach, d.slots)
+				}
+				if !placed {
+					full[coach-1] = d.slots
+				}
+			}
+			if placed {
+				g.schedules[i] = slot{
+					class:   int32(c),
+					room:    int32(room + 1),
+					start:   int32(day*24*60 + openHour*60 + first*slotMinutes),
+					minutes: int16(d.minutes),
+				}
+				break
+			}
+			if c = c%g.counts.Classes + 1; c == picked {
+				return fmt.Errorf("schedules (%d) do not fit: rooms and coaches are fully booked after %d", g.counts.Schedules, i)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Generator) startOf(s slot) time.Time {
+	return g.first.Add(time.Duration(s.start) * time.Minute)
+}
+
+func (g *Generator) endOf(s slot) time.Time {
+	return g.startOf(s).Add(time.Duration(s.minutes) * time.Minute)
+}
+
+// upcoming — занятие ещё не началось к моменту, от которого считаются
+// временные брони.
+func (g *Generator) upcoming(s slot) bool {
+	return g.startOf(s).After(g.holdsFrom)
+}
+
+// seats — сколько разных пользователей может записаться на занятие.
+func (g *Generator) seats(s slot) int {
+	return min(g.rooms[s.room-1], g.counts.Users)
+}
+
+// planBookings распределяет брони по занятиям с учётом сезонности и
+// вместимости зала.
+func (g *Generator) planBookings() error {
+	total := 0
+	for _, s := range g.schedules {
+		total += g.seats(s)
+	}
+	if g.counts.Bookings > total {
+		return fmt.Errorf("bookings (%d) exceed the seats of all schedules (%d)", g.counts.Bookings, total)
+	}
+
+	r := g.rng("bookings")
+	maxWeight := slices.Max(monthWeights) * slices.Max(weekdayWeights)
+	pick := func() int {
+		for range 100 {
+			i := r.IntN(len(g.schedules))
+			s := g.schedules[i]
+			start := g.startOf(s)
+			w := monthWeights[start.Month()] * weekdayWeights[start.Weekday()]
+			if int(s.booked) < g.seats(s) && r.Float64()*maxWeight < w {
+				return i
+			}
+		}
+		// Почти все места заняты — первое свободное по кругу.
+		i := r.IntN(len(g.schedules))
+		for int(g.schedules[i].booked) >= g.seats(g.schedules[i]) {
+			i = (i + 1) % len(g.schedules)
+		}
+		return i
+	}
+
+	for range g.counts.Bookings {
+		s := &g.schedules[pick()]
+		s.booked++
+		if r.Float64() < cancelRate {
+			s.cancelled++
+		} else if !g.endOf(*s).After(g.now) {
+			g.attendable++
+		}
+	}
+	if g.counts.AttendanceLogs > g.attendable {
+		return fmt.Errorf("attendance_logs (%d) exceed confirmed bookings of past schedules (%d); increase bookings",
+			g.counts.AttendanceLogs, g.attendable)
+	}
+
+	free := 0
+	for _, s := range g.schedules {
+		if g.upcoming(s) {
+			free += g.seats(s) - int(s.booked)
+		}
+	}
+	if g.counts.TempBookings > free {
+		return fmt.Errorf("temp_bookings (%d) exceed free seats of upcoming schedules (%d)", g.counts.TempBookings, free)
+	}
+	return nil
+}
+
+// scheduleUsers — пользователи, записавшиеся на i-е занятие (без повторов).
+// У каждого занятия свой поток случайных чисел, поэтому список можно
+// получить для любого занятия в любой момент.
+func (g *Generator) scheduleUsers(i int) []int {
+	n := int(g.schedules[i].booked)
+	r := rand.New(rand.NewPCG(g.opts.Seed, uint64(len(Tables))+uint64(i)+1))
+	users := make([]int, 0, n)
+	for len(users) < n {
+		u := 1 + r.IntN(g.counts.Users)
+		if !slices.Contains(users, u) {
+			users = append(users, u)
+		}
+	}
+	return users
+}
+
+// planPromotions задаёт сроки промокодов, выбирает оплаты со скидкой и
+// подсчитывает used_count.
+func (g *Generator) planPromotions() error {
+	r := g.rng("promotions")
+	g.promosByDay = make([][]int32, historyDays+scheduleDays)
+	g.promos = make([]model.Promotion, g.counts.Promotions)
+	// Промокоды по очереди покрывают всю историю и немного перекрываются.
+	span := (historyDays + len(g.promos) - 1) / max(1, len(g.promos))
+	for i := range g.promos {
+		day := max(0, i*span-r.IntN(15))
+		length := i*span - day + span + 30 + r.IntN(61)
+		g.promos[i] = model.Promotion{
+			ID:              i + 1,
+			Code:            fmt.Sprintf("PROMO%d", i+1),
+			DiscountPercent: 10 + r.IntN(41),
+			ValidFrom:       g.first.AddDate(0, 0, day),
+			ValidUntil:      g.first.AddDate(0, 0, day+length),
+		}
+		for d := day; d <= day+length && d < len(g.promosByDay); d++ {
+			g.promosByDay[d] = append(g.promosByDay[d], int32(i+1))
+		}
+	}
+
+	for p := range g.payments() {
+		if len(p.promos) > 0 {
+			g.promoEligible++
+		}
+	}
+	if g.counts.PromotionUsage > g.promoEligible {
+		return fmt.Errorf("promotion_usage (%d) exceeds completed payments made while a promotion was valid (%d)",
+			g.counts.PromotionUsage, g.promoEligible)
+	}
+
+	used := make([]int, len(g.promos))
+	for p := range g.purchases() {
+		if p.promo > 0 {
+			used[p.promo-1]++
+		}
+	}
+	for i := range g.promos {
+		g.promos[i].UsedCount = ptr(used[i])
+		g.promos[i].MaxUses = ptr(max(used[i], 100+r.IntN(1001)))
+	}
+	return nil
+}
+
+// purchase — оплата абонемента.
+type purchase struct {
+	payment model.Payment
+	promos  []int32 // промокоды, действовавшие в день успешной оплаты
+	promo   int     // применённый промокод или 0
+}
+
+// payments перебирает оплаты абонементов без промокодов; 5% неуспешных.
+func (g *Generator) payments() iter.Seq[purchase] {
+	return func(yield func(purchase) bool) {
+		r := g.rng("payments")
+		emit := func(id, userID int, m model.Membership, paid time.Time) bool {
+			p := purchase{payment: model.Payment{ID: id, UserID: userID, Amount: m.Price}}
+			status := model.PaymentCompleted
+			if r.Float64() < 0.05 {
+				status = model.PaymentFailed
+			} else if day := int(paid.Sub(g.first).Hours() / 24); day >= 0 && day < len(g.promosByDay) {
+				p.promos = g.promosByDay[day]
+			}
+			p.payment.Status = &status
+			return yield(p)
+		}
+
+		id := 0
+		for renewal := 0; id < g.counts.Payments; renewal++ {
+			// Платежей больше, чем абонементов, — предыдущие периоды тех же абонементов.
+			for um := range g.UserMemberships() {
+				if id == g.counts.Payments {
+					break
+				}
+				id++
+				m := g.membership(um.MembershipID)
+				if !emit(id, um.UserID, m, um.StartedAt.AddDate(0, 0, -renewal*m.DurationDays)) {
+					return
+				}
+			}
+			if g.counts.UserMemberships == 0 {
+				id++
+				m := g.membership(1 + r.IntN(g.counts.Memberships))
+				if !emit(id, 1+r.IntN(g.counts.Users), m, g.first.AddDate(0, 0, r.IntN(historyDays))) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// purchases — payments, где ровно counts.PromotionUsage подходящих оплат
+// получили скидку одного из действовавших промокодов.
+func (g *Generator) purchases() iter.Seq[purchase] {
+	return func(yield func(purchase) bool) {
+		r := g.rng("promotion_usage")
+		need, left := g.counts.PromotionUsage, g.promoEligible
+		for p := range g.payments() {
+			if len(p.promos) > 0 {
+				if r.IntN(left) < need {
+					p.promo = int(p.promos[r.IntN(len(p.promos))])
+					discount := g.promos[p.promo-1].DiscountPercent
+					p.payment.Amount = math.Round(p.payment.Amount*float64(100-discount)) / 100
+					need--
+				}
+				left--
+			}
+			if !yield(p) {
+				return
+			}
+		}
+	}
+}
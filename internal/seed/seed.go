@@ -0,0 +1,227 @@
+// Package seed генерирует тестовые данные для всех таблиц схемы.
+//
+// Объёмы задаются масштабом (Scale = 1 соответствует бывшему
+// generate_3m_bookings.sql) и точечными переопределениями по таблицам.
+// При одинаковых Options с заданным Now результат побайтно одинаков: у
+// каждой таблицы свой генератор случайных чисел, производный от Seed,
+// поэтому изменение объёма одной таблицы не сдвигает данные независимых от
+// неё таблиц. Без Now сроки временных броней считаются от текущего момента.
+// Ссылочную согласованность зависимых таблиц обеспечивает plan.go.
+package seed
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tables — таблицы в порядке загрузки (родительские раньше дочерних).
+var Tables = []string{
+	"users", "coaches", "sports", "classes", "rooms", "schedules",
+	"memberships", "user_memberships", "payments", "bookings", "attendance_logs",
+	"reviews", "promotions", "promotion_usage", "notifications", "loyalty_points",
+	"referrals", "audit_logs", "system_settings", "temp_bookings",
+}
+
+// Counts — число строк по таблицам.
+type Counts struct {
+	Users           int
+	Coaches         int
+	Sports          int
+	Classes         int
+	Rooms           int
+	Schedules       int
+	Memberships     int
+	UserMemberships int
+	Payments        int
+	Bookings        int
+	AttendanceLogs  int
+	Reviews         int
+	Promotions      int
+	PromotionUsage  int
+	Notifications   int
+	LoyaltyPoints   int
+	Referrals       int
+	AuditLogs       int
+	SystemSettings  int
+	TempBookings    int
+}
+
+// DefaultCounts — объёмы при Scale = 1.
+func DefaultCounts() Counts {
+	return Counts{
+		Users:           10_000,
+		Coaches:         100,
+		Sports:          10,
+		Classes:         500,
+		Rooms:           20,
+		Schedules:       50_000,
+		Memberships:     5,
+		UserMemberships: 8_000,
+		Payments:        8_000,
+		Bookings:        45_000,
+		AttendanceLogs:  30_000,
+		Reviews:         5_000,
+		Promotions:      10,
+		PromotionUsage:  2_000,
+		Notifications:   15_000,
+		LoyaltyPoints:   5_000,
+		Referrals:       1_000,
+		AuditLogs:       50_000,
+		SystemSettings:  len(systemSettings),
+		TempBookings:    1_000,
+	}
+}
+
+// Справочники не масштабируются: их размер задаёт предметная область.
+var unscaled = []string{"sports", "memberships", "system_settings"}
+
+// Field возвращает указатель на счётчик таблицы или nil для неизвестного имени.
+func (c *Counts) Field(table string) *int {
+	switch table {
+	case "users":
+		return &c.Users
+	case "coaches":
+		return &c.Coaches
+	case "sports":
+		return &c.Sports
+	case "classes":
+		return &c.Classes
+	case "rooms":
+		return &c.Rooms
+	case "schedules":
+		return &c.Schedules
+	case "memberships":
+		return &c.Memberships
+	case "user_memberships":
+		return &c.UserMemberships
+	case "payments":
+		return &c.Payments
+	case "bookings":
+		return &c.Bookings
+	case "attendance_logs":
+		return &c.AttendanceLogs
+	case "reviews":
+		return &c.Reviews
+	case "promotions":
+		return &c.Promotions
+	case "promotion_usage":
+		return &c.PromotionUsage
+	case "notifications":
+		return &c.Notifications
+	case "loyalty_points":
+		return &c.LoyaltyPoints
+	case "referrals":
+		return &c.Referrals
+	case "audit_logs":
+		return &c.AuditLogs
+	case "system_settings":
+		return &c.SystemSettings
+	case "temp_bookings":
+		return &c.TempBookings
+	}
+	return nil
+}
+
+type Options struct {
+	// Seed определяет все случайные значения.
+	Seed uint64
+	// Scale умножает объёмы DefaultCounts; 0 означает 1.
+	Scale float64
+	// Counts переопределяет объём отдельных таблиц (ключ — имя из Tables)
+	// после применения Scale.
+	Counts map[string]int
+	// Now — точка отсчёта для дат; нулевое значение — начало текущих суток UTC,
+	// а для сроков временных броней — момент вызова New. Для воспроизводимого
+	// результата задавайте явно.
+	Now time.Time
+}
+
+// Plan вычисляет итоговые объёмы и проверяет их согласованность.
+func (o Options) Plan() (Counts, error) {
+	scale := o.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	if scale < 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		return Counts{}, fmt.Errorf("invalid scale %v", o.Scale)
+	}
+
+	c := DefaultCounts()
+	for _, table := range Tables {
+		if slices.Contains(unscaled, table) {
+			continue
+		}
+		// Не меньше одной строки, иначе на малом масштабе у дочерних
+		// таблиц пропадут родительские (например, залы для расписания).
+		p := c.Field(table)
+		*p = max(1, int(math.Round(float64(*p)*scale)))
+	}
+
+	for table, n := range o.Counts {
+		p := c.Field(table)
+		if p == nil {
+			return Counts{}, fmt.Errorf("unknown table %q", table)
+		}
+		if n < 0 {
+			return Counts{}, fmt.Errorf("%s: negative count %d", table, n)
+		}
+		*p = n
+	}
+
+	return c, c.validate()
+}
+
+func (c Counts) validate() error {
+	var problems []string
+	need := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	need(c.Coaches <= c.Users, "coaches (%d) must not exceed users (%d)", c.Coaches, c.Users)
+	need(c.LoyaltyPoints <= c.Users, "loyalty_points (%d) must not exceed users (%d)", c.LoyaltyPoints, c.Users)
+	need(c.UserMemberships <= c.Users, "user_memberships (%d) must not exceed users (%d)",
+		c.UserMemberships, c.Users)
+	need(c.SystemSettings <= len(systemSettings), "system_settings (%d) must not exceed %d",
+		c.SystemSettings, len(systemSettings))
+	need(c.AttendanceLogs <= c.Bookings, "attendance_logs (%d) must not exceed bookings (%d)",
+		c.AttendanceLogs, c.Bookings)
+	need(c.PromotionUsage <= c.Payments, "promotion_usage (%d) must not exceed payments (%d)",
+		c.PromotionUsage, c.Payments)
+	slots := c.Rooms * (historyDays + scheduleDays) * maxSchedulesPerRoomDay
+	need(c.Schedules <= slots, "schedules (%d) must not exceed %d per room per day (%d)",
+		c.Schedules, maxSchedulesPerRoomDay, slots)
+	need(c.Referrals <= c.Users*(c.Users-1), "referrals (%d) must not exceed users × (users - 1)", c.Referrals)
+
+	dependsOn := func(child string, n int, parents ...string) {
+		for _, parent := range parents {
+			need(n == 0 || *c.Field(parent) > 0, "%s requires %s", child, parent)
+		}
+	}
+	dependsOn("classes", c.Classes, "sports", "coaches")
+	dependsOn("schedules", c.Schedules, "classes", "rooms")
+	dependsOn("user_memberships", c.UserMemberships, "memberships")
+	dependsOn("payments", c.Payments, "users", "memberships")
+	dependsOn("bookings", c.Bookings, "users", "schedules")
+	dependsOn("attendance_logs", c.AttendanceLogs, "bookings")
+	dependsOn("reviews", c.Reviews, "users", "classes")
+	dependsOn("promotion_usage", c.PromotionUsage, "payments", "promotions")
+	dependsOn("notifications", c.Notifications, "users")
+	dependsOn("temp_bookings", c.TempBookings, "users", "schedules")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("inconsistent counts: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (o Options) now() time.Time {
+	if !o.Now.IsZero() {
+		return o.Now
+	}
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
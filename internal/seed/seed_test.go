@@ -0,0 +1,251 @@
+package seed_test
+
+import (
+	"encoding/json"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"databases2026/internal/seed"
+	"databases2026/pkg/model"
+)
+
+var baseDate = time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)
+
+func generate(t *testing.T, opts seed.Options) *seed.Generator {
+	t.Helper()
+	g, err := seed.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func collect[T any](t *testing.T, rows iter.Seq[T]) []string {
+	t.Helper()
+	var out []string
+	for row := range rows {
+		b, err := json.Marshal(row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, string(b))
+	}
+	return out
+}
+
+// dump возвращает строки всех таблиц в JSON по именам таблиц.
+func dump(t *testing.T, g *seed.Generator) map[string][]string {
+	t.Helper()
+	return map[string][]string{
+		"users":            collect(t, g.Users()),
+		"coaches":          collect(t, g.Coaches()),
+		"sports":           collect(t, g.Sports()),
+		"classes":          collect(t, g.Classes()),
+		"rooms":            collect(t, g.Rooms()),
+		"schedules":        collect(t, g.Schedules()),
+		"memberships":      collect(t, g.Memberships()),
+		"user_memberships": collect(t, g.UserMemberships()),
+		"payments":         collect(t, g.Payments()),
+		"bookings":         collect(t, g.Bookings()),
+		"attendance_logs":  collect(t, g.AttendanceLogs()),
+		"reviews":          collect(t, g.Reviews()),
+		"promotions":       collect(t, g.Promotions()),
+		"promotion_usage":  collect(t, g.PromotionUsage()),
+		"notifications":    collect(t, g.Notifications()),
+		"loyalty_points":   collect(t, g.LoyaltyPoints()),
+		"referrals":        collect(t, g.Referrals()),
+		"audit_logs":       collect(t, g.AuditLogs()),
+		"system_settings":  collect(t, g.SystemSettings()),
+		"temp_bookings":    collect(t, g.TempBookings()),
+	}
+}
+
+func TestDeterministic(t *testing.T) {
+	opts := seed.Options{Seed: 7, Scale: 0.02, Now: baseDate}
+	first := dump(t, generate(t, opts))
+	second := dump(t, generate(t, opts))
+	counts := generate(t, opts).Counts()
+
+	for _, table := range seed.Tables {
+		if !slices.Equal(first[table], second[table]) {
+			t.Errorf("%s differs between two runs with the same options", table)
+		}
+		if want := *counts.Field(table); len(first[table]) != want {
+			t.Errorf("%s: %d rows, want %d", table, len(first[table]), want)
+		}
+	}
+
+	// Повторный обход того же генератора тоже даёт те же строки.
+	g := generate(t, opts)
+	if !slices.Equal(collect(t, g.Bookings()), collect(t, g.Bookings())) {
+		t.Error("bookings differ between two passes over one generator")
+	}
+}
+
+func TestSeedChangesData(t *testing.T) {
+	opts := seed.Options{Seed: 7, Scale: 0.02, Now: baseDate}
+	first := dump(t, generate(t, opts))
+	opts.Seed = 8
+	other := dump(t, generate(t, opts))
+
+	// Справочники и строки без случайных полей от seed не зависят.
+	for _, table := range []string{"schedules", "bookings", "payments", "reviews", "audit_logs", "temp_bookings"} {
+		if slices.Equal(first[table], other[table]) {
+			t.Errorf("%s is the same for seeds 7 and 8", table)
+		}
+	}
+}
+
+// Объём одной таблицы не сдвигает данные независимых от неё таблиц.
+func TestTablesAreIndependent(t *testing.T) {
+	opts := seed.Options{Seed: 7, Scale: 0.02, Now: baseDate}
+	first := dump(t, generate(t, opts))
+	opts.Counts = map[string]int{"notifications": 10}
+	other := dump(t, generate(t, opts))
+
+	for _, table := range []string{"schedules", "bookings", "reviews", "audit_logs"} {
+		if !slices.Equal(first[table], other[table]) {
+			t.Errorf("%s changed with the notifications count", table)
+		}
+	}
+	if !slices.Equal(first["notifications"][:10], other["notifications"]) {
+		t.Error("notifications are not a prefix of the larger run")
+	}
+}
+
+func TestPlanErrors(t *testing.T) {
+	for name, opts := range map[string]seed.Options{
+		"negative scale":       {Scale: -1},
+		"unknown table":        {Counts: map[string]int{"nope": 1}},
+		"negative count":       {Counts: map[string]int{"users": -1}},
+		"coaches beyond users": {Counts: map[string]int{"users": 5, "coaches": 6}},
+		"attendance beyond bookings": {
+			Counts: map[string]int{"bookings": 10, "attendance_logs": 11},
+		},
+		"schedules without rooms": {Counts: map[string]int{"rooms": 0}},
+	} {
+		if _, err := seed.New(opts); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
+
+// Гарантии из README проверяются на самих сгенерированных строках.
+func TestGuarantees(t *testing.T) {
+	g := generate(t, seed.Options{Seed: 7, Scale: 0.02, Now: baseDate})
+
+	capacity := map[int]int{}
+	for r := range g.Rooms() {
+		capacity[r.ID] = r.Capacity
+	}
+	coach := map[int]int{}
+	for c := range g.Classes() {
+		coach[c.ID] = c.CoachID
+	}
+	schedules := map[int]model.Schedule{}
+	byRoom, byCoach := map[int][]model.Schedule{}, map[int][]model.Schedule{}
+	for s := range g.Schedules() {
+		schedules[s.ID] = s
+		byRoom[s.RoomID] = append(byRoom[s.RoomID], s)
+		byCoach[coach[s.ClassID]] = append(byCoach[coach[s.ClassID]], s)
+	}
+	for what, groups := range map[string]map[int][]model.Schedule{"room": byRoom, "coach": byCoach} {
+		for id, list := range groups {
+			slices.SortFunc(list, func(a, b model.Schedule) int { return a.StartTime.Compare(b.StartTime) })
+			for i := 1; i < len(list); i++ {
+				if list[i].StartTime.Before(list[i-1].EndTime) {
+					t.Errorf("schedules %d and %d of %s %d overlap", list[i-1].ID, list[i].ID, what, id)
+				}
+			}
+		}
+	}
+
+	type pair struct{ user, schedule int }
+	booked := map[pair]bool{}
+	confirmed := map[int]int{}         // занятие -> подтверждённые брони
+	past := map[int][]model.Schedule{} // пользователь -> прошедшие занятия с подтверждённой бронью
+	for b := range g.Bookings() {
+		p := pair{b.UserID, b.ScheduleID}
+		if booked[p] {
+			t.Errorf("user %d booked schedule %d twice", b.UserID, b.ScheduleID)
+		}
+		booked[p] = true
+		if *b.Status != model.BookingConfirmed {
+			continue
+		}
+		s := schedules[b.ScheduleID]
+		confirmed[s.ID]++
+		if !s.EndTime.After(baseDate) {
+			past[b.UserID] = append(past[b.UserID], s)
+		}
+	}
+	for id, n := range confirmed {
+		if c := capacity[schedules[id].RoomID]; n > c {
+			t.Errorf("schedule %d: %d confirmed bookings, room has %d seats", id, n, c)
+		}
+	}
+
+	// Каждый визит — отдельная подтверждённая бронь прошедшего занятия.
+	attended := map[pair]bool{}
+	for a := range g.AttendanceLogs() {
+		i := slices.IndexFunc(past[a.UserID], func(s model.Schedule) bool {
+			return !attended[pair{a.UserID, s.ID}] &&
+				!a.StartTime.After(s.StartTime) && s.StartTime.Sub(a.StartTime) <= 15*time.Minute &&
+				!a.EndTime.Before(s.EndTime) && a.EndTime.Sub(s.EndTime) <= 30*time.Minute
+		})
+		if i < 0 {
+			t.Errorf("attendance log %d matches no confirmed booking of a past class", a.ID)
+			continue
+		}
+		attended[pair{a.UserID, past[a.UserID][i].ID}] = true
+	}
+
+	uses := map[int]int{}
+	for u := range g.PromotionUsage() {
+		uses[u.PromotionID]++
+	}
+	for p := range g.Promotions() {
+		if p.UsedCount == nil || *p.UsedCount != uses[p.ID] {
+			t.Errorf("promotion %d: used_count %v, %d usage rows", p.ID, p.UsedCount, uses[p.ID])
+		}
+	}
+
+	for tb := range g.TempBookings() {
+		s := schedules[tb.ScheduleID]
+		if !s.StartTime.After(baseDate) {
+			t.Errorf("temp booking %d holds schedule %d, which has started", tb.ID, s.ID)
+		}
+		if booked[pair{tb.UserID, s.ID}] {
+			t.Errorf("temp booking %d: user %d already booked schedule %d", tb.ID, tb.UserID, s.ID)
+		}
+		if !tb.ExpiresAt.After(baseDate) || tb.ExpiresAt.After(baseDate.Add(35*time.Minute)) {
+			t.Errorf("temp booking %d expires at %s", tb.ID, tb.ExpiresAt)
+		}
+	}
+}
+
+// Без Options.Now сроки удержаний считаются от момента генерации, а не от
+// конца истории, иначе serve удалил бы их сразу после загрузки.
+func TestHoldsExpireAfterLoad(t *testing.T) {
+	before := time.Now()
+	g := generate(t, seed.Options{Seed: 7, Scale: 0.02})
+	schedules := map[int]model.Schedule{}
+	for s := range g.Schedules() {
+		schedules[s.ID] = s
+	}
+	n := 0
+	for tb := range g.TempBookings() {
+		n++
+		if !tb.ExpiresAt.After(before) {
+			t.Errorf("temp booking %d expires at %s, before the load", tb.ID, tb.ExpiresAt)
+		}
+		if s := schedules[tb.ScheduleID]; !s.StartTime.After(before) {
+			t.Errorf("temp booking %d holds schedule %d starting at %s", tb.ID, s.ID, s.StartTime)
+		}
+	}
+	if n == 0 {
+		t.Fatal("no temp bookings generated")
+	}
+}
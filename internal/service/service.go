@@ -1,32 +1,107 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"databases2026/internal/handler"
+
+	"github.com/lib/pq"
 )
 
+// Отчёты возвращают строки результата; печатает их internal/presenter.
+
+// ReportError — ошибка отчёта: на каком шаге и с каким SQLSTATE он упал.
+type ReportError struct {
+	Report string
+	Stage  string // query, scan или rows
+	// SQLState — код ошибки PostgreSQL (например, 42P01 — нет таблицы);
+	// пусто, если ошибка не от сервера.
+	SQLState string
+	Err      error
+}
+
+func (e *ReportError) Error() string {
+	msg := fmt.Sprintf("report %s: %s failed", e.Report, e.Stage)
+	if e.SQLState != "" {
+		msg += fmt.Sprintf(" [%s %s]", e.SQLState, pq.ErrorCode(e.SQLState).Name())
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *ReportError) Unwrap() error {
+	return e.Err
+}
+
+func reportError(report, stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	e := &ReportError{Report: report, Stage: stage, Err: err}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		e.SQLState = string(pqErr.Code)
+	}
+	return e
+}
+
+// queryRows выполняет запрос отчёта report и сканирует каждую строку в новый T.
+func queryRows[T any](
+	ctx context.Context,
+	db handler.Executor,
+	report, query string,
+	scan func(*sql.Rows, *T) error,
+) ([]T, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, reportError(report, "query", err)
+	}
+	defer rows.Close()
+
+	result := []T{}
+	for rows.Next() {
+		var v T
+		if err := scan(rows, &v); err != nil {
+			return nil, reportError(report, "scan", err)
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, reportError(report, "rows", err)
+	}
+	return result, nil
+}
+
+// queryValue — queryRows для отчёта из одного значения.
+func queryValue[T any](ctx context.Context, db handler.Executor, report, query string) (T, error) {
+	var v T
+	err := db.QueryRowContext(ctx, query).Scan(&v)
+	return v, reportError(report, "query", err)
+}
+
 // =============== БИЗНЕС-ЗАПРОСЫ ===============
 
 // --- Агрегирующие (4) ---
-func GetTotalRevenue(db *sql.DB) float64 {
-	var total float64
-	db.QueryRow(`
+func GetTotalRevenue(ctx context.Context, db handler.Executor) (float64, error) {
+	return queryValue[float64](ctx, db, "TotalRevenue", `
 		SELECT COALESCE(SUM(amount), 0) FROM payments WHERE status = 'completed'
-	`).Scan(&total)
-	return total
+	`)
+}
+
+func GetAvgClassRating(ctx context.Context, db handler.Executor) (float64, error) {
+	return queryValue[float64](ctx, db, "AvgClassRating", "SELECT COALESCE(AVG(rating), 0) FROM reviews")
 }
 
-func GetAvgClassRating(db *sql.DB) float64 {
-	var avg float64
-	db.QueryRow("SELECT COALESCE(AVG(rating), 0) FROM reviews").Scan(&avg)
-	return avg
+// DayBookings — число броней на занятия одного дня.
+type DayBookings struct {
+	Day      time.Time `json:"day"`
+	Bookings int       `json:"bookings"`
 }
 
-func GetBookingsPerDay(db *sql.DB) {
+func GetBookingsPerDay(ctx context.Context, db handler.Executor) ([]DayBookings, error) {
 	const query = `
 		SELECT DATE(start_time) AS day, COUNT(*) AS bookings
 		FROM schedules s
@@ -36,18 +111,17 @@ func GetBookingsPerDay(db *sql.DB) {
 		LIMIT 7
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "BookingsPerDay", query, func(rows *sql.Rows, v *DayBookings) error {
+		return rows.Scan(&v.Day, &v.Bookings)
+	})
+}
 
-	for rows.Next() {
-		var day time.Time
-		var cnt int
-		rows.Scan(&day, &cnt)
-		fmt.Printf("📅 %s: %d bookings\n", day.Format("2006-01-02"), cnt)
-	}
+type SportVisits struct {
+	Sport  string `json:"sport"`
+	Visits int    `json:"visits"`
 }
 
-func GetTopSportsByAttendance(db *sql.DB) {
+func GetTopSportsByAttendance(ctx context.Context, db handler.Executor) ([]SportVisits, error) {
 	const query = `
 		SELECT sp.name, COUNT(*) AS visits
 		FROM attendance_logs al
@@ -60,19 +134,19 @@ func GetTopSportsByAttendance(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
-
-	for rows.Next() {
-		var name string
-		var visits int
-		rows.Scan(&name, &visits)
-		fmt.Printf("🏆 %s: %d visits\n", name, visits)
-	}
+	return queryRows(ctx, db, "TopSportsByAttendance", query, func(rows *sql.Rows, v *SportVisits) error {
+		return rows.Scan(&v.Sport, &v.Visits)
+	})
 }
 
 // --- Оконные функции (4) ---
-func GetUserRankByLoyalty(db *sql.DB) {
+type LoyaltyRank struct {
+	UserID int `json:"user_id"`
+	Points int `json:"points"`
+	Rank   int `json:"rank"`
+}
+
+func GetUserRankByLoyalty(ctx context.Context, db handler.Executor) ([]LoyaltyRank, error) {
 	const query = `
 		SELECT user_id, points,
 		RANK() OVER (ORDER BY points DESC) AS rank
@@ -81,17 +155,18 @@ func GetUserRankByLoyalty(db *sql.DB) {
 		LIMIT 10
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "UserRankByLoyalty", query, func(rows *sql.Rows, v *LoyaltyRank) error {
+		return rows.Scan(&v.UserID, &v.Points, &v.Rank)
+	})
+}
 
-	for rows.Next() {
-		var uid, pts, rank int
-		rows.Scan(&uid, &pts, &rank)
-		fmt.Printf("🏅 User %d: %d pts (rank %d)\n", uid, pts, rank)
-	}
+type RunningRevenue struct {
+	PaymentID    int     `json:"payment_id"`
+	Amount       float64 `json:"amount"`
+	RunningTotal float64 `json:"running_total"`
 }
 
-func GetRunningTotalRevenue(db *sql.DB) {
+func GetRunningTotalRevenue(ctx context.Context, db handler.Executor) ([]RunningRevenue, error) {
 	const query = `
 		SELECT id, amount, 
 		SUM(amount) OVER (ORDER BY id) AS running_total
@@ -101,18 +176,18 @@ func GetRunningTotalRevenue(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "RunningTotalRevenue", query, func(rows *sql.Rows, v *RunningRevenue) error {
+		return rows.Scan(&v.PaymentID, &v.Amount, &v.RunningTotal)
+	})
+}
 
-	for rows.Next() {
-		var id int
-		var amt, total float64
-		rows.Scan(&id, &amt, &total)
-		fmt.Printf("💰 Payment %d: $%.2f → Total: $%.2f\n", id, amt, total)
-	}
+type ClassBookings struct {
+	ClassID   int     `json:"class_id"`
+	Bookings  int     `json:"bookings"`
+	MovingAvg float64 `json:"moving_avg"`
 }
 
-func GetClassBookingsWithMovingAvg(db *sql.DB) {
+func GetClassBookingsWithMovingAvg(ctx context.Context, db handler.Executor) ([]ClassBookings, error) {
 	const query = `
 		SELECT c.id, COUNT(b.id) AS bookings,
 		AVG(COUNT(b.id)) OVER 
@@ -126,18 +201,18 @@ func GetClassBookingsWithMovingAvg(db *sql.DB) {
 		LIMIT 10
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "ClassBookingsWithMovingAvg", query, func(rows *sql.Rows, v *ClassBookings) error {
+		return rows.Scan(&v.ClassID, &v.Bookings, &v.MovingAvg)
+	})
+}
 
-	for rows.Next() {
-		var cid, bookings int
-		var avg float64
-		rows.Scan(&cid, &bookings, &avg)
-		fmt.Printf("📚 Class %d: %d bookings (avg: %.2f)\n", cid, bookings, avg)
-	}
+type CoachRating struct {
+	CoachID   int     `json:"coach_id"`
+	AvgRating float64 `json:"avg_rating"`
+	RowNumber int     `json:"row_number"`
 }
 
-func GetCoachRatingWithRowNumber(db *sql.DB) {
+func GetCoachRatingWithRowNumber(ctx context.Context, db handler.Executor) ([]CoachRating, error) {
 	const query = `
 		SELECT coach_id, AVG(rating) AS avg_rating,
 		ROW_NUMBER() OVER (ORDER BY AVG(rating) DESC) AS rn
@@ -149,20 +224,18 @@ func GetCoachRatingWithRowNumber(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
-
-	for rows.Next() {
-		var cid int
-		var avg float64
-		var rn int
-		rows.Scan(&cid, &avg, &rn)
-		fmt.Printf("👨‍🏫 Coach %d: %.2f ★ (rank %d)\n", cid, avg, rn)
-	}
+	return queryRows(ctx, db, "CoachRatingWithRowNumber", query, func(rows *sql.Rows, v *CoachRating) error {
+		return rows.Scan(&v.CoachID, &v.AvgRating, &v.RowNumber)
+	})
 }
 
 // --- JOIN 2 таблицы (2) ---
-func GetUsersWithLoyalty(db *sql.DB) {
+type UserLoyalty struct {
+	Email  string `json:"email"`
+	Points int    `json:"points"`
+}
+
+func GetUsersWithLoyalty(ctx context.Context, db handler.Executor) ([]UserLoyalty, error) {
 	const query = `
 		SELECT u.email, lp.points
 		FROM users u
@@ -170,18 +243,18 @@ func GetUsersWithLoyalty(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "UsersWithLoyalty", query, func(rows *sql.Rows, v *UserLoyalty) error {
+		return rows.Scan(&v.Email, &v.Points)
+	})
+}
 
-	for rows.Next() {
-		var email string
-		var pts int
-		rows.Scan(&email, &pts)
-		fmt.Printf("📧 %s → %d pts\n", email, pts)
-	}
+type ActiveMembership struct {
+	Email        string    `json:"email"`
+	DurationDays int       `json:"duration_days"`
+	StartedAt    time.Time `json:"started_at"`
 }
 
-func GetActiveMemberships(db *sql.DB) {
+func GetActiveMemberships(ctx context.Context, db handler.Executor) ([]ActiveMembership, error) {
 	const query = `
 		SELECT u.email, m.duration_days, um.started_at
 		FROM users u
@@ -191,27 +264,19 @@ func GetActiveMemberships(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, err := db.Query(query)
-	if err != nil {
-		log.Println("GetActiveMemberships query error:", err)
-		return
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var email string
-		var days int
-		var started time.Time
-		if err := rows.Scan(&email, &days, &started); err != nil {
-			log.Println("GetActiveMemberships scan error:", err)
-			continue
-		}
-		fmt.Printf("🎫 %s: %d days since %s\n", email, days, started.Format("2006-01-02"))
-	}
+	return queryRows(ctx, db, "ActiveMemberships", query, func(rows *sql.Rows, v *ActiveMembership) error {
+		return rows.Scan(&v.Email, &v.DurationDays, &v.StartedAt)
+	})
 }
 
 // --- JOIN 3 таблицы (4) ---
-func GetBookingsWithDetails(db *sql.DB) {
+type BookingDetails struct {
+	Email     string    `json:"email"`
+	Sport     string    `json:"sport"`
+	StartTime time.Time `json:"start_time"`
+}
+
+func GetBookingsWithDetails(ctx context.Context, db handler.Executor) ([]BookingDetails, error) {
 	const query = `
 		SELECT u.email, sp.name AS sport, s.start_time
 		FROM bookings b
@@ -222,18 +287,18 @@ func GetBookingsWithDetails(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "BookingsWithDetails", query, func(rows *sql.Rows, v *BookingDetails) error {
+		return rows.Scan(&v.Email, &v.Sport, &v.StartTime)
+	})
+}
 
-	for rows.Next() {
-		var email, sport string
-		var start time.Time
-		rows.Scan(&email, &sport, &start)
-		fmt.Printf("📅 %s booked %s at %s\n", email, sport, start.Format("15:04"))
-	}
+type PaymentMembership struct {
+	Email        string  `json:"email"`
+	Amount       float64 `json:"amount"`
+	DurationDays int     `json:"duration_days"`
 }
 
-func GetPaymentsWithMembership(db *sql.DB) {
+func GetPaymentsWithMembership(ctx context.Context, db handler.Executor) ([]PaymentMembership, error) {
 	const query = `
 		SELECT u.email, p.amount, m.duration_days
 		FROM payments p
@@ -243,19 +308,18 @@ func GetPaymentsWithMembership(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "PaymentsWithMembership", query, func(rows *sql.Rows, v *PaymentMembership) error {
+		return rows.Scan(&v.Email, &v.Amount, &v.DurationDays)
+	})
+}
 
-	for rows.Next() {
-		var email string
-		var amt float64
-		var days int
-		rows.Scan(&email, &amt, &days)
-		fmt.Printf("💳 %s paid $%.2f for %d-day plan\n", email, amt, days)
-	}
+type CoachReview struct {
+	Email  string `json:"email"`
+	Rating int    `json:"rating"`
+	Coach  string `json:"coach"`
 }
 
-func GetReviewsWithCoachInfo(db *sql.DB) {
+func GetReviewsWithCoachInfo(ctx context.Context, db handler.Executor) ([]CoachReview, error) {
 	const query = `
 		SELECT u.email, r.rating, 'Coach ' || r.coach_id AS coach
 		FROM reviews r
@@ -264,19 +328,18 @@ func GetReviewsWithCoachInfo(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
+	return queryRows(ctx, db, "ReviewsWithCoachInfo", query, func(rows *sql.Rows, v *CoachReview) error {
+		return rows.Scan(&v.Email, &v.Rating, &v.Coach)
+	})
+}
 
-	for rows.Next() {
-		var email string
-		var rating int
-		var coach string
-		rows.Scan(&email, &rating, &coach)
-		fmt.Printf("⭐ %s rated %s: %d\n", email, coach, rating)
-	}
+type ReferralReward struct {
+	Referrer string `json:"referrer"`
+	Referred string `json:"referred"`
+	Rewarded bool   `json:"rewarded"`
 }
 
-func GetReferralRewards(db *sql.DB) {
+func GetReferralRewards(ctx context.Context, db handler.Executor) ([]ReferralReward, error) {
 	const query = `
 		SELECT ref.email AS referrer, refd.email AS referred, r.rewarded
 		FROM referrals r
@@ -285,19 +348,20 @@ func GetReferralRewards(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
-
-	for rows.Next() {
-		var ref, refd string
-		var rewarded bool
-		rows.Scan(&ref, &refd, &rewarded)
-		fmt.Printf("🤝 %s referred %s (rewarded: %t)\n", ref, refd, rewarded)
-	}
+	return queryRows(ctx, db, "ReferralRewards", query, func(rows *sql.Rows, v *ReferralReward) error {
+		return rows.Scan(&v.Referrer, &v.Referred, &v.Rewarded)
+	})
 }
 
 // --- JOIN 4 таблицы (1) ---
-func GetScheduleWithRoomAndSport(db *sql.DB) {
+type ScheduleInfo struct {
+	StartTime time.Time `json:"start_time"`
+	Sport     string    `json:"sport"`
+	Capacity  int       `json:"capacity"`
+	CoachID   int       `json:"coach_id"`
+}
+
+func GetScheduleWithRoomAndSport(ctx context.Context, db handler.Executor) ([]ScheduleInfo, error) {
 	const query = `
 		SELECT s.start_time, sp.name AS sport, r.capacity, c.coach_id
 		FROM schedules s
@@ -307,22 +371,21 @@ func GetScheduleWithRoomAndSport(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
-
-	for rows.Next() {
-		var start time.Time
-		var sport string
-		var cap int
-		var coach int
-		rows.Scan(&start, &sport, &cap, &coach)
-		fmt.Printf("🏋️ %s at %s in room (cap %d) by coach %d\n",
-		          sport, start.Format("15:04"), cap, coach)
-	}
+	return queryRows(ctx, db, "ScheduleWithRoomAndSport", query, func(rows *sql.Rows, v *ScheduleInfo) error {
+		return rows.Scan(&v.StartTime, &v.Sport, &v.Capacity, &v.CoachID)
+	})
 }
 
 // --- JOIN 5 таблиц (1) ---
-func GetFullBookingInfo(db *sql.DB) {
+type FullBooking struct {
+	Email     string    `json:"email"`
+	Sport     string    `json:"sport"`
+	CoachID   int       `json:"coach_id"`
+	Capacity  int       `json:"capacity"`
+	StartTime time.Time `json:"start_time"`
+}
+
+func GetFullBookingInfo(ctx context.Context, db handler.Executor) ([]FullBooking, error) {
 	const query = `
 		SELECT u.email, sp.name AS sport, c.coach_id, r.capacity, s.start_time
 		FROM bookings b
@@ -334,15 +397,7 @@ func GetFullBookingInfo(db *sql.DB) {
 		LIMIT 5
 	`
 
-	rows, _ := db.Query(query)
-	defer rows.Close()
-
-	for rows.Next() {
-		var email, sport string
-		var coach, cap int
-		var start time.Time
-		rows.Scan(&email, &sport, &coach, &cap, &start)
-		fmt.Printf("✅ %s booked %s (coach %d) in room (cap %d) at %s\n",
-		          email, sport, coach, cap, start.Format("15:04"))
-	}
+	return queryRows(ctx, db, "FullBookingInfo", query, func(rows *sql.Rows, v *FullBooking) error {
+		return rows.Scan(&v.Email, &v.Sport, &v.CoachID, &v.Capacity, &v.StartTime)
+	})
 }
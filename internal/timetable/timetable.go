@@ -0,0 +1,153 @@
+// Package timetable — составление расписания: занятия в одном зале и
+// занятия одного тренера не должны пересекаться по времени.
+//
+// Запрет держит и сама схема (миграция 0003_schedule_overlap: exclusion
+// constraint по залу и триггер по тренеру), но её ошибка не говорит, с
+// каким занятием конфликт. Поэтому Create и Update сначала ищут
+// пересечения сами и возвращают *ConflictError с конфликтующим занятием.
+package timetable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/pkg/model"
+)
+
+var ErrConflict = errors.New("schedule conflict")
+
+// Ресурсы, за которые конфликтуют занятия.
+const (
+	ResourceRoom  = "room"
+	ResourceCoach = "coach"
+)
+
+// ConflictError — занятие пересекается с Clash в том же зале или у того же
+// тренера; errors.Is(err, ErrConflict).
+type ConflictError struct {
+	Resource   string // ResourceRoom или ResourceCoach
+	ResourceID int    // id зала или тренера
+	// ScheduleID — изменяемое занятие; 0 при создании.
+	ScheduleID int
+	Clash      model.Schedule
+}
+
+func (e *ConflictError) Error() string {
+	const layout = "2006-01-02 15:04"
+	what := "new schedule"
+	if e.ScheduleID != 0 {
+		what = fmt.Sprintf("schedule %d", e.ScheduleID)
+	}
+	return fmt.Sprintf("%s overlaps schedule %d of %s %d (%s – %s)", what, e.Clash.ID, e.Resource,
+		e.ResourceID, e.Clash.StartTime.Format(layout), e.Clash.EndTime.Format(layout))
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+type Service struct {
+	store repository.Store
+}
+
+func New(store repository.Store) *Service {
+	return &Service{store: store}
+}
+
+// Create добавляет занятие в расписание; при пересечении возвращает
+// *ConflictError.
+func (s *Service) Create(ctx context.Context, sched model.Schedule) (model.Schedule, error) {
+	var created model.Schedule
+	err := s.retry(ctx, func(tx repository.Store) error {
+		if err := conflicts(ctx, tx, sched); err != nil {
+			return err
+		}
+		var err error
+		created, err = tx.Schedules().Create(ctx, sched)
+		return err
+	})
+	return created, err
+}
+
+// Update меняет класс, зал или время занятия; при пересечении возвращает
+// *ConflictError.
+func (s *Service) Update(ctx context.Context, id int, p handler.SchedulePatch) (model.Schedule, error) {
+	var updated model.Schedule
+	err := s.retry(ctx, func(tx repository.Store) error {
+		sched, err := tx.Schedules().Lock(ctx, id)
+		if err != nil {
+			return err
+		}
+		if p.ClassID != nil {
+			sched.ClassID = *p.ClassID
+		}
+		if p.RoomID != nil {
+			sched.RoomID = *p.RoomID
+		}
+		if p.StartTime != nil {
+			sched.StartTime = *p.StartTime
+		}
+		if p.EndTime != nil {
+			sched.EndTime = *p.EndTime
+		}
+		if err := conflicts(ctx, tx, sched); err != nil {
+			return err
+		}
+		updated, err = tx.Schedules().Update(ctx, id, p)
+		return err
+	})
+	return updated, err
+}
+
+// retry выполняет fn в транзакции. Проверка в fn не видит параллельные
+// незакоммиченные занятия, и тогда пересечение ловит схема (ErrExclusion);
+// повторная попытка уже видит конфликтующее занятие и называет его.
+func (s *Service) retry(ctx context.Context, fn func(tx repository.Store) error) error {
+	err := s.store.InTx(ctx, fn)
+	if errors.Is(err, handler.ErrExclusion) {
+		err = s.store.InTx(ctx, fn)
+	}
+	return err
+}
+
+// conflicts ищет занятие, пересекающееся с sched в том же зале или у того
+// же тренера. Некорректные занятия (нет класса, конец раньше начала)
+// пропускает: их отклонят ограничения схемы.
+func conflicts(ctx context.Context, tx repository.Store, sched model.Schedule) error {
+	if !sched.EndTime.After(sched.StartTime) {
+		return nil
+	}
+	class, err := tx.Classes().Get(ctx, sched.ClassID)
+	if errors.Is(err, handler.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []struct {
+		resource string
+		id       int
+		filter   handler.ScheduleFilter
+	}{
+		{ResourceRoom, sched.RoomID, handler.ScheduleFilter{RoomID: sched.RoomID}},
+		{ResourceCoach, class.CoachID, handler.ScheduleFilter{CoachID: class.CoachID}},
+	} {
+		c.filter.From, c.filter.To = sched.StartTime, sched.EndTime
+		// Само изменяемое занятие тоже попадает в выборку, поэтому две строки.
+		c.filter.Page = handler.Page{Limit: 2}
+		found, err := tx.Schedules().List(ctx, c.filter)
+		if err != nil {
+			return err
+		}
+		for _, clash := range found {
+			if clash.ID != sched.ID {
+				return &ConflictError{Resource: c.resource, ResourceID: c.id, ScheduleID: sched.ID, Clash: clash}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package timetable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"databases2026/internal/handler"
+	"databases2026/internal/repository"
+	"databases2026/internal/repository/memory"
+	"databases2026/internal/timetable"
+	"databases2026/pkg/model"
+)
+
+var start = time.Date(2030, 1, 1, 10, 0, 0, 0, time.UTC)
+
+// fixture — два тренера с классом у каждого и два зала.
+type fixture struct {
+	st      repository.Store
+	svc     *timetable.Service
+	coaches [2]int
+	classes [2]int
+	rooms   [2]int
+}
+
+func setup(t *testing.T) fixture {
+	t.Helper()
+	ctx := context.Background()
+	st := memory.New()
+	f := fixture{st: st, svc: timetable.New(st)}
+	noErr := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sport, err := st.Sports().Create(ctx, model.Sport{Name: "Yoga"})
+	noErr(err)
+	for i := range 2 {
+		u, err := st.Users().Create(ctx, model.User{Email: fmt.Sprintf("coach%d@example.com", i)})
+		noErr(err)
+		c, err := st.Coaches().Create(ctx, model.Coach{UserID: u.ID})
+		noErr(err)
+		class, err := st.Classes().Create(ctx, model.Class{SportID: sport.ID, CoachID: c.UserID})
+		noErr(err)
+		room, err := st.Rooms().Create(ctx, model.Room{Capacity: 10})
+		noErr(err)
+		f.coaches[i], f.classes[i], f.rooms[i] = c.UserID, class.ID, room.ID
+	}
+	return f
+}
+
+// create добавляет занятие класса class в зале room с start+from до
+// start+to.
+func (f fixture) create(class, room int, from, to time.Duration) (model.Schedule, error) {
+	return f.svc.Create(context.Background(), model.Schedule{
+		ClassID:   class,
+		RoomID:    room,
+		StartTime: start.Add(from),
+		EndTime:   start.Add(to),
+	})
+}
+
+func TestCreate(t *testing.T) {
+	tests := []struct {
+		name string
+		// Класс, зал (индексы в fixture) и время второго занятия; первое —
+		// класс 0 в зале 0 с start до start+2ч.
+		class, room int
+		from, to    time.Duration
+		resource    string // "" — пересечения нет
+	}{
+		{name: "room clash", class: 1, room: 0, from: time.Hour, to: 3 * time.Hour, resource: timetable.ResourceRoom},
+		{name: "coach clash", class: 0, room: 1, from: time.Hour, to: 3 * time.Hour, resource: timetable.ResourceCoach},
+		{name: "same slot", class: 0, room: 0, from: 0, to: 2 * time.Hour, resource: timetable.ResourceRoom},
+		{name: "inside", class: 1, room: 0, from: 30 * time.Minute, to: time.Hour, resource: timetable.ResourceRoom},
+		{name: "adjacent in the room", class: 1, room: 0, from: 2 * time.Hour, to: 3 * time.Hour},
+		{name: "adjacent for the coach", class: 0, room: 1, from: -time.Hour, to: 0},
+		{name: "other room and coach", class: 1, room: 1, from: time.Hour, to: 3 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setup(t)
+			first, err := f.create(f.classes[0], f.rooms[0], 0, 2*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = f.create(f.classes[tt.class], f.rooms[tt.room], tt.from, tt.to)
+			if tt.resource == "" {
+				if err != nil {
+					t.Fatalf("Create: %v", err)
+				}
+				return
+			}
+
+			want := &timetable.ConflictError{Resource: tt.resource, Clash: first}
+			if tt.resource == timetable.ResourceRoom {
+				want.ResourceID = f.rooms[0]
+			} else {
+				want.ResourceID = f.coaches[0]
+			}
+			checkConflict(t, err, want)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	first, err := f.create(f.classes[0], f.rooms[0], 0, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := f.create(f.classes[1], f.rooms[1], 0, 2*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Перенос в занятый зал.
+	_, err = f.svc.Update(ctx, second.ID, handler.SchedulePatch{RoomID: &f.rooms[0]})
+	checkConflict(t, err, &timetable.ConflictError{
+		Resource: timetable.ResourceRoom, ResourceID: f.rooms[0], ScheduleID: second.ID, Clash: first,
+	})
+
+	// Смена класса на класс занятого тренера.
+	_, err = f.svc.Update(ctx, second.ID, handler.SchedulePatch{ClassID: &f.classes[0]})
+	checkConflict(t, err, &timetable.ConflictError{
+		Resource: timetable.ResourceCoach, ResourceID: f.coaches[0], ScheduleID: second.ID, Clash: first,
+	})
+
+	// Сдвиг внутри собственного времени с самим собой не конфликтует.
+	later := first.StartTime.Add(time.Hour)
+	moved, err := f.svc.Update(ctx, first.ID, handler.SchedulePatch{StartTime: &later})
+	if err != nil {
+		t.Fatalf("Update within its own slot: %v", err)
+	}
+	if !moved.StartTime.Equal(later) {
+		t.Fatalf("StartTime = %s, want %s", moved.StartTime, later)
+	}
+
+	// Отказ ничего не меняет.
+	got, err := f.st.Schedules().Get(ctx, second.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RoomID != second.RoomID || got.ClassID != second.ClassID {
+		t.Fatalf("schedule after rejected updates = %+v, want %+v", got, second)
+	}
+}
+
+func checkConflict(t *testing.T, err error, want *timetable.ConflictError) {
+	t.Helper()
+	if !errors.Is(err, timetable.ErrConflict) {
+		t.Fatalf("got %v, want ErrConflict", err)
+	}
+	var cerr *timetable.ConflictError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("got %T, want *ConflictError", err)
+	}
+	if cerr.Resource != want.Resource || cerr.ResourceID != want.ResourceID ||
+		cerr.ScheduleID != want.ScheduleID || cerr.Clash.ID != want.Clash.ID {
+		t.Fatalf("got %+v, want %+v", cerr, want)
+	}
+	if !cerr.Clash.StartTime.Equal(want.Clash.StartTime) || !cerr.Clash.EndTime.Equal(want.Clash.EndTime) {
+		t.Fatalf("clash %+v, want %+v", cerr.Clash, want.Clash)
+	}
+	if msg := fmt.Sprintf("overlaps schedule %d of %s %d", want.Clash.ID, want.Resource, want.ResourceID); !strings.Contains(err.Error(), msg) {
+		t.Fatalf("error %q does not name the clash (%q)", err, msg)
+	}
+}
@@ -1,9 +1,206 @@
 package model
 
+import "time"
+
 type DbConnectionSettings struct {
 	Host         string
 	Port         int
 	User         string
 	Password     string
 	DataBaseName string
+
+	// SSLMode: disable, require, verify-ca, verify-full (пусто = disable).
+	SSLMode     string
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	ConnectTimeout   time.Duration
+	StatementTimeout time.Duration
+	SearchPath       string
+	// ApplicationName видно в pg_stat_activity.
+	ApplicationName string
+
+	// Параметры пула *sql.DB; нулевые значения оставляют умолчания database/sql.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// PingAttempts — сколько раз пробовать первый Ping (минимум 1),
+	// PingBackoff — начальная пауза между попытками, удваивается каждый раз.
+	PingAttempts int
+	PingBackoff  time.Duration
+}
+
+// Ниже — строки таблиц из configs/sql/migrations. Столбцы без NOT NULL
+// представлены указателями: nil соответствует NULL (а при вставке — DEFAULT).
+
+const (
+	BookingConfirmed = "confirmed"
+	BookingCancelled = "cancelled"
+
+	PaymentCompleted = "completed"
+	PaymentFailed    = "failed"
+)
+
+// 1. users
+type User struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+// 2. coaches
+type Coach struct {
+	UserID int `json:"user_id"`
+}
+
+// 3. sports
+type Sport struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// 4. classes
+type Class struct {
+	ID      int `json:"id"`
+	SportID int `json:"sport_id"`
+	CoachID int `json:"coach_id"`
+}
+
+// 5. rooms
+type Room struct {
+	ID       int `json:"id"`
+	Capacity int `json:"capacity"`
+}
+
+// 6. schedules
+type Schedule struct {
+	ID        int       `json:"id"`
+	ClassID   int       `json:"class_id"`
+	RoomID    int       `json:"room_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// 7. bookings
+type Booking struct {
+	ID         int     `json:"id"`
+	UserID     int     `json:"user_id"`
+	ScheduleID int     `json:"schedule_id"`
+	Status     *string `json:"status"`
+}
+
+// 8. memberships
+type Membership struct {
+	ID           int     `json:"id"`
+	DurationDays int     `json:"duration_days"`
+	Price        float64 `json:"price"`
+}
+
+// 9. user_memberships
+type UserMembership struct {
+	ID           int       `json:"id"`
+	UserID       int       `json:"user_id"`
+	MembershipID int       `json:"membership_id"`
+	StartedAt    time.Time `json:"started_at"`
+	EndedAt      time.Time `json:"ended_at"`
+	IsActive     *bool     `json:"is_active"`
+}
+
+// 10. payments
+type Payment struct {
+	ID     int     `json:"id"`
+	UserID int     `json:"user_id"`
+	Amount float64 `json:"amount"`
+	Status *string `json:"status"`
+}
+
+// 11. attendance_logs
+type AttendanceLog struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// 12. reviews
+type Review struct {
+	ID      int  `json:"id"`
+	UserID  int  `json:"user_id"`
+	CoachID *int `json:"coach_id"`
+	ClassID *int `json:"class_id"`
+	Rating  int  `json:"rating"`
+}
+
+// 13. promotions
+type Promotion struct {
+	ID              int       `json:"id"`
+	Code            string    `json:"code"`
+	DiscountPercent int       `json:"discount_percent"`
+	ValidFrom       time.Time `json:"valid_from"`
+	ValidUntil      time.Time `json:"valid_until"`
+	MaxUses         *int      `json:"max_uses"`
+	UsedCount       *int      `json:"used_count"`
+}
+
+// 14. promotion_usage
+type PromotionUsage struct {
+	ID          int `json:"id"`
+	UserID      int `json:"user_id"`
+	PromotionID int `json:"promotion_id"`
+}
+
+// 15. notifications
+type Notification struct {
+	ID     int   `json:"id"`
+	UserID int   `json:"user_id"`
+	IsRead *bool `json:"is_read"`
+}
+
+// 16. loyalty_points
+type LoyaltyPoints struct {
+	UserID int  `json:"user_id"`
+	Points *int `json:"points"`
+}
+
+// 17. referrals
+type Referral struct {
+	ID         int   `json:"id"`
+	ReferrerID int   `json:"referrer_id"`
+	ReferredID int   `json:"referred_id"`
+	Rewarded   *bool `json:"rewarded"`
+}
+
+// 18. audit_logs
+type AuditLog struct {
+	ID          int        `json:"id"`
+	UserID      *int       `json:"user_id"`
+	Action      string     `json:"action"`
+	EntityType  *string    `json:"entity_type"`
+	EntityID    *int       `json:"entity_id"`
+	PerformedAt *time.Time `json:"performed_at"`
+}
+
+// 19. system_settings
+type SystemSetting struct {
+	Key   string  `json:"key"`
+	Value *string `json:"value"`
+}
+
+// 20. temp_bookings
+type TempBooking struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	ScheduleID int       `json:"schedule_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Token      string    `json:"token"`
+}
+
+// 21. waitlist
+type WaitlistEntry struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	ScheduleID int        `json:"schedule_id"`
+	CreatedAt  *time.Time `json:"created_at"`
 }